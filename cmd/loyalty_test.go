@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository/postgres"
+)
+
+// serve sends one request with an optional JSON body, or a merge patch for
+// PATCH, to the router.
+func serve(router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	switch {
+	case body != "" && method == "PATCH":
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+	case body != "":
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestLoyaltyEarnedOnce closes an order, reopens it in the database and
+// closes it again: the customer is credited for the first close only.
+func TestLoyaltyEarnedOnce(t *testing.T) {
+	conn := startPostgres(t)
+	router := newRouter(postgres.New(conn), 60, time.UTC)
+
+	rec := serve(router, "POST", "/orders", `{"customer_id":3,"payment_method":"cash","items":[{"menu_item_id":"8","quantity":2}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create order: status %d\n%s", rec.Code, rec.Body)
+	}
+	var receipt struct {
+		OrderID int `json:"order_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &receipt); err != nil {
+		t.Fatal(err)
+	}
+
+	closeOrder := func() {
+		t.Helper()
+		rec := serve(router, "POST", fmt.Sprintf("/orders/%d/close", receipt.OrderID), "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("close order: status %d\n%s", rec.Code, rec.Body)
+		}
+	}
+	account := func() db.LoyaltyAccount {
+		t.Helper()
+		rec := serve(router, "GET", "/customers/3/loyalty", "")
+		var account db.LoyaltyAccount
+		if err := json.Unmarshal(rec.Body.Bytes(), &account); err != nil {
+			t.Fatalf("loyalty: %v\n%s", err, rec.Body)
+		}
+		return account
+	}
+
+	before := account()
+	closeOrder()
+	earned := account()
+	if earned.Balance <= before.Balance {
+		t.Fatalf("closing the order earned nothing: balance %d, was %d", earned.Balance, before.Balance)
+	}
+
+	if _, err := conn.Exec("UPDATE orders SET status = 'open' WHERE id = $1", receipt.OrderID); err != nil {
+		t.Fatal(err)
+	}
+	closeOrder()
+	again := account()
+	if again.Balance != earned.Balance || again.Punches != earned.Punches {
+		t.Fatalf("closing again changed the account: %d points and %d punches, want %d and %d",
+			again.Balance, again.Punches, earned.Balance, earned.Punches)
+	}
+
+	var rows int
+	err := conn.QueryRow(`SELECT COUNT(*) FROM loyalty_transactions
+		WHERE order_id = $1 AND transaction_type = 'earned'`, receipt.OrderID).Scan(&rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 1 {
+		t.Fatalf("%d 'earned' rows for the order, want 1", rows)
+	}
+}
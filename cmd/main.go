@@ -1,61 +1,67 @@
 package main
 
 import (
+    "context"
+    "flag"
+    "fmt"
     "log"
     "net/http"
 
     "frappuccino/internal/config"
     "frappuccino/internal/db"
-    "frappuccino/internal/handlers"
+    "frappuccino/internal/migrations"
+    "frappuccino/internal/repository"
+    "frappuccino/internal/repository/memory"
+    "frappuccino/internal/repository/postgres"
 )
 
 func main() {
-    // Загружаем конфигурацию базы данных
-    cfg := config.LoadConfig()
+    configPath := flag.String("config", "", "path to a JSON or YAML config file")
+    flag.Usage = func() { fmt.Fprintln(flag.CommandLine.Output(), usage) }
+    flag.Parse()
 
-    // Подключаемся к базе данных
-    dbConn, err := db.Connect(cfg.DB)
+    // Загружаем конфигурацию
+    cfg, err := config.LoadConfig(*configPath)
     if err != nil {
-        log.Fatalf("Failed to connect to the database: %v", err)
+        log.Fatal(err)
     }
-    defer dbConn.Close()
-
-    // Регистрируем обработчики
-    http.HandleFunc("GET /orders", handlers.GetOrders(dbConn))
-    http.HandleFunc("POST /orders", handlers.CreateOrder(dbConn))
-    http.HandleFunc("DELETE /orders/", handlers.DeleteOrder(dbConn))
-    http.HandleFunc("GET /orders/", handlers.GetOrderByID(dbConn))
-    http.HandleFunc("PUT /orders/", handlers.UpdateOrderByID(dbConn))
-    http.HandleFunc("POST /orders/close/", handlers.CloseOrder(dbConn))
-
-    // Inventory routes
-    http.HandleFunc("GET /inventory", handlers.GetInventoryItems(dbConn))
-    http.HandleFunc("POST /inventory", handlers.CreateInventoryItem(dbConn))
-    http.HandleFunc("GET /inventory/", handlers.GetInventoryItemByID(dbConn))
-    http.HandleFunc("PUT /inventory/", handlers.UpdateInventoryItem(dbConn))
-    http.HandleFunc("DELETE /inventory/", handlers.DeleteInventoryItem(dbConn))
-    // http.HandleFunc("POST /inventory/restock/", handlers.RestockInventoryItem(dbConn))
-
-    // Menu Items routes
-    http.HandleFunc("GET /menu", handlers.GetMenuItems(dbConn))
-    http.HandleFunc("POST /menu", handlers.CreateMenuItem(dbConn))
-    http.HandleFunc("GET /menu/", handlers.GetMenuItemByID(dbConn))
-    http.HandleFunc("PUT /menu/", handlers.UpdateMenuItem(dbConn))
-    http.HandleFunc("DELETE /menu/", handlers.DeleteMenuItem(dbConn))
-    // http.HandleFunc("POST /menu_items/toggle/", handlers.ToggleMenuItemAvailability(dbConn))
-
-
-    // Report routes
-    http.HandleFunc("GET /reports/total-sales", handlers.TotalAmount(dbConn))
-    http.HandleFunc("GET /reports/popular-items", handlers.PopularItems(dbConn))
-
-    http.HandleFunc("GET /orders/numberOfOrderedItems", handlers.GetNumberOfOrderedItems(dbConn))
-
-    http.HandleFunc("GET /reports/search", handlers.FullTextSearchReport(dbConn))
-    http.HandleFunc("GET /reports/orderedItemsByPeriod", handlers.OrderedItemsByPeriod(dbConn))
-    http.HandleFunc("POST /orders/batch-process", handlers.BulkOrderProcess(dbConn))
-    http.HandleFunc("GET /inventory/getLeftOvers", handlers.GetLeftovers(dbConn))
-        // Запускаем HTTP-сервер    
-    log.Println("Server is running on port 8080...")
-    log.Fatal(http.ListenAndServe(":8080", nil))
+
+    // Собираем репозитории
+    var repos *repository.Repositories
+    if cfg.Storage == config.StorageMemory {
+        if len(flag.Args()) > 0 {
+            log.Fatal("Commands need the postgres storage")
+        }
+        log.Println("Using the in-memory store; data is lost when the server stops")
+        repos = memory.New()
+    } else {
+        // Подключаемся к базе данных
+        dbConn, err := db.Connect(db.Config(cfg.DB))
+        if err != nil {
+            log.Fatalf("Failed to connect to the database: %v", err)
+        }
+        defer dbConn.Close()
+
+        if args := flag.Args(); len(args) > 0 {
+            if err := runCommand(context.Background(), dbConn, args); err != nil {
+                log.Fatal(err)
+            }
+            return
+        }
+
+        if cfg.AutoMigrate {
+            applied, err := migrations.Up(context.Background(), dbConn)
+            if err != nil {
+                log.Fatalf("Failed to migrate the database: %v", err)
+            }
+            for _, m := range applied {
+                log.Printf("Applied migration %d_%s", m.Version, m.Name)
+            }
+        }
+        repos = postgres.New(dbConn)
+    }
+
+    // Запускаем HTTP-сервер
+    log.Printf("Server is running on %s...", cfg.Addr)
+    log.Fatal(http.ListenAndServe(cfg.Addr, newRouter(repos, cfg.MarginThreshold, cfg.Location)))
 }
\ No newline at end of file
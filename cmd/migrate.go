@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"frappuccino/internal/migrations"
+)
+
+const usage = `usage: main [--config file] [command]
+
+Without a command the HTTP server starts. Commands:
+  migrate up          apply every pending migration
+  migrate down [n]    revert the last n migrations (1 by default)
+  migrate status      list migrations and when they were applied
+  seed                load the demo data into an empty database`
+
+// runCommand runs a maintenance command given on the command line.
+func runCommand(ctx context.Context, dbConn *sql.DB, args []string) error {
+	switch {
+	case args[0] == "seed" && len(args) == 1:
+		if err := migrations.Seed(ctx, dbConn); errors.Is(err, migrations.ErrAlreadySeeded) {
+			log.Println("Seed skipped:", err)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		log.Println("Demo data loaded")
+		return nil
+
+	case args[0] == "migrate" && len(args) == 2 && args[1] == "up":
+		applied, err := migrations.Up(ctx, dbConn)
+		for _, m := range applied {
+			log.Printf("Applied %d_%s", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			log.Println("Schema is up to date")
+		}
+		return err
+
+	case args[0] == "migrate" && len(args) <= 3 && len(args) >= 2 && args[1] == "down":
+		steps := 1
+		if len(args) == 3 {
+			n, err := strconv.Atoi(args[2])
+			if err != nil || n < 1 {
+				return errors.New("migrate down takes a positive number of steps")
+			}
+			steps = n
+		}
+		reverted, err := migrations.Down(ctx, dbConn, steps)
+		for _, m := range reverted {
+			log.Printf("Reverted %d_%s", m.Version, m.Name)
+		}
+		return err
+
+	case args[0] == "migrate" && len(args) == 2 && args[1] == "status":
+		statuses, err := migrations.Statuses(ctx, dbConn)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	}
+	return errors.New(usage)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/migrations"
+)
+
+// postgresBin returns the directory holding initdb and pg_ctl: $PG_BIN, the
+// directory of initdb on $PATH, or the newest Debian-style install under
+// /usr/lib/postgresql. It returns "" when there is none.
+func postgresBin() string {
+	if dir := os.Getenv("PG_BIN"); dir != "" {
+		return dir
+	}
+	if path, err := exec.LookPath("initdb"); err == nil {
+		return filepath.Dir(path)
+	}
+	dirs, _ := filepath.Glob("/usr/lib/postgresql/*/bin")
+	sort.Strings(dirs)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if _, err := os.Stat(filepath.Join(dirs[i], "initdb")); err == nil {
+			return dirs[i]
+		}
+	}
+	return ""
+}
+
+// startPostgres returns a connection to a throwaway database with the schema
+// migrated and the demo data seeded. With $DATABASE_URL set the database is
+// created on that server and dropped when the test ends; otherwise a
+// Postgres server is started in a temporary directory, listening only on a
+// unix socket, and stopped when the test ends. The test is skipped when
+// neither is possible.
+func startPostgres(t *testing.T) *sql.DB {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("starting Postgres is skipped in short mode")
+	}
+	var conn *sql.DB
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		conn = createDatabase(t, url)
+	} else {
+		conn = startServer(t)
+	}
+
+	ctx := context.Background()
+	if _, err := migrations.Up(ctx, conn); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := migrations.Seed(ctx, conn); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	return conn
+}
+
+// lastDatabase numbers the databases created by createDatabase.
+var lastDatabase int
+
+// createDatabase creates an empty database on the server of the connection
+// string dsn, a URL or key=value pairs, and returns a connection to it.
+func createDatabase(t *testing.T, dsn string) *sql.DB {
+	t.Helper()
+	server, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	lastDatabase++
+	name := fmt.Sprintf("frappuccino_test_%d_%d", os.Getpid(), lastDatabase)
+	if _, err := server.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { server.Exec("DROP DATABASE IF EXISTS " + name) })
+
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		u.Path = "/" + name
+		dsn = u.String()
+	} else {
+		dsn += " dbname=" + name
+	}
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	// Registered after the drop, so it runs first and the drop finds no
+	// open connections
+	t.Cleanup(func() { conn.Close() })
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	return conn
+}
+
+// startServer starts a Postgres server in a temporary directory. Postgres
+// refuses to run as root, so as root the server runs as $PG_USER, or nobody
+// when it is not set.
+func startServer(t *testing.T) *sql.DB {
+	t.Helper()
+	bin := postgresBin()
+	if bin == "" {
+		t.Skip("initdb not found; install Postgres, set PG_BIN or set DATABASE_URL")
+	}
+
+	dir, err := os.MkdirTemp("", "frappuccino-pg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	var credential *syscall.Credential
+	if os.Geteuid() == 0 {
+		name := os.Getenv("PG_USER")
+		if name == "" {
+			name = "nobody"
+		}
+		credential, err = lookupCredential(name)
+		if err != nil {
+			t.Skipf("Postgres refuses to run as root and user %s cannot run it: %v", name, err)
+		}
+		if err := os.Chown(dir, int(credential.Uid), int(credential.Gid)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := filepath.Join(dir, "data")
+	command := func(name string, args ...string) *exec.Cmd {
+		cmd := exec.Command(filepath.Join(bin, name), args...)
+		cmd.Dir = dir
+		if credential != nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{Credential: credential}
+		}
+		return cmd
+	}
+	run := func(name string, args ...string) {
+		t.Helper()
+		out, err := command(name, args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", name, err, out)
+		}
+	}
+	run("initdb", "-D", data, "-U", "postgres", "--auth=trust", "-E", "UTF8", "--no-locale")
+	run("pg_ctl", "-D", data, "-l", filepath.Join(dir, "postgres.log"), "-w",
+		"-o", "-k "+dir+" -c listen_addresses= -c fsync=off", "start")
+	t.Cleanup(func() {
+		command("pg_ctl", "-D", data, "-m", "immediate", "stop").Run()
+	})
+
+	conn, err := db.Connect(db.Config{
+		Host:           dir,
+		Port:           "5432",
+		User:           "postgres",
+		Name:           "postgres",
+		SSLMode:        "disable",
+		TimeZone:       "UTC",
+		ConnectTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// lookupCredential returns the user and group ids of a system user.
+func lookupCredential(name string) (*syscall.Credential, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"frappuccino/internal/repository/postgres"
+)
+
+// TestReceiveAfterUnitChange changes the stock unit of cream, which is batch
+// tracked and on the open purchase order 1, from l to ml and then receives
+// the order: the 12 l ordered arrive as 12000 ml and every batch is in ml.
+func TestReceiveAfterUnitChange(t *testing.T) {
+	conn := startPostgres(t)
+	router := newRouter(postgres.New(conn), 60, time.UTC)
+
+	type levels struct {
+		stock, batches, reorder, par float64
+		count                        int
+	}
+	check := func(step string, want levels) {
+		t.Helper()
+		var got levels
+		err := conn.QueryRow(`
+			SELECT i.stock, COALESCE(SUM(b.quantity), 0), i.reorder_level, i.par_level, COUNT(b.id)
+			FROM inventory i
+			LEFT JOIN inventory_batches b ON b.inventory_id = i.id
+			WHERE i.id = '18'
+			GROUP BY i.id`).Scan(&got.stock, &got.batches, &got.reorder, &got.par, &got.count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("%s: stock %v, batches %v in %d, reorder at %v up to %v; want %+v",
+				step, got.stock, got.batches, got.count, got.reorder, got.par, want)
+		}
+	}
+
+	check("seeded", levels{stock: 30, batches: 30, reorder: 15, par: 40, count: 1})
+	rec := serve(router, "PATCH", "/inventory/18", `{"unit_type":"ml","stock":30000}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("change unit: status %d\n%s", rec.Code, rec.Body)
+	}
+	check("unit changed", levels{stock: 30000, batches: 30000, reorder: 15000, par: 40000, count: 1})
+
+	var quantity, unitCost float64
+	err := conn.QueryRow(`SELECT quantity, unit_cost FROM purchase_order_items
+		WHERE purchase_order_id = 1 AND inventory_id = '18'`).Scan(&quantity, &unitCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quantity != 12000 || unitCost != 0.0028 {
+		t.Fatalf("purchase order line: %v at %v, want 12000 at 0.0028", quantity, unitCost)
+	}
+
+	rec = serve(router, "POST", "/purchase-orders/1/receive", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("receive: status %d\n%s", rec.Code, rec.Body)
+	}
+	check("received", levels{stock: 42000, batches: 42000, reorder: 15000, par: 40000, count: 2})
+
+	rec = serve(router, "PATCH", "/inventory/18", `{"unit_type":"kg","stock":0}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("change to another dimension: status %d, want 409\n%s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"frappuccino/internal/handlers"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/service"
+)
+
+// newRouter registers every route of the API on a new mux. Ids are {id}
+// wildcards read with r.PathValue; literal segments such as
+// /orders/numberOfOrderedItems take precedence over them. Routes whose
+// repository the storage does not provide are left out; literal paths among
+// them answer 404 rather than reach an {id} route.
+func newRouter(repos *repository.Repositories, marginThreshold float64, loc *time.Location) http.Handler {
+	orders := service.NewOrders(repos.Orders, loc)
+	menu := service.NewMenu(repos.Menu)
+	inventory := service.NewInventory(repos.Inventory)
+	customers := service.NewCustomers(repos.Customers)
+
+	mux := http.NewServeMux()
+
+	// Order routes
+	mux.HandleFunc("GET /orders", handlers.GetOrders(orders))
+	mux.HandleFunc("POST /orders", handlers.CreateOrder(orders))
+	mux.HandleFunc("DELETE /orders/{id}", handlers.DeleteOrder(orders))
+	mux.HandleFunc("GET /orders/{id}", handlers.GetOrderByID(orders))
+	mux.HandleFunc("PUT /orders/{id}", handlers.UpdateOrderByID(orders))
+	mux.HandleFunc("PATCH /orders/{id}", handlers.PatchOrder(orders))
+	mux.HandleFunc("POST /orders/{id}/close", handlers.CloseOrder(orders))
+	mux.HandleFunc("POST /orders/batch-process", handlers.BulkOrderProcess(orders))
+
+	// Inventory routes
+	mux.HandleFunc("GET /inventory", handlers.GetInventoryItems(inventory))
+	mux.HandleFunc("POST /inventory", handlers.CreateInventoryItem(inventory))
+	mux.HandleFunc("GET /inventory/{id}", handlers.GetInventoryItemByID(inventory))
+	mux.HandleFunc("PUT /inventory/{id}", handlers.UpdateInventoryItem(inventory))
+	mux.HandleFunc("PATCH /inventory/{id}", handlers.PatchInventoryItem(inventory))
+	mux.HandleFunc("DELETE /inventory/{id}", handlers.DeleteInventoryItem(inventory))
+	mux.HandleFunc("POST /inventory/{id}/restock", handlers.RestockInventoryItem(inventory))
+	mux.HandleFunc("GET /inventory/expiring", handlers.GetExpiringBatches(inventory))
+	mux.HandleFunc("POST /inventory/batches/{id}/write-off", handlers.WriteOffBatch(inventory))
+
+	// Supplier routes
+	if repos.Suppliers != nil {
+		mux.HandleFunc("GET /suppliers", handlers.GetSuppliers(repos.Suppliers))
+		mux.HandleFunc("POST /suppliers", handlers.CreateSupplier(repos.Suppliers))
+		mux.HandleFunc("GET /suppliers/{id}", handlers.GetSupplierByID(repos.Suppliers))
+		mux.HandleFunc("PUT /suppliers/{id}", handlers.UpdateSupplier(repos.Suppliers))
+		mux.HandleFunc("DELETE /suppliers/{id}", handlers.DeleteSupplier(repos.Suppliers))
+	}
+
+	// Purchase order routes
+	if repos.PurchaseOrders != nil {
+		mux.HandleFunc("GET /purchase-orders", handlers.GetPurchaseOrders(repos.PurchaseOrders))
+		mux.HandleFunc("POST /purchase-orders", handlers.CreatePurchaseOrder(repos.PurchaseOrders))
+		mux.HandleFunc("GET /purchase-orders/suggested", handlers.SuggestedPurchaseOrders(repos.PurchaseOrders))
+		mux.HandleFunc("GET /purchase-orders/{id}", handlers.GetPurchaseOrderByID(repos.PurchaseOrders))
+		mux.HandleFunc("PUT /purchase-orders/{id}", handlers.UpdatePurchaseOrder(repos.PurchaseOrders))
+		mux.HandleFunc("DELETE /purchase-orders/{id}", handlers.DeletePurchaseOrder(repos.PurchaseOrders))
+		mux.HandleFunc("POST /purchase-orders/{id}/send", handlers.SendPurchaseOrder(repos.PurchaseOrders))
+		mux.HandleFunc("POST /purchase-orders/{id}/receive", handlers.ReceivePurchaseOrder(repos.PurchaseOrders))
+	}
+
+	// Stock take routes
+	if repos.StockTakes != nil {
+		mux.HandleFunc("GET /stock-takes", handlers.GetStockTakes(repos.StockTakes))
+		mux.HandleFunc("POST /stock-takes", handlers.OpenStockTake(repos.StockTakes))
+		mux.HandleFunc("GET /stock-takes/{id}", handlers.GetStockTakeByID(repos.StockTakes))
+		mux.HandleFunc("DELETE /stock-takes/{id}", handlers.DeleteStockTake(repos.StockTakes))
+		mux.HandleFunc("POST /stock-takes/{id}/counts", handlers.SubmitStockCounts(repos.StockTakes))
+		mux.HandleFunc("POST /stock-takes/{id}/commit", handlers.CommitStockTake(repos.StockTakes))
+	}
+
+	// Unit routes
+	mux.HandleFunc("GET /units", handlers.GetUnits(repos.Units))
+	mux.HandleFunc("POST /units", handlers.CreateUnit(repos.Units))
+
+	// Menu Items routes
+	mux.HandleFunc("GET /menu", handlers.GetMenuItems(menu))
+	mux.HandleFunc("POST /menu", handlers.CreateMenuItem(menu))
+	mux.HandleFunc("GET /menu/{id}", handlers.GetMenuItemByID(menu))
+	mux.HandleFunc("GET /menu/export", handlers.ExportMenu(menu))
+	mux.HandleFunc("PUT /menu/{id}", handlers.UpdateMenuItem(menu))
+	mux.HandleFunc("PATCH /menu/{id}", handlers.PatchMenuItem(menu))
+	mux.HandleFunc("DELETE /menu/{id}", handlers.DeleteMenuItem(menu))
+
+	// Allergen routes
+	mux.HandleFunc("GET /allergens", handlers.GetAllergens(repos.Allergens))
+	mux.HandleFunc("POST /allergens", handlers.CreateAllergen(repos.Allergens))
+
+	// Category routes
+	mux.HandleFunc("GET /categories", handlers.GetCategories(repos.Categories))
+	mux.HandleFunc("POST /categories", handlers.CreateCategory(repos.Categories))
+	mux.HandleFunc("GET /categories/{id}", handlers.GetCategoryByID(repos.Categories))
+	mux.HandleFunc("PUT /categories/{id}", handlers.UpdateCategory(repos.Categories))
+	mux.HandleFunc("DELETE /categories/{id}", handlers.DeleteCategory(repos.Categories))
+
+	// Menu product routes
+	mux.HandleFunc("POST /menu-products", handlers.CreateMenuProduct(repos.Products))
+	mux.HandleFunc("GET /menu-products/{id}", handlers.GetMenuProductByID(repos.Products))
+	mux.HandleFunc("PUT /menu-products/{id}", handlers.UpdateMenuProduct(repos.Products))
+	mux.HandleFunc("DELETE /menu-products/{id}", handlers.DeleteMenuProduct(repos.Products))
+
+	// Modifier group routes
+	if repos.Modifiers != nil {
+		mux.HandleFunc("GET /modifier-groups", handlers.GetModifierGroups(repos.Modifiers))
+		mux.HandleFunc("POST /modifier-groups", handlers.CreateModifierGroup(repos.Modifiers))
+		mux.HandleFunc("PUT /modifier-groups/{id}", handlers.UpdateModifierGroup(repos.Modifiers))
+		mux.HandleFunc("DELETE /modifier-groups/{id}", handlers.DeleteModifierGroup(repos.Modifiers))
+	}
+
+	// Report routes
+	if repos.Reports != nil {
+		mux.HandleFunc("GET /reports/total-sales", handlers.TotalAmount(repos.Reports))
+		mux.HandleFunc("GET /reports/popular-items", handlers.PopularItems(repos.Reports))
+
+		mux.HandleFunc("GET /orders/numberOfOrderedItems", handlers.GetNumberOfOrderedItems(repos.Reports))
+
+		mux.HandleFunc("GET /reports/search", handlers.FullTextSearchReport(repos.Reports))
+		mux.HandleFunc("GET /reports/orderedItemsByPeriod", handlers.OrderedItemsByPeriod(repos.Reports))
+		mux.HandleFunc("GET /inventory/getLeftOvers", handlers.GetLeftovers(repos.Reports))
+		mux.HandleFunc("GET /reports/discount-usage", handlers.DiscountUsage(repos.Reports))
+		mux.HandleFunc("GET /reports/margins", handlers.MarginReport(repos.Reports, marginThreshold))
+		mux.HandleFunc("GET /reports/cogs", handlers.COGSReport(repos.Reports))
+		mux.HandleFunc("GET /reports/profit-loss", handlers.ProfitAndLoss(repos.Reports))
+		mux.HandleFunc("GET /reports/forecast", handlers.DemandForecast(service.NewForecasts(repos.Reports)))
+	} else {
+		// Without reports these would be taken for ids by the routes above
+		mux.HandleFunc("GET /orders/numberOfOrderedItems", handlers.NotFound)
+		mux.HandleFunc("GET /inventory/getLeftOvers", handlers.NotFound)
+	}
+
+	// Customer routes
+	mux.HandleFunc("POST /customers", handlers.CreateCustomer(customers))
+	mux.HandleFunc("GET /customers/{id}", handlers.GetCustomerByID(customers))
+	mux.HandleFunc("GET /customers/{id}/loyalty", handlers.GetCustomerLoyalty(customers))
+
+	// Promotion routes
+	if repos.Promotions != nil {
+		mux.HandleFunc("GET /promotions", handlers.GetPromotions(repos.Promotions))
+		mux.HandleFunc("POST /promotions", handlers.CreatePromotion(repos.Promotions))
+		mux.HandleFunc("GET /promotions/{id}", handlers.GetPromotionByID(repos.Promotions))
+		mux.HandleFunc("PUT /promotions/{id}", handlers.UpdatePromotion(repos.Promotions))
+		mux.HandleFunc("DELETE /promotions/{id}", handlers.DeletePromotion(repos.Promotions))
+	}
+
+	return handlers.RouteErrors(mux)
+}
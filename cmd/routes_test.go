@@ -0,0 +1,329 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"frappuccino/internal/repository/memory"
+	"frappuccino/internal/repository/postgres"
+)
+
+// routeCase is one request against the router and the response expected.
+// Cases run in order on the same database, so later cases see the changes
+// of earlier ones. Ids of new rows follow the seed data: it has 30 orders, 4
+// suppliers, 2 purchase orders, 6 categories, 18 menu products, 3 modifier
+// groups, 4 promotions and no stock takes.
+type routeCase struct {
+	name   string
+	method string
+	path   string
+	body   string // sent as application/json, or a merge patch for PATCH
+	status int
+	want   string // substring of the response body, if set
+}
+
+var routeCases = []routeCase{
+	// Orders
+	{"list orders", "GET", "/orders", "", 200, `"id":1,`},
+	{"get order", "GET", "/orders/2", "", 200, `"status":"closed"`},
+	{"get missing order", "GET", "/orders/9999", "", 404, `"code":"not_found"`},
+	{"get order with bad id", "GET", "/orders/abc", "", 400, `"code":"invalid_request"`},
+	{"get order with partly numeric id", "GET", "/orders/2x", "", 400, ""},
+	{"get order below the collection", "GET", "/orders/2/items", "", 404, ""},
+	{"create order", "POST", "/orders", `{"customer_id":1,"payment_method":"cash","items":[{"menu_item_id":"8","quantity":1}]}`, 201, `"order_id":31`},
+	{"create order without items", "POST", "/orders", `{"customer_id":2,"payment_method":"card","total_amount":7.5}`, 201, `"order_id":32`},
+	{"create order without items but with a promo code", "POST", "/orders", `{"customer_id":2,"payment_method":"card","total_amount":7.5,"promo_code":"WELCOME10"}`, 400, `"field":"promo_code"`},
+	{"create order with unknown item", "POST", "/orders", `{"customer_id":1,"payment_method":"cash","items":[{"menu_item_id":"999","quantity":1}]}`, 422, `"code":"unknown_menu_item"`},
+	{"create order with bad quantity", "POST", "/orders", `{"customer_id":1,"payment_method":"cash","items":[{"menu_item_id":"8","quantity":0}]}`, 400, ""},
+	{"create order with too many points", "POST", "/orders", `{"customer_id":1,"payment_method":"cash","redeem_points":1000000,"items":[{"menu_item_id":"8","quantity":1}]}`, 400, `"message":"insufficient loyalty points"`},
+	{"create order with unknown promo code", "POST", "/orders", `{"customer_id":1,"payment_method":"cash","promo_code":"NOPE","items":[{"menu_item_id":"8","quantity":1}]}`, 400, ""},
+	{"create order without customer", "POST", "/orders", `{"payment_method":"cash","items":[{"menu_item_id":"8","quantity":1}]}`, 400, "customer_id is required"},
+	{"create order with malformed body", "POST", "/orders", `{"customer_id":`, 400, `"code":"invalid_body"`},
+	{"create order with mistyped field", "POST", "/orders", `{"customer_id":"one"}`, 400, `"field":"customer_id"`},
+	{"create order for unknown customer", "POST", "/orders", `{"customer_id":9999,"payment_method":"cash","total_amount":1}`, 422, `"code":"unknown_customer"`},
+	{"create order with bad payment method", "POST", "/orders", `{"customer_id":1,"payment_method":"cheque","total_amount":1}`, 400, `"field":"payment_method"`},
+	{"create order with several bad fields", "POST", "/orders", `{"payment_method":"cheque","items":[{"menu_item_id":"8","quantity":0}]}`, 400,
+		`customer_id is required; payment_method must be one of cash, card, kaspi_qr; items[0].quantity must be greater than 0`},
+	{"create order with unknown field", "POST", "/orders", `{"customer_id":1,"payment_method":"cash","total_amount":1,"tip":5}`, 400, `"field":"tip"`},
+	{"create order with two bodies", "POST", "/orders", `{"customer_id":1,"payment_method":"cash","total_amount":1}{}`, 400, `"code":"invalid_body"`},
+	{"create order with huge body", "POST", "/orders", `{"special_instructions":"` + strings.Repeat("x", 1<<20) + `"}`, 413, `"code":"body_too_large"`},
+	{"update order", "PUT", "/orders/2", `{"customer_id":2,"total_amount":10,"status":"closed","payment_method":"card"}`, 200, `"order_id":"2"`},
+	{"update missing order", "PUT", "/orders/9999", `{"customer_id":2,"total_amount":10,"payment_method":"card"}`, 404, ""},
+	{"patch order", "PATCH", "/orders/32", `{"payment_method":"kaspi_qr","special_instructions":{"note":"no sugar"}}`, 200, `"payment_method":"kaspi_qr"`},
+	{"patch order keeps unpatched fields", "PATCH", "/orders/32", `{"status":"closed"}`, 200, `"special_instructions":{"note":"no sugar"}`},
+	{"reopen closed order", "PATCH", "/orders/32", `{"status":"open"}`, 409, "cannot be reopened"},
+	{"patch order to a zero total", "PATCH", "/orders/32", `{"total_amount":0}`, 200, `"total_amount":0,`},
+	{"patch order to an invalid result", "PATCH", "/orders/32", `{"payment_method":null}`, 400, "payment_method is required"},
+	{"patch order with unknown field", "PATCH", "/orders/32", `{"tip":5}`, 400, `"field":"tip"`},
+	{"patch order with non-object patch", "PATCH", "/orders/32", `[]`, 400, `"code":"invalid_body"`},
+	{"patch missing order", "PATCH", "/orders/9999", `{"status":"closed"}`, 404, ""},
+	{"close order", "POST", "/orders/1/close", "", 200, "Order closed successfully"},
+	{"close closed order", "POST", "/orders/1/close", "", 404, ""},
+	{"delete order", "DELETE", "/orders/3", "{}", 200, "Order deleted successfully"},
+	{"delete deleted order", "DELETE", "/orders/3", "{}", 404, ""},
+	{"count ordered items", "GET", "/orders/numberOfOrderedItems?startDate=2024-01-01&endDate=2030-12-31", "", 200, ""},
+	{"count ordered items without dates", "GET", "/orders/numberOfOrderedItems", "", 400, `"code":"invalid_date"`},
+	{"unsupported method", "PATCH", "/orders", "{}", 405, `"code":"method_not_allowed"`},
+
+	// Bulk orders: each order stands on its own
+	{"bulk orders", "POST", "/orders/batch-process", `{"orders":[
+		{"customer_name":"John Smith","items":[{"menu_item_id":"8","quantity":1}]},
+		{"customer_name":"Emily Johnson","items":[{"menu_item_id":"8","quantity":100000}]},
+		{"customer_name":"Michael Williams","items":[{"menu_item_id":"999","quantity":1}]},
+		{"customer_name":"Sarah Brown","items":[]}]}`, 200, `"accepted":1,`},
+	{"bulk order stock shortage", "POST", "/orders/batch-process", `{"orders":[
+		{"customer_name":"Emily Johnson","items":[{"menu_item_id":"8","quantity":100000}]}]}`, 200, `"reason":"insufficient_inventory"`},
+	{"bulk order unknown item", "POST", "/orders/batch-process", `{"orders":[
+		{"customer_name":"Michael Williams","items":[{"menu_item_id":"999","quantity":1}]}]}`, 200, `"reason":"unknown_menu_item"`},
+	{"bulk order for unknown customer", "POST", "/orders/batch-process", `{"orders":[
+		{"customer_name":"Jon Smith","items":[{"menu_item_id":"8","quantity":1}]}]}`, 200, `"reason":"unknown_customer"`},
+	{"bulk order without items", "POST", "/orders/batch-process", `{"orders":[
+		{"customer_name":"Sarah Brown","items":[]}]}`, 200, `"reason":"invalid_items"`},
+
+	// Inventory
+	{"list inventory", "GET", "/inventory", "", 200, "Espresso Beans"},
+	{"get inventory item", "GET", "/inventory/1", "", 200, "Espresso Beans"},
+	{"get missing inventory item", "GET", "/inventory/999", "", 404, ""},
+	{"create inventory item", "POST", "/inventory", `{"id":"100","name":"Vanilla Syrup","stock":5,"price":8,"unit_type":"l"}`, 201, `"id":"100"`},
+	{"create duplicate inventory item", "POST", "/inventory", `{"id":"100","name":"Vanilla Syrup","stock":5,"price":8,"unit_type":"l"}`, 409, "already exists"},
+	{"create inventory item with unknown unit", "POST", "/inventory", `{"id":"101","name":"Salt","stock":1,"price":1,"unit_type":"bucket"}`, 400, `"code":"invalid_unit"`},
+	{"create inventory item with unknown supplier", "POST", "/inventory", `{"id":"101","name":"Salt","stock":1,"price":1,"unit_type":"kg","reorder":{"supplier_id":999,"level":1,"par_level":2}}`, 422, `"code":"unknown_supplier"`},
+	{"create inventory item without name", "POST", "/inventory", `{"id":"101","stock":1,"price":1,"unit_type":"kg"}`, 400, "name is required"},
+	{"create inventory item with bad reorder policy", "POST", "/inventory", `{"id":"101","name":"Salt","stock":-1,"price":1,"unit_type":"kg","reorder":{"level":5,"par_level":2}}`, 400,
+		`stock cannot be negative; reorder.par_level cannot be below the level`},
+	{"create inventory item without content type", "POST", "/inventory", "", 415, `"code":"unsupported_media_type"`},
+	{"update inventory item", "PUT", "/inventory/100", `{"name":"Vanilla Syrup","stock":6,"price":8.5,"unit_type":"l"}`, 200, ""},
+	{"patch inventory item", "PATCH", "/inventory/100", `{"stock":7,"allergens":null}`, 200, `"stock":7,`},
+	{"change stock unit to another dimension with stock left", "PATCH", "/inventory/100", `{"unit_type":"piece"}`, 409, "write it off first"},
+	{"patch inventory item to an invalid result", "PATCH", "/inventory/100", `{"price":0}`, 400, "price must be greater than 0"},
+	{"patch missing inventory item", "PATCH", "/inventory/999", `{"stock":1}`, 404, ""},
+	{"update missing inventory item", "PUT", "/inventory/999", `{"name":"Nothing","stock":1,"price":1,"unit_type":"l"}`, 404, ""},
+	{"restock inventory item", "POST", "/inventory/100/restock", `{"amount":500,"unit":"ml"}`, 200, `"added":0.5`},
+	{"restock with incompatible unit", "POST", "/inventory/100/restock", `{"amount":1,"unit":"kg"}`, 400, ""},
+	{"restock at the former path", "POST", "/inventory/restock/100", `{"amount":1}`, 404, ""},
+	{"restock missing inventory item", "POST", "/inventory/999/restock", `{"amount":1}`, 404, ""},
+	{"expiring batches", "GET", "/inventory/expiring?days=30", "", 200, `"batches"`},
+	{"expiring batches with bad days", "GET", "/inventory/expiring?days=-1", "", 400, ""},
+	{"write off batch", "POST", "/inventory/batches/1/write-off", `{"quantity":1}`, 200, ""},
+	{"write off missing batch", "POST", "/inventory/batches/99999/write-off", "", 404, ""},
+	{"leftovers", "GET", "/inventory/getLeftOvers?sortBy=price&page=1&pageSize=5", "", 200, `"currentPage":1`},
+	{"leftovers with bad sort", "GET", "/inventory/getLeftOvers?sortBy=name", "", 400, ""},
+	{"delete inventory item on a purchase order", "DELETE", "/inventory/2", "", 409, "still on purchase orders"},
+	{"delete inventory item", "DELETE", "/inventory/100", "", 200, ""},
+	{"delete missing inventory item", "DELETE", "/inventory/100", "", 404, ""},
+
+	// Suppliers
+	{"list suppliers", "GET", "/suppliers", "", 200, "Highland Roasters"},
+	{"create supplier", "POST", "/suppliers", `{"name":"Bean Co","lead_time_days":2}`, 201, `"id":5`},
+	{"create duplicate supplier", "POST", "/suppliers", `{"name":"bean co"}`, 409, ""},
+	{"create supplier without name", "POST", "/suppliers", `{"lead_time_days":2}`, 400, ""},
+	{"get supplier", "GET", "/suppliers/5", "", 200, "Bean Co"},
+	{"get missing supplier", "GET", "/suppliers/999", "", 404, ""},
+	{"update supplier", "PUT", "/suppliers/5", `{"name":"Bean Company","lead_time_days":3}`, 200, ""},
+	{"delete supplier with purchase orders", "DELETE", "/suppliers/2", "", 409, `"code":"conflict"`},
+	{"delete supplier", "DELETE", "/suppliers/5", "", 204, ""},
+
+	// Purchase orders
+	{"list purchase orders", "GET", "/purchase-orders?status=sent", "", 200, `"status":"sent"`},
+	{"list purchase orders with bad status", "GET", "/purchase-orders?status=lost", "", 400, ""},
+	{"suggested purchase orders", "GET", "/purchase-orders/suggested", "", 200, ""},
+	{"create purchase order", "POST", "/purchase-orders", `{"supplier_id":1,"items":[{"inventory_id":"1","quantity":10,"unit_cost":11}]}`, 201, `"id":3`},
+	{"create purchase order for unknown supplier", "POST", "/purchase-orders", `{"supplier_id":999,"items":[{"inventory_id":"1","quantity":10}]}`, 422, `"code":"unknown_supplier"`},
+	{"create purchase order without items", "POST", "/purchase-orders", `{"supplier_id":1,"items":[]}`, 400, ""},
+	{"get purchase order", "GET", "/purchase-orders/3", "", 200, `"status":"draft"`},
+	{"get missing purchase order", "GET", "/purchase-orders/999", "", 404, ""},
+	{"update purchase order", "PUT", "/purchase-orders/3", `{"supplier_id":1,"notes":"Monthly beans","items":[{"inventory_id":"1","quantity":12,"unit_cost":11}]}`, 200, ""},
+	{"send purchase order", "POST", "/purchase-orders/3/send", "", 200, `"status":"sent"`},
+	{"update sent purchase order", "PUT", "/purchase-orders/3", `{"supplier_id":1,"items":[{"inventory_id":"1","quantity":1}]}`, 409, ""},
+	{"receive purchase order", "POST", "/purchase-orders/3/receive", "", 200, `"status":"received"`},
+	{"delete sent purchase order", "DELETE", "/purchase-orders/1", "", 409, ""},
+	{"delete draft purchase order", "DELETE", "/purchase-orders/2", "", 204, ""},
+
+	// Stock takes
+	{"open stock take", "POST", "/stock-takes", `{"notes":"Spot check"}`, 201, `"id":1`},
+	{"delete open stock take", "DELETE", "/stock-takes/1", "", 204, ""},
+	{"open another stock take", "POST", "/stock-takes", `{"notes":"Monthly count"}`, 201, `"id":2`},
+	{"open second stock take", "POST", "/stock-takes", "", 409, "already open"},
+	{"list stock takes", "GET", "/stock-takes", "", 200, "Monthly count"},
+	{"submit counts", "POST", "/stock-takes/2/counts", `{"counts":[{"inventory_id":"1","quantity":140}]}`, 200, `"items_counted":1`},
+	{"submit counts for unknown item", "POST", "/stock-takes/2/counts", `{"counts":[{"inventory_id":"999","quantity":1}]}`, 422, `"code":"unknown_ingredient"`},
+	{"get stock take", "GET", "/stock-takes/2", "", 200, `"status":"open"`},
+	{"commit stock take", "POST", "/stock-takes/2/commit", "", 200, `"status":"committed"`},
+	{"delete committed stock take", "DELETE", "/stock-takes/2", "", 409, ""},
+	{"get missing stock take", "GET", "/stock-takes/999", "", 404, ""},
+
+	// Units and allergens
+	{"list units", "GET", "/units", "", 200, `"code":"kg"`},
+	{"create unit", "POST", "/units", `{"code":"pinch","name":"Pinch","dimension":"mass","factor":0.3}`, 201, `"code":"pinch"`},
+	{"create duplicate unit", "POST", "/units", `{"code":"pinch","name":"Pinch","dimension":"mass","factor":0.3}`, 409, ""},
+	{"create unit without factor", "POST", "/units", `{"code":"dash","name":"Dash","dimension":"volume"}`, 400, ""},
+	{"list allergens", "GET", "/allergens", "", 200, `"code":"milk"`},
+	{"create allergen", "POST", "/allergens", `{"code":"kiwi","name":"Kiwi"}`, 201, `"code":"kiwi"`},
+	{"create duplicate allergen", "POST", "/allergens", `{"code":"Kiwi","name":"Kiwi fruit"}`, 409, ""},
+
+	// Menu
+	{"list menu", "GET", "/menu", "", 200, "Cappuccino"},
+	{"list menu by category", "GET", "/menu?category=2", "", 200, "Croissant"},
+	{"list menu without allergens", "GET", "/menu?exclude_allergens=milk", "", 200, ""},
+	{"list menu with bad category", "GET", "/menu?category=coffee", "", 400, ""},
+	{"list menu with unknown allergen", "GET", "/menu?exclude_allergens=plutonium", "", 400, ""},
+	{"get menu item", "GET", "/menu/1", "", 200, `"nutrition"`},
+	{"get missing menu item", "GET", "/menu/999", "", 404, ""},
+	{"export menu", "GET", "/menu/export", "", 200, "MENU"},
+	{"export menu as csv", "GET", "/menu/export?format=csv", "", 200, "category,id,name"},
+	{"export menu in bad format", "GET", "/menu/export?format=pdf", "", 400, ""},
+	{"create menu item", "POST", "/menu", `{"id":"30","name":"Cortado","description":"Espresso cut with warm milk","price":3.2,"category_id":5,"size":"small"}`, 201, `"id":"30"`},
+	{"create menu item with duplicate id", "POST", "/menu", `{"id":"30","name":"Cortado","price":3.6,"category_id":5,"size":"medium"}`, 409, ""},
+	{"create menu item with duplicate name", "POST", "/menu", `{"id":"31","name":"Cappuccino","price":4.1,"category_id":5,"size":"medium"}`, 409, ""},
+	{"create menu item for unknown product", "POST", "/menu", `{"id":"31","product_id":999,"price":4.1,"size":"medium"}`, 422, `"code":"unknown_product"`},
+	{"create menu item in unknown size", "POST", "/menu", `{"id":"31","product_id":1,"price":4.1,"size":"huge"}`, 400, `"field":"size"`},
+	{"create menu item in unknown category", "POST", "/menu", `{"id":"31","name":"Tea","price":2,"category_id":999,"size":"medium"}`, 422, "category not found"},
+	{"create menu item without price", "POST", "/menu", `{"id":"31","name":"Tea","category_id":5,"size":"medium"}`, 400, ""},
+	{"update menu item", "PUT", "/menu/30", `{"name":"Cortado","description":"Espresso cut with warm milk","price":3.4,"category_id":5,"size":"small"}`, 200, `"id":"30"`},
+	{"patch menu item", "PATCH", "/menu/30", `{"price":3.5}`, 200, `"description":"Espresso cut with warm milk"`},
+	{"patch menu item with bad size", "PATCH", "/menu/30", `{"size":"huge"}`, 400, `"field":"size"`},
+	{"patch menu item with mistyped field", "PATCH", "/menu/30", `{"price":"cheap"}`, 400, `"field":"price"`},
+	{"rename menu item to an existing one", "PUT", "/menu/30", `{"name":"Latte","price":3.4,"category_id":5,"size":"small"}`, 409, ""},
+	{"update missing menu item", "PUT", "/menu/999", `{"name":"Nothing","price":1,"category_id":5,"size":"small"}`, 404, ""},
+	{"delete menu item", "DELETE", "/menu/30", "", 204, ""},
+	{"delete missing menu item", "DELETE", "/menu/30", "", 404, ""},
+	{"delete bundle component", "DELETE", "/menu/16", "", 409, "component of bundle Coffee & Croissant"},
+
+	// Categories
+	{"list categories", "GET", "/categories", "", 200, "Hot Coffee"},
+	{"create category", "POST", "/categories", `{"name":"Tea","parent_id":1,"sort_order":3}`, 201, `"id":7`},
+	{"create duplicate category", "POST", "/categories", `{"name":"beverage"}`, 409, ""},
+	{"create category under unknown parent", "POST", "/categories", `{"name":"Juice","parent_id":999}`, 422, `"code":"unknown_category"`},
+	{"get category", "GET", "/categories/7", "", 200, `"name":"Tea"`},
+	{"get missing category", "GET", "/categories/999", "", 404, ""},
+	{"update category", "PUT", "/categories/7", `{"name":"Teas","parent_id":1,"sort_order":3}`, 200, ""},
+	{"delete category in use", "DELETE", "/categories/1", "", 409, ""},
+	{"delete category", "DELETE", "/categories/7", "", 204, ""},
+
+	// Menu products; the Cortado above was product 19
+	{"create menu product", "POST", "/menu-products", `{"name":"Iced Tea","category_id":6,"ingredients":[{"ingredient_id":"1","quantity":0.01}]}`, 201, `"id":20`},
+	{"create duplicate menu product", "POST", "/menu-products", `{"name":"Iced Tea","category_id":6}`, 409, ""},
+	{"create menu product with unknown ingredient", "POST", "/menu-products", `{"name":"Lemonade","category_id":6,"ingredients":[{"ingredient_id":"999","quantity":1}]}`, 422, "unknown ingredient"},
+	{"get menu product", "GET", "/menu-products/9", "", 200, `"variants"`},
+	{"get missing menu product", "GET", "/menu-products/999", "", 404, ""},
+	{"update menu product", "PUT", "/menu-products/20", `{"name":"Iced Tea","description":"Cold brewed","category_id":6,"ingredients":[{"ingredient_id":"1","quantity":0.02}]}`, 200, ""},
+	{"delete menu product with variants", "DELETE", "/menu-products/1", "", 409, ""},
+	{"delete menu product", "DELETE", "/menu-products/20", "", 204, ""},
+
+	// Modifier groups
+	{"list modifier groups", "GET", "/modifier-groups", "", 200, "Oat milk"},
+	{"create modifier group", "POST", "/modifier-groups", `{"name":"Sweetener","max_selections":1,"modifiers":[{"name":"Honey","price_delta":0.3}],"menu_item_ids":["1"]}`, 201, `"id":4`},
+	{"create modifier group without modifiers", "POST", "/modifier-groups", `{"name":"Empty","max_selections":1}`, 400, ""},
+	{"update modifier group", "PUT", "/modifier-groups/4", `{"name":"Sweetener","max_selections":2,"modifiers":[{"name":"Honey","price_delta":0.3},{"name":"Agave","price_delta":0.4}]}`, 200, ""},
+	{"delete modifier group", "DELETE", "/modifier-groups/4", "", 204, ""},
+	{"delete missing modifier group", "DELETE", "/modifier-groups/4", "", 404, ""},
+
+	// Reports
+	{"total sales", "GET", "/reports/total-sales", "", 200, ""},
+	{"popular items", "GET", "/reports/popular-items", "", 200, ""},
+	{"search", "GET", "/reports/search?q=latte&filter=menu", "", 200, "Latte"},
+	{"search without query", "GET", "/reports/search", "", 400, ""},
+	{"ordered items by day", "GET", "/reports/orderedItemsByPeriod?period=day&month=february", "", 200, `"period":"day"`},
+	{"ordered items by month", "GET", "/reports/orderedItemsByPeriod?period=month&year=2024", "", 200, `"period":"month"`},
+	{"ordered items by bad period", "GET", "/reports/orderedItemsByPeriod?period=week", "", 400, ""},
+	{"discount usage", "GET", "/reports/discount-usage", "", 200, `"total_discount"`},
+	{"margins", "GET", "/reports/margins", "", 200, ""},
+	{"margin history", "GET", "/reports/margins?view=history", "", 200, ""},
+	{"margins with bad view", "GET", "/reports/margins?view=future", "", 400, ""},
+	{"cogs", "GET", "/reports/cogs?period=month", "", 200, `"periods"`},
+	{"profit and loss", "GET", "/reports/profit-loss", "", 200, `"total"`},
+	{"profit and loss by bad period", "GET", "/reports/profit-loss?period=year", "", 400, ""},
+	{"forecast", "GET", "/reports/forecast?days=3", "", 200, ""},
+	{"forecast with bad days", "GET", "/reports/forecast?days=0", "", 400, ""},
+
+	// Customers
+	{"customer loyalty", "GET", "/customers/1/loyalty", "", 200, `"customer_id":1`},
+	{"missing customer loyalty", "GET", "/customers/9999/loyalty", "", 404, ""},
+	{"create customer", "POST", "/customers", `{"name":"Aigerim Sadykova","email":"aigerim@example.com"}`, 201, `"id":31`},
+	{"create customer without name", "POST", "/customers", `{"email":"nobody@example.com"}`, 400, `"field":"name"`},
+	{"create customer with taken email", "POST", "/customers", `{"name":"John Smith","email":"john_smith@gmail.com"}`, 409, ""},
+	{"get customer", "GET", "/customers/31", "", 200, `"name":"Aigerim Sadykova"`},
+	{"get missing customer", "GET", "/customers/9999", "", 404, ""},
+
+	// Promotions
+	{"list promotions", "GET", "/promotions", "", 200, "WELCOME10"},
+	{"create promotion", "POST", "/promotions", `{"name":"Morning Coffee","discount_type":"percentage","value":10,"code":"MORNING10","category_id":5}`, 201, `"id":5`},
+	{"create promotion with bad type", "POST", "/promotions", `{"name":"Free","discount_type":"free","value":1}`, 400, ""},
+	{"get promotion", "GET", "/promotions/5", "", 200, "MORNING10"},
+	{"update promotion", "PUT", "/promotions/5", `{"name":"Morning Coffee","discount_type":"percentage","value":15,"code":"MORNING10","category_id":5,"is_active":true}`, 200, ""},
+	{"delete promotion", "DELETE", "/promotions/5", "", 204, ""},
+	{"get deleted promotion", "GET", "/promotions/5", "", 404, ""},
+	{"create single-use promotion", "POST", "/promotions", `{"name":"Once","discount_type":"fixed","value":1,"code":"ONCE","usage_limit":1,"is_active":true}`, 201, `"id":6`},
+	{"create order with single-use code", "POST", "/orders", `{"customer_id":1,"payment_method":"cash","promo_code":"once","items":[{"menu_item_id":"8","quantity":1}]}`, 201, `"code":"ONCE"`},
+	{"create order with used up code", "POST", "/orders", `{"customer_id":1,"payment_method":"cash","promo_code":"ONCE","items":[{"menu_item_id":"8","quantity":1}]}`, 409, `"code":"promo_code_used_up"`},
+
+	{"unknown route", "GET", "/nowhere", "", 404, `"code":"not_found"`},
+}
+
+// memoryRouteCases run against the in-memory store, which starts without
+// customers and serves no reports.
+var memoryRouteCases = []routeCase{
+	{"orders report without reports", "GET", "/orders/numberOfOrderedItems", "", 404, `"code":"not_found"`},
+	{"leftovers without reports", "GET", "/inventory/getLeftOvers", "", 404, `"code":"not_found"`},
+	{"create customer", "POST", "/customers", `{"name":"Aigerim Sadykova","email":"aigerim@example.com"}`, 201, `"id":1`},
+	{"create customer with taken email", "POST", "/customers", `{"name":"Aigerim","email":"aigerim@example.com"}`, 409, ""},
+	{"get customer", "GET", "/customers/1", "", 200, `"email":"aigerim@example.com"`},
+	{"order for the customer", "POST", "/orders", `{"customer_id":1,"payment_method":"cash","total_amount":5}`, 201, `"order_id":1`},
+	{"customer loyalty", "GET", "/customers/1/loyalty", "", 200, ""},
+	{"list modifier groups", "GET", "/modifier-groups", "", 200, ""},
+	{"create promotion", "POST", "/promotions", `{"name":"Welcome","discount_type":"percentage","value":10,"code":"WELCOME10"}`, 201, `"id":1`},
+	{"create promotion with taken code", "POST", "/promotions", `{"name":"Again","discount_type":"fixed","value":1,"code":"WELCOME10"}`, 409, ""},
+	{"get promotion", "GET", "/promotions/1", "", 200, `"code":"WELCOME10"`},
+}
+
+func TestRoutes(t *testing.T) {
+	runRouteCases(t, newRouter(postgres.New(startPostgres(t)), 60, time.UTC), routeCases)
+}
+
+func TestMemoryRoutes(t *testing.T) {
+	runRouteCases(t, newRouter(memory.New(), 60, time.UTC), memoryRouteCases)
+}
+
+func runRouteCases(t *testing.T, router http.Handler, cases []routeCase) {
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+			switch {
+			case c.body != "" && c.method == "PATCH":
+				req.Header.Set("Content-Type", "application/merge-patch+json")
+			case c.body != "":
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != c.status {
+				t.Fatalf("%s %s: status %d, want %d\n%s", c.method, c.path, rec.Code, c.status, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); c.status >= 400 && ct != "application/json" {
+				t.Fatalf("%s %s: error with Content-Type %q, want application/json", c.method, c.path, ct)
+			}
+			if c.want != "" && !strings.Contains(rec.Body.String(), c.want) {
+				t.Fatalf("%s %s: body does not contain %s\n%s", c.method, c.path, c.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestPatchNeedsMergePatch(t *testing.T) {
+	router := newRouter(memory.New(), 60, time.UTC)
+
+	for _, path := range []string{"/orders/1", "/menu/1", "/inventory/1"} {
+		req := httptest.NewRequest("PATCH", path, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != 415 {
+			t.Fatalf("PATCH %s as application/json: status %d, want 415\n%s", path, rec.Code, rec.Body)
+		}
+	}
+}
@@ -1,30 +1,367 @@
 package config
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// Zone names resolve even in images without a zone database
+	_ "time/tzdata"
+)
+
 type Config struct {
-	DB struct {
-		Host     string
-		Port     string
-		User     string
-		Password string
-		Name     string
-	}
-}
-
-// LoadConfig loads the application configuration.
-func LoadConfig() *Config {
-	return &Config{
-		DB: struct {
-			Host     string
-			Port     string
-			User     string
-			Password string
-			Name     string
-		}{
-			Host:     "db",          // Matches the service name in docker-compose.yml
-			Port:     "5432",        // Default PostgreSQL port
-			User:     "latte",       // Database user
-			Password: "latte",       // Database password
-			Name:     "frappuccino", // Database name
-		},
+	// Addr is the address the HTTP server listens on.
+	Addr string
+	// Storage selects the repositories: StoragePostgres, or StorageMemory
+	// to keep all data in memory without a database.
+	Storage string
+	DB      DBConfig
+	// AutoMigrate applies pending migrations when the server starts.
+	AutoMigrate bool
+	// MarginThreshold is the gross margin, in percent, below which the
+	// margin report flags a menu item.
+	MarginThreshold float64
+	// Location is the time zone of the shop, in which daily promotion
+	// windows such as a 15:00-17:00 happy hour are read.
+	Location *time.Location
+}
+
+// DBConfig mirrors db.Config field for field so that it converts directly.
+type DBConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+	TimeZone string
+	// MaxOpenConns and MaxIdleConns size the connection pool; 0 leaves
+	// the number of open connections unlimited.
+	MaxOpenConns int
+	MaxIdleConns int
+	// ConnMaxLifetime closes pooled connections after this long; 0 keeps
+	// them open.
+	ConnMaxLifetime time.Duration
+	// ConnectTimeout is how long startup keeps retrying while the database
+	// is not yet reachable.
+	ConnectTimeout time.Duration
+	SSLRootCert    string
+	SSLCert        string
+	SSLKey         string
+}
+
+// setting is one configuration key: its name in a config file, the
+// environment variable that overrides it and its default. Required settings
+// have no default and must not be empty.
+type setting struct {
+	key      string
+	env      string
+	def      string
+	required bool
+	set      func(c *Config, v string) error
+}
+
+// Storage backends.
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+var settings = []setting{
+	{key: "addr", env: "LISTEN_ADDR", def: ":8080", set: func(c *Config, v string) error {
+		c.Addr = v
+		return nil
+	}},
+	{key: "storage", env: "STORAGE", def: StoragePostgres, set: func(c *Config, v string) error {
+		if v != StoragePostgres && v != StorageMemory {
+			return fmt.Errorf("must be %s or %s", StoragePostgres, StorageMemory)
+		}
+		c.Storage = v
+		return nil
+	}},
+	{key: "db.host", env: "DB_HOST", required: true, set: func(c *Config, v string) error {
+		c.DB.Host = v
+		return nil
+	}},
+	{key: "db.port", env: "DB_PORT", def: "5432", set: func(c *Config, v string) error {
+		if p, err := strconv.Atoi(v); err != nil || p < 1 || p > 65535 {
+			return errors.New("must be a port number")
+		}
+		c.DB.Port = v
+		return nil
+	}},
+	{key: "db.user", env: "DB_USER", required: true, set: func(c *Config, v string) error {
+		c.DB.User = v
+		return nil
+	}},
+	{key: "db.password", env: "DB_PASSWORD", set: func(c *Config, v string) error {
+		c.DB.Password = v
+		return nil
+	}},
+	{key: "db.name", env: "DB_NAME", required: true, set: func(c *Config, v string) error {
+		c.DB.Name = v
+		return nil
+	}},
+	{key: "db.sslmode", env: "DB_SSLMODE", def: "disable", set: func(c *Config, v string) error {
+		for _, m := range sslModes {
+			if v == m {
+				c.DB.SSLMode = v
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(sslModes, ", "))
+	}},
+	{key: "db.timezone", env: "DB_TIMEZONE", def: "UTC", set: func(c *Config, v string) error {
+		c.DB.TimeZone = v
+		return nil
+	}},
+	{key: "db.max_open_conns", env: "DB_MAX_OPEN_CONNS", def: "10", set: func(c *Config, v string) error {
+		return setCount(&c.DB.MaxOpenConns, v)
+	}},
+	{key: "db.max_idle_conns", env: "DB_MAX_IDLE_CONNS", def: "5", set: func(c *Config, v string) error {
+		return setCount(&c.DB.MaxIdleConns, v)
+	}},
+	{key: "db.conn_max_lifetime", env: "DB_CONN_MAX_LIFETIME", def: "30m", set: func(c *Config, v string) error {
+		return setDuration(&c.DB.ConnMaxLifetime, v)
+	}},
+	{key: "db.connect_timeout", env: "DB_CONNECT_TIMEOUT", def: "30s", set: func(c *Config, v string) error {
+		return setDuration(&c.DB.ConnectTimeout, v)
+	}},
+	{key: "db.sslrootcert", env: "DB_SSLROOTCERT", set: func(c *Config, v string) error {
+		c.DB.SSLRootCert = v
+		return nil
+	}},
+	{key: "db.sslcert", env: "DB_SSLCERT", set: func(c *Config, v string) error {
+		c.DB.SSLCert = v
+		return nil
+	}},
+	{key: "db.sslkey", env: "DB_SSLKEY", set: func(c *Config, v string) error {
+		c.DB.SSLKey = v
+		return nil
+	}},
+	{key: "auto_migrate", env: "AUTO_MIGRATE", def: "false", set: func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		c.AutoMigrate = b
+		return nil
+	}},
+	{key: "margin_threshold", env: "MARGIN_THRESHOLD", def: "60", set: func(c *Config, v string) error {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t < 0 || t > 100 {
+			return errors.New("must be a percentage between 0 and 100")
+		}
+		c.MarginThreshold = t
+		return nil
+	}},
+	{key: "timezone", env: "TIMEZONE", def: "UTC", set: func(c *Config, v string) error {
+		loc, err := time.LoadLocation(v)
+		if err != nil {
+			return errors.New("must be a time zone name such as Asia/Almaty")
+		}
+		c.Location = loc
+		return nil
+	}},
+}
+
+func setDuration(dst *time.Duration, v string) error {
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return errors.New("must be a non-negative duration such as 30s or 5m")
+	}
+	*dst = d
+	return nil
+}
+
+func setCount(dst *int, v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return errors.New("must be a non-negative integer")
+	}
+	*dst = n
+	return nil
+}
+
+// LoadConfig loads the application configuration. Every setting starts at
+// its default, is overridden by the config file at path when one is given
+// (JSON, or YAML for .yaml and .yml files) and then by its environment
+// variable. The returned error lists every missing or invalid setting.
+func LoadConfig(path string) (*Config, error) {
+	file := map[string]string{}
+	if path != "" {
+		var err error
+		if file, err = readFile(path); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+
+	var cfg Config
+	var problems, missing []string
+	for _, s := range settings {
+		v, ok := s.def, s.def != ""
+		if fv, found := file[s.key]; found {
+			v, ok = fv, true
+			delete(file, s.key)
+		}
+		if ev, found := os.LookupEnv(s.env); found {
+			v, ok = ev, true
+		}
+		if !ok || (s.required && v == "") {
+			if s.required {
+				missing = append(missing, fmt.Sprintf("%s (%s)", s.key, s.env))
+			}
+			continue
+		}
+		if err := s.set(&cfg, v); err != nil {
+			problems = append(problems, fmt.Sprintf("%s (%s) %v", s.key, s.env, err))
+		}
+	}
+	unknown := make([]string, 0, len(file))
+	for key := range file {
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		problems = append(problems, "unknown key "+key+" in config file")
+	}
+	if cfg.DB.MaxOpenConns > 0 && cfg.DB.MaxIdleConns > cfg.DB.MaxOpenConns {
+		problems = append(problems, "db.max_idle_conns cannot exceed db.max_open_conns")
+	}
+	if (cfg.DB.SSLCert == "") != (cfg.DB.SSLKey == "") {
+		problems = append(problems, "db.sslcert and db.sslkey must be set together")
+	}
+	if cfg.DB.SSLMode == "disable" && (cfg.DB.SSLRootCert != "" || cfg.DB.SSLCert != "") {
+		problems = append(problems, "SSL certificates are set but db.sslmode is disable")
+	}
+
+	// The database settings are only needed to connect to one
+	if cfg.Storage == StorageMemory {
+		missing = nil
+	}
+	if len(missing) > 0 {
+		problems = append([]string{"missing " + strings.Join(missing, ", ")}, problems...)
+	}
+	if len(problems) > 0 {
+		return nil, errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+	return &cfg, nil
+}
+
+// readFile reads a config file into dotted keys such as "db.host".
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return values, parseYAML(string(data), values)
+	case ".json":
+		var doc map[string]interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return values, flatten("", doc, values)
+	}
+	return nil, errors.New("must be a .json, .yaml or .yml file")
+}
+
+func flatten(prefix string, doc map[string]interface{}, values map[string]string) error {
+	for k, v := range doc {
+		key := prefix + k
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if err := flatten(key+".", v, values); err != nil {
+				return err
+			}
+		case string:
+			values[key] = v
+		case float64:
+			values[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		default:
+			return fmt.Errorf("%s: unsupported value", key)
+		}
+	}
+	return nil
+}
+
+// parseYAML reads the subset of YAML a config file needs: nested mappings
+// of scalars, with comments and quoted strings. Sequences, anchors and
+// multi-line values are not supported.
+func parseYAML(data string, values map[string]string) error {
+	type level struct {
+		indent int
+		prefix string
+	}
+	stack := []level{{indent: -1}}
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(stripComment(line), " \t\r")
+		content := strings.TrimLeft(line, " ")
+		if content == "" || content == "---" {
+			continue
+		}
+		if strings.HasPrefix(content, "\t") {
+			return fmt.Errorf("line %d: tabs cannot indent YAML", n+1)
+		}
+		indent := len(line) - len(content)
+
+		key, value, ok := strings.Cut(content, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.HasPrefix(content, "- ") {
+			return fmt.Errorf("line %d: expected \"key: value\"", n+1)
+		}
+		for indent <= stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		key = stack[len(stack)-1].prefix + key
+
+		value = strings.TrimSpace(value)
+		if value == "" {
+			stack = append(stack, level{indent: indent, prefix: key + "."})
+			continue
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			if value[0] == '"' {
+				unquoted, err := strconv.Unquote(value)
+				if err != nil {
+					return fmt.Errorf("line %d: %v", n+1, err)
+				}
+				value = unquoted
+			} else {
+				value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+			}
+		}
+		values[key] = value
+	}
+	return nil
+}
+
+// stripComment removes a # comment that is not inside quotes.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
 	}
+	return line
 }
@@ -1,10 +1,15 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq" // PostgreSQL driver
 )
 
 // Config represents the database connection settings.
@@ -14,23 +19,87 @@ type Config struct {
 	User     string
 	Password string
 	Name     string
+	SSLMode  string
+	TimeZone string
+	// MaxOpenConns and MaxIdleConns size the connection pool; 0 leaves
+	// the number of open connections unlimited.
+	MaxOpenConns int
+	MaxIdleConns int
+	// ConnMaxLifetime closes pooled connections after this long; 0 keeps
+	// them open.
+	ConnMaxLifetime time.Duration
+	// ConnectTimeout is how long Connect keeps retrying while the
+	// database is not yet reachable.
+	ConnectTimeout time.Duration
+	// SSLRootCert verifies the server for sslmode verify-ca and
+	// verify-full; SSLCert and SSLKey are the client certificate.
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
 }
 
-// Connect establishes a connection to the PostgreSQL database.
-func Connect(cfg Config) (*sql.DB, error) {
-	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name,
-	)
+const (
+	initialBackoff = 250 * time.Millisecond
+	maxBackoff     = 5 * time.Second
+)
 
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		return nil, err
+// connString builds a lib/pq connection string, quoting every value.
+func connString(cfg Config) string {
+	params := []struct{ key, value string }{
+		{"host", cfg.Host},
+		{"port", cfg.Port},
+		{"user", cfg.User},
+		{"password", cfg.Password},
+		{"dbname", cfg.Name},
+		{"sslmode", cfg.SSLMode},
+		{"timezone", cfg.TimeZone},
+		{"sslrootcert", cfg.SSLRootCert},
+		{"sslcert", cfg.SSLCert},
+		{"sslkey", cfg.SSLKey},
+	}
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	var parts []string
+	for _, p := range params {
+		if p.value != "" {
+			parts = append(parts, fmt.Sprintf("%s='%s'", p.key, quote.Replace(p.value)))
+		}
 	}
+	return strings.Join(parts, " ")
+}
 
-	if err := db.Ping(); err != nil {
+// Connect establishes a connection to the PostgreSQL database. While the
+// database is unreachable it retries with exponential backoff until
+// cfg.ConnectTimeout has passed.
+func Connect(cfg Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", connString(cfg))
+	if err != nil {
 		return nil, err
 	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
-	return db, nil
+	deadline := time.Now().Add(cfg.ConnectTimeout)
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), maxBackoff)
+		err = db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return db, nil
+		}
+		// A server that rejects the login or database will not change its mind
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && (pqErr.Code.Class() == "28" || pqErr.Code.Class() == "3D") {
+			db.Close()
+			return nil, err
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			db.Close()
+			return nil, fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		}
+		log.Printf("Database not ready (attempt %d): %v; retrying in %s", attempt, err, backoff)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxBackoff)
+	}
 }
@@ -2,35 +2,399 @@ package db
 
 import (
 	"encoding/json"
+	"math"
 	"time"
 )
 
+// Loyalty programme rules.
+const (
+	// PointsPerCurrencyUnit is how many points a closed order earns per unit spent.
+	PointsPerCurrencyUnit = 1
+	// PointValue is the discount one point is worth when redeemed.
+	PointValue = 0.01
+	// PunchCardSize is the number of coffees in a punch card; the last one is free.
+	PunchCardSize = 10
+	// PunchCardCategory is the menu category that collects punches, together
+	// with its subcategories.
+	PunchCardCategory = "Beverage"
+)
+
 type Order struct {
-	ID                  int             `json:"id"`
-	CustomerID          int             `json:"customer_id"`
-	TotalAmount         float64         `json:"total_amount"`
-	Status              string          `json:"status"`
-	SpecialInstructions json.RawMessage `json:"special_instructions,omitempty"`
-	PaymentMethod       string          `json:"payment_method"`
-	CreatedAt           time.Time       `json:"created_at"`
-	UpdatedAt           time.Time       `json:"updated_at"`
+	ID                  int                `json:"id"`
+	CustomerID          int                `json:"customer_id" validate:"required"`
+	TotalAmount         float64            `json:"total_amount" validate:"min=0"`
+	Status              string             `json:"status" validate:"oneof=open closed"`
+	SpecialInstructions json.RawMessage    `json:"special_instructions,omitempty"`
+	PaymentMethod       string             `json:"payment_method" validate:"required,oneof=cash card kaspi_qr"`
+	DiscountAmount      float64            `json:"discount_amount"`
+	PromoCode           string             `json:"promo_code,omitempty"`
+	RedeemPoints        int                `json:"redeem_points,omitempty" validate:"min=0"`
+	Items               []OrderItem        `json:"items,omitempty"`
+	AppliedPromotions   []AppliedPromotion `json:"applied_promotions,omitempty"`
+	CreatedAt           time.Time          `json:"created_at"`
+	UpdatedAt           time.Time          `json:"updated_at"`
 }
 
+// OrderReceipt is what placing an order with items reports back: the amounts
+// charged after promotions and loyalty redemptions.
+type OrderReceipt struct {
+	OrderID           int                `json:"order_id"`
+	TotalAmount       float64            `json:"total_amount"`
+	DiscountAmount    float64            `json:"discount_amount"`
+	AppliedPromotions []AppliedPromotion `json:"applied_promotions"`
+	Loyalty           LoyaltyRedemption  `json:"loyalty"`
+}
+
+type OrderItem struct {
+	MenuItemID   string         `json:"menu_item_id" validate:"required"`
+	Quantity     int            `json:"quantity" validate:"gt=0"`
+	Modifiers    []int          `json:"modifiers,omitempty"` // ids of the selected modifiers
+	Choices      []BundleChoice `json:"choices,omitempty"`   // items picked for bundle choice slots
+	PriceAtOrder float64        `json:"price_at_order,omitempty"`
+}
+
+// BundleChoice picks the menu item for a choice slot of a bundle.
+type BundleChoice struct {
+	ComponentID int    `json:"component_id"`
+	MenuItemID  string `json:"menu_item_id"`
+}
+
+// MenuItem is a sellable size variant of a MenuProduct.
 type MenuItem struct {
 	ID          string   `json:"id"`
+	ProductID   int      `json:"product_id"`
 	Name        string   `json:"name"`
 	Description string   `json:"description"`
-	Price       float64  `json:"price"`
-	Allergens   []string `json:"allergens"`
-	Category    string   `json:"category"`
-	Size        string   `json:"size"`
+	Price       float64  `json:"price" validate:"gt=0"`
+	Allergens   []string `json:"allergens"` // derived from the recipe, read-only
+	CategoryID  int      `json:"category_id"`
+	Category    string   `json:"category"` // name of CategoryID
+	Size        string   `json:"size" validate:"required,oneof=small medium large"`
+	RecipeScale float64  `json:"recipe_scale" validate:"min=0"` // multiplies the product recipe
+
+	Nutrition      *Nutrition        `json:"nutrition,omitempty"`
+	ModifierGroups []ModifierGroup   `json:"modifier_groups,omitempty"`
+	Components     []BundleComponent `json:"components,omitempty"` // set for bundle items
+}
+
+// BundleComponent is one slot of a bundle menu item: either a fixed menu item
+// or a choice of any item of a category or its subcategories, optionally
+// restricted to one size.
+type BundleComponent struct {
+	ID               int    `json:"id"`
+	Name             string `json:"name" validate:"required"`
+	MenuItemID       string `json:"menu_item_id,omitempty"`
+	ChoiceCategoryID int    `json:"choice_category_id,omitempty"`
+	ChoiceCategory   string `json:"choice_category,omitempty"`
+	ChoiceSize       string `json:"choice_size,omitempty" validate:"oneof=small medium large"`
+	Quantity         int    `json:"quantity" validate:"min=0"`
+}
+
+// MenuProduct groups the size variants of one drink or dish and holds the
+// recipe they share.
+type MenuProduct struct {
+	ID          int                `json:"id"`
+	Name        string             `json:"name" validate:"required"`
+	Description string             `json:"description"`
+	CategoryID  int                `json:"category_id"`
+	Category    string             `json:"category"`
+	Ingredients []RecipeIngredient `json:"ingredients,omitempty"`
+	Variants    []MenuItem         `json:"variants"`
+}
+
+// Category groups menu items. Categories nest, and siblings are listed by
+// SortOrder.
+type Category struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name" validate:"required"`
+	ParentID  int        `json:"parent_id,omitempty"`
+	SortOrder int        `json:"sort_order"`
+	Children  []Category `json:"children,omitempty"`
+}
+
+type RecipeIngredient struct {
+	IngredientID string  `json:"ingredient_id" validate:"required"`
+	Quantity     float64 `json:"quantity" validate:"gt=0"`
+	Unit         string  `json:"unit,omitempty"` // defaults to the stock unit
 }
 
 type Inventory struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Stock       float64   `json:"stock"`
-	Price       float64   `json:"price"`
-	UnitType    string    `json:"unit_type"`
-	LastUpdated time.Time `json:"last_updated"` // default now
+	ID            string           `json:"id"`
+	Name          string           `json:"name" validate:"required"`
+	Stock         float64          `json:"stock" validate:"min=0"`
+	Price         float64          `json:"price" validate:"gt=0"`
+	UnitType      string           `json:"unit_type" validate:"required"` // stock unit
+	PurchaseUnit  *PurchaseUnit    `json:"purchase_unit,omitempty"`
+	Reorder       *ReorderPolicy   `json:"reorder,omitempty"`
+	BatchTracking *BatchTracking   `json:"batch_tracking,omitempty"`
+	Batches       []InventoryBatch `json:"batches,omitempty"`   // remaining lots, earliest expiry first
+	Allergens     []string         `json:"allergens"`           // allergen codes
+	Nutrition     *Nutrition       `json:"nutrition,omitempty"` // per unit_type
+	LastUpdated   time.Time        `json:"last_updated"`        // default now
+}
+
+// Restock is stock received for an inventory item outside a purchase order.
+type Restock struct {
+	Amount    float64 `json:"amount" validate:"gt=0"`
+	Unit      string  `json:"unit"`       // defaults to the stock unit
+	ExpiresOn string  `json:"expires_on"` // batch-tracked items, defaults to the shelf life
+}
+
+// RestockResult reports the amount added, in the stock unit, and the new stock.
+type RestockResult struct {
+	ID       string  `json:"id"`
+	Added    float64 `json:"added"`
+	Stock    float64 `json:"stock"`
+	UnitType string  `json:"unit_type"`
+}
+
+// Nutrition holds calories (kcal) and macros (g). UpTo is set for bundles
+// whose values depend on the items chosen; they are then the maximum.
+type Nutrition struct {
+	Calories      float64 `json:"calories" validate:"min=0"`
+	Protein       float64 `json:"protein" validate:"min=0"`
+	Carbohydrates float64 `json:"carbohydrates" validate:"min=0"`
+	Fat           float64 `json:"fat" validate:"min=0"`
+	UpTo          bool    `json:"up_to,omitempty"`
+}
+
+type Allergen struct {
+	Code string `json:"code" validate:"required"`
+	Name string `json:"name" validate:"required"`
+}
+
+type Promotion struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name" validate:"required"`
+	DiscountType string     `json:"discount_type" validate:"required,oneof=percentage fixed buy_x_get_y"` // percentage, fixed or buy_x_get_y
+	Value        float64    `json:"value"`
+	Code         string     `json:"code,omitempty"` // empty means applied automatically
+	UsageLimit   int        `json:"usage_limit,omitempty" validate:"min=0"`
+	TimesUsed    int        `json:"times_used"`
+	CategoryID   int        `json:"category_id,omitempty"` // includes subcategories
+	Category     string     `json:"category,omitempty"`
+	MenuItemID   string     `json:"menu_item_id,omitempty"`
+	BuyQuantity  int        `json:"buy_quantity,omitempty"`
+	GetQuantity  int        `json:"get_quantity,omitempty"`
+	StartTime    string     `json:"start_time,omitempty"` // daily window, e.g. "15:00"
+	EndTime      string     `json:"end_time,omitempty"`
+	ValidFrom    *time.Time `json:"valid_from,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	IsActive     bool       `json:"is_active"`
+}
+
+type AppliedPromotion struct {
+	PromotionID    int     `json:"promotion_id"`
+	Name           string  `json:"name"`
+	Code           string  `json:"code,omitempty"`
+	DiscountAmount float64 `json:"discount_amount"`
+}
+
+type Customer struct {
+	ID          int             `json:"id"`
+	Name        string          `json:"name" validate:"required"`
+	Email       string          `json:"email,omitempty"`
+	Preferences json.RawMessage `json:"preferences,omitempty"`
+}
+
+type LoyaltyTransaction struct {
+	ID              int       `json:"id"`
+	OrderID         *int      `json:"order_id,omitempty"`
+	TransactionType string    `json:"transaction_type"` // earned, redeemed, reward or adjusted
+	Points          int       `json:"points"`
+	Punches         int       `json:"punches"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// LoyaltyRedemption describes the loyalty discounts taken on a new order.
+type LoyaltyRedemption struct {
+	PointsRedeemed int     `json:"points_redeemed,omitempty"`
+	PointsDiscount float64 `json:"points_discount,omitempty"`
+	FreeCoffees    int     `json:"free_coffees,omitempty"`
+	RewardDiscount float64 `json:"reward_discount,omitempty"`
+}
+
+func (l LoyaltyRedemption) Discount() float64 {
+	return math.Round((l.PointsDiscount+l.RewardDiscount)*100) / 100
+}
+
+type LoyaltyAccount struct {
+	CustomerID      int                  `json:"customer_id"`
+	Balance         int                  `json:"balance"`
+	BalanceValue    float64              `json:"balance_value"`
+	Punches         int                  `json:"punches"`
+	PunchesToReward int                  `json:"punches_to_reward"`
+	History         []LoyaltyTransaction `json:"history"`
+}
+
+type ModifierGroup struct {
+	ID            int        `json:"id"`
+	Name          string     `json:"name" validate:"required"`
+	MinSelections int        `json:"min_selections" validate:"min=0"`
+	MaxSelections int        `json:"max_selections" validate:"min=1"`
+	Modifiers     []Modifier `json:"modifiers" validate:"required"`
+	MenuItemIDs   []string   `json:"menu_item_ids,omitempty"`
+}
+
+type Modifier struct {
+	ID          int                  `json:"id"`
+	Name        string               `json:"name" validate:"required"`
+	PriceDelta  float64              `json:"price_delta"`
+	Ingredients []ModifierIngredient `json:"ingredients,omitempty"`
+}
+
+// ModifierIngredient changes the recipe of the item the modifier is applied to.
+// A negative delta removes an ingredient, e.g. oat milk replacing milk.
+type ModifierIngredient struct {
+	IngredientID  string  `json:"ingredient_id" validate:"required"`
+	QuantityDelta float64 `json:"quantity_delta"`
+	Unit          string  `json:"unit,omitempty"` // defaults to the stock unit
+}
+
+// Unit is a unit of measure. Quantities convert between units of the same
+// Dimension; Factor is the size of the unit in the dimension's base unit.
+type Unit struct {
+	Code      string  `json:"code" validate:"required"`
+	Name      string  `json:"name" validate:"required"`
+	Dimension string  `json:"dimension" validate:"required"`
+	Factor    float64 `json:"factor" validate:"gt=0"`
+}
+
+// ReorderPolicy tells when and from whom an inventory item is reordered:
+// stock at or below Level is topped up to ParLevel (in the stock unit).
+type ReorderPolicy struct {
+	SupplierID int     `json:"supplier_id,omitempty"`
+	Supplier   string  `json:"supplier,omitempty"`
+	Level      float64 `json:"level" validate:"min=0"`
+	ParLevel   float64 `json:"par_level" validate:"min=0"`
+}
+
+type Supplier struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name" validate:"required"`
+	ContactName  string    `json:"contact_name,omitempty"`
+	Email        string    `json:"email,omitempty"`
+	Phone        string    `json:"phone,omitempty"`
+	LeadTimeDays int       `json:"lead_time_days" validate:"min=0"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// PurchaseOrder is stock ordered from a supplier. It moves from draft to sent
+// and is then received, possibly in several deliveries.
+type PurchaseOrder struct {
+	ID         int                 `json:"id"`
+	SupplierID int                 `json:"supplier_id" validate:"required,gt=0"`
+	Supplier   string              `json:"supplier"`
+	Status     string              `json:"status"` // draft, sent, partially_received or received
+	Notes      string              `json:"notes,omitempty"`
+	Items      []PurchaseOrderItem `json:"items" validate:"required"`
+	Total      float64             `json:"total"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+	SentAt     *time.Time          `json:"sent_at,omitempty"`
+	ReceivedAt *time.Time          `json:"received_at,omitempty"`
+}
+
+// PurchaseOrderItem is one inventory item on a purchase order. Quantity and
+// UnitCost may be sent in any compatible unit or the item's purchase unit;
+// they are stored and returned in the stock unit.
+type PurchaseOrderItem struct {
+	InventoryID      string  `json:"inventory_id" validate:"required"`
+	Name             string  `json:"name,omitempty"`
+	Quantity         float64 `json:"quantity" validate:"gt=0"`
+	Unit             string  `json:"unit,omitempty"`
+	UnitCost         float64 `json:"unit_cost" validate:"min=0"` // defaults to the current inventory cost
+	ReceivedQuantity float64 `json:"received_quantity"`
+	ExpiresOn        string  `json:"expires_on,omitempty"` // receipts of batch-tracked items, YYYY-MM-DD
+}
+
+// SuggestedOrder is the stock to order from one supplier to bring items at or
+// below their reorder level back up to par.
+type SuggestedOrder struct {
+	SupplierID     int             `json:"supplier_id,omitempty"` // unset for items without a supplier
+	Supplier       string          `json:"supplier,omitempty"`
+	LeadTimeDays   int             `json:"lead_time_days"`
+	Items          []SuggestedItem `json:"items"`
+	EstimatedTotal float64         `json:"estimated_total"`
+}
+
+type SuggestedItem struct {
+	InventoryID  string  `json:"inventory_id"`
+	Name         string  `json:"name"`
+	Stock        float64 `json:"stock"`
+	OnOrder      float64 `json:"on_order"`
+	ReorderLevel float64 `json:"reorder_level"`
+	ParLevel     float64 `json:"par_level"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit"`
+	PurchaseUnit string  `json:"purchase_unit,omitempty"`
+	Packs        float64 `json:"packs,omitempty"`
+	UnitCost     float64 `json:"unit_cost"`
+}
+
+// StockTake is a physical count of the stockroom. While open, counts are
+// compared with the current system stock; committing sets stock to the
+// counted quantities and keeps the variances.
+type StockTake struct {
+	ID            int             `json:"id"`
+	Status        string          `json:"status"` // open or committed
+	Notes         string          `json:"notes,omitempty"`
+	OpenedAt      time.Time       `json:"opened_at"`
+	CommittedAt   *time.Time      `json:"committed_at,omitempty"`
+	ItemsCounted  int             `json:"items_counted"`
+	ShortageValue float64         `json:"shortage_value"` // at cost
+	SurplusValue  float64         `json:"surplus_value"`
+	NetVariance   float64         `json:"net_variance_value"`
+	Lines         []StockTakeLine `json:"lines,omitempty"`
+}
+
+// StockTakeLine compares the counted and the system quantity of one item.
+type StockTakeLine struct {
+	InventoryID     string  `json:"inventory_id"`
+	Name            string  `json:"name"`
+	Unit            string  `json:"unit"`
+	SystemQuantity  float64 `json:"system_quantity"`
+	CountedQuantity float64 `json:"counted_quantity"`
+	Variance        float64 `json:"variance"`
+	UnitCost        float64 `json:"unit_cost"`
+	VarianceValue   float64 `json:"variance_value"`
+}
+
+// StockCount is a counted quantity submitted to a stock take, in any
+// compatible unit or the item's purchase unit.
+type StockCount struct {
+	InventoryID string  `json:"inventory_id" validate:"required"`
+	Quantity    float64 `json:"quantity" validate:"min=0"`
+	Unit        string  `json:"unit,omitempty"`
+}
+
+// BatchTracking turns on lot tracking for an inventory item. Received stock
+// expires ShelfLifeDays after receipt unless an expiry date is given.
+type BatchTracking struct {
+	Enabled       bool `json:"enabled"`
+	ShelfLifeDays int  `json:"shelf_life_days,omitempty" validate:"min=0"`
+}
+
+// InventoryBatch is one lot of a batch-tracked inventory item. Quantity is
+// what is left of it, in the stock unit.
+type InventoryBatch struct {
+	ID               int       `json:"id"`
+	InventoryID      string    `json:"inventory_id"`
+	Name             string    `json:"name,omitempty"`
+	Quantity         float64   `json:"quantity"`
+	ReceivedQuantity float64   `json:"received_quantity"`
+	Unit             string    `json:"unit"`
+	UnitCost         float64   `json:"unit_cost"`
+	ExpiresOn        string    `json:"expires_on,omitempty"` // YYYY-MM-DD
+	DaysLeft         *int      `json:"days_left,omitempty"`  // negative once expired
+	ReceivedAt       time.Time `json:"received_at"`
+	PurchaseOrderID  int       `json:"purchase_order_id,omitempty"`
+}
+
+// PurchaseUnit is the pack an inventory item is bought in, e.g. a case
+// holding 12 l of milk.
+type PurchaseUnit struct {
+	Name     string  `json:"name" validate:"required"`
+	Quantity float64 `json:"quantity" validate:"gt=0"`
+	Unit     string  `json:"unit"`
 }
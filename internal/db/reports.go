@@ -0,0 +1,139 @@
+package db
+
+// Rows of the reports under /reports.
+
+type PopularItem struct {
+	ID            string  `json:"menuItemID"`
+	Name          string  `json:"name"`
+	Description   string  `json:"description"`
+	TotalQuantity float64 `json:"totalQuantity"`
+	Revenue       float64 `json:"revenue"`
+	BundleRevenue float64 `json:"bundleRevenue"`
+}
+
+type OrderedItemCount struct {
+	ID        string `json:"menuItemID"`
+	Total     int    `json:"totalQuantity"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type SearchResults struct {
+	MenuItems    []MenuItemMatch `json:"menu_items"`
+	Orders       []OrderMatch    `json:"orders"`
+	TotalMatches int             `json:"total_matches"`
+}
+
+type MenuItemMatch struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Relevance   float32 `json:"relevance"`
+}
+
+type OrderMatch struct {
+	ID           int      `json:"id"`
+	CustomerName string   `json:"customer_name"`
+	Items        []string `json:"items"`
+	Total        float64  `json:"total"`
+	Relevance    float32  `json:"relevance"`
+}
+
+type Leftover struct {
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+type PromotionUsage struct {
+	PromotionID       int     `json:"promotion_id"`
+	Name              string  `json:"name"`
+	Code              string  `json:"code,omitempty"`
+	DiscountType      string  `json:"discount_type"`
+	TimesApplied      int     `json:"times_applied"`
+	TotalDiscount     float64 `json:"total_discount"`
+	DiscountedRevenue float64 `json:"discounted_revenue"`
+	UsageLimit        *int    `json:"usage_limit,omitempty"`
+}
+
+// ItemMargin is the current gross margin of a menu item.
+type ItemMargin struct {
+	ProductID      int     `json:"product_id"`
+	Product        string  `json:"product"`
+	MenuItemID     string  `json:"menu_item_id"`
+	Name           string  `json:"name"`
+	Size           string  `json:"size"`
+	Price          float64 `json:"price"`
+	IngredientCost float64 `json:"ingredient_cost"`
+	GrossMargin    float64 `json:"gross_margin"`
+	MarginPercent  float64 `json:"margin_percent"`
+	BelowThreshold bool    `json:"below_threshold"`
+	CostVaries     bool    `json:"cost_varies,omitempty"` // bundles costed with their priciest choice
+}
+
+// SoldMargin is the gross margin made on the past sales of a menu item.
+type SoldMargin struct {
+	MenuItemID     string  `json:"menu_item_id"`
+	Name           string  `json:"name"`
+	Size           string  `json:"size"`
+	UnitsSold      float64 `json:"units_sold"`
+	Revenue        float64 `json:"revenue"`
+	IngredientCost float64 `json:"ingredient_cost"`
+	GrossMargin    float64 `json:"gross_margin"`
+	MarginPercent  float64 `json:"margin_percent"`
+	BelowThreshold bool    `json:"below_threshold"`
+}
+
+// PeriodTotals are the figures of one day or month of trading.
+type PeriodTotals struct {
+	Period             string  `json:"period"`
+	Orders             int     `json:"orders"`
+	UnitsSold          float64 `json:"units_sold"`
+	Sales              float64 `json:"sales"`     // menu prices before discounts
+	Discounts          float64 `json:"discounts"` // promotions and loyalty
+	Revenue            float64 `json:"revenue"`
+	COGS               float64 `json:"cogs"`
+	WriteOffs          float64 `json:"write_offs"`
+	GrossProfit        float64 `json:"gross_profit"`
+	GrossMarginPercent float64 `json:"gross_margin_percent"`
+}
+
+// StockLevel is the stock on hand of an inventory item.
+type StockLevel struct {
+	InventoryID string
+	Name        string
+	Unit        string
+	Stock       float64
+}
+
+// MenuLabel is the allergen and nutrition label of a menu item. Category is
+// the full category path, e.g. "Beverage > Coffee".
+type MenuLabel struct {
+	Category  string
+	ID        string
+	Name      string
+	Size      string
+	Price     float64
+	Allergens []string
+	Nutrition Nutrition
+}
+
+// IngredientForecast is the projected consumption of one inventory item.
+type IngredientForecast struct {
+	InventoryID   string          `json:"inventory_id"`
+	Name          string          `json:"name"`
+	Unit          string          `json:"unit"`
+	Stock         float64         `json:"stock"`
+	AverageDaily  float64         `json:"average_daily"`  // over the whole history
+	MovingAverage float64         `json:"moving_average"` // over the last 7 days
+	Forecast      []DailyForecast `json:"forecast"`
+	ForecastTotal float64         `json:"forecast_total"`
+	DaysOfCover   *float64        `json:"days_of_cover"` // null when nothing is used
+	StockoutDate  string          `json:"stockout_date,omitempty"`
+	Short         bool            `json:"short"` // stock does not cover the forecast
+}
+
+type DailyForecast struct {
+	Date     string  `json:"date"`
+	Quantity float64 `json:"quantity"`
+}
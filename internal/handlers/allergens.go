@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+func GetAllergens(repo repository.AllergenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		allergens, err := repo.List(r.Context())
+		if err != nil {
+			writeError(w, err, "Failed to fetch allergens")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(allergens)
+	}
+}
+
+func CreateAllergen(repo repository.AllergenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var allergen db.Allergen
+		if err := decodeJSON(w, r, &allergen); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		allergen.Code = strings.ToLower(strings.TrimSpace(allergen.Code))
+		errs := validate.Struct(allergen)
+		if strings.ContainsAny(allergen.Code, ", ") {
+			errs.Add("code", "cannot contain commas or spaces")
+		}
+		if err := errs.Err(); err != nil {
+			writeError(w, err, "Invalid allergen")
+			return
+		}
+
+		if err := repo.Create(r.Context(), allergen); err != nil {
+			writeError(w, err, "Failed to create allergen")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(allergen)
+	}
+}
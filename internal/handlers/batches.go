@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"frappuccino/internal/service"
+)
+
+// GetExpiringBatches lists batches with stock left that expire within
+// ?days= days (2 by default), including those already expired, with the
+// value at risk.
+func GetExpiringBatches(inventory *service.Inventory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		days := 2
+		if v := r.URL.Query().Get("days"); v != "" {
+			d, err := strconv.Atoi(v)
+			if err != nil {
+				httpError(w, "days must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			days = d
+		}
+
+		batches, value, err := inventory.ExpiringBatches(r.Context(), days)
+		if err != nil {
+			writeError(w, err, "Failed to fetch batches")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"days":    days,
+			"value":   value,
+			"batches": batches,
+		})
+	}
+}
+
+// WriteOffBatch writes off what is left of a batch, or the "quantity" given
+// in the body, and records a 'written off' inventory transaction.
+func WriteOffBatch(inventory *service.Inventory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid batch ID", http.StatusBadRequest)
+			return
+		}
+
+		var req struct {
+			Quantity float64 `json:"quantity"` // defaults to the whole batch
+		}
+		if err := decodeJSON(w, r, &req); err != nil && err != io.EOF {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		b, err := inventory.WriteOffBatch(r.Context(), id, req.Quantity)
+		if err != nil {
+			writeError(w, err, "Failed to write off batch")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(b)
+	}
+}
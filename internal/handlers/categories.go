@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+func CreateCategory(repo repository.CategoryRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var category db.Category
+		if err := decodeJSON(w, r, &category); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		category.Name = strings.TrimSpace(category.Name)
+		if err := validate.Struct(category).Err(); err != nil {
+			writeError(w, err, "Invalid category")
+			return
+		}
+
+		id, err := repo.Create(r.Context(), category)
+		if err != nil {
+			writeError(w, err, "Failed to create category")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+// GetCategories returns the category tree in display order
+func GetCategories(repo repository.CategoryRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		categories, err := repo.Tree(r.Context())
+		if err != nil {
+			writeError(w, err, "Failed to fetch categories")
+			return
+		}
+		if categories == nil {
+			categories = make([]db.Category, 0)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(categories)
+	}
+}
+
+func GetCategoryByID(repo repository.CategoryRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid category ID", http.StatusBadRequest)
+			return
+		}
+
+		category, err := repo.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to fetch category")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(category)
+	}
+}
+
+func UpdateCategory(repo repository.CategoryRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid category ID", http.StatusBadRequest)
+			return
+		}
+
+		var category db.Category
+		if err := decodeJSON(w, r, &category); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		category.Name = strings.TrimSpace(category.Name)
+		if err := validate.Struct(category).Err(); err != nil {
+			writeError(w, err, "Invalid category")
+			return
+		}
+
+		if err := repo.Update(r.Context(), id, category); err != nil {
+			writeError(w, err, "Failed to update category")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+func DeleteCategory(repo repository.CategoryRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid category ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.Delete(r.Context(), id); err != nil {
+			writeError(w, err, "Failed to delete category")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"frappuccino/internal/repository"
+)
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// marginPercent returns the gross margin as a percentage of the price.
+func marginPercent(price, cost float64) float64 {
+	if price == 0 {
+		return 0
+	}
+	return roundMoney((price - cost) / price * 100)
+}
+
+// MarginReport returns ingredient cost, price and gross margin of every menu
+// item and flags items whose margin is below the threshold (in percent, from
+// the configuration unless ?threshold= is given). With ?view=history it
+// reports the margins actually made on past sales, using the costs recorded
+// at the time of sale, optionally limited by startDate and endDate.
+func MarginReport(reports repository.ReportRepository, defaultThreshold float64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		threshold := defaultThreshold
+		if v := r.URL.Query().Get("threshold"); v != "" {
+			t, err := strconv.ParseFloat(v, 64)
+			if err != nil || t < 0 || t > 100 {
+				httpError(w, "threshold must be a percentage between 0 and 100", http.StatusBadRequest)
+				return
+			}
+			threshold = t
+		}
+
+		switch view := r.URL.Query().Get("view"); view {
+		case "", "current":
+			currentMargins(w, r, reports, threshold)
+		case "history":
+			historicalMargins(w, r, reports, threshold)
+		default:
+			httpError(w, "view must be current or history", http.StatusBadRequest)
+		}
+	}
+}
+
+func currentMargins(w http.ResponseWriter, r *http.Request, reports repository.ReportRepository, threshold float64) {
+	items, err := reports.CurrentMargins(r.Context())
+	if err != nil {
+		writeError(w, err, "Failed to compute ingredient cost")
+		return
+	}
+
+	belowThreshold := 0
+	for i := range items {
+		m := &items[i]
+		cost := m.IngredientCost
+		m.IngredientCost = roundMoney(cost)
+		m.GrossMargin = roundMoney(m.Price - cost)
+		m.MarginPercent = marginPercent(m.Price, cost)
+		m.BelowThreshold = m.MarginPercent < threshold
+		if m.BelowThreshold {
+			belowThreshold++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"threshold_percent": threshold,
+		"below_threshold":   belowThreshold,
+		"items":             items,
+	})
+}
+
+func historicalMargins(w http.ResponseWriter, r *http.Request, reports repository.ReportRepository, threshold float64) {
+	startDate := r.URL.Query().Get("startDate")
+	endDate := r.URL.Query().Get("endDate")
+
+	items, err := reports.SoldMargins(r.Context(), startDate, endDate)
+	if err != nil {
+		writeError(w, err, "Failed to fetch sales")
+		return
+	}
+
+	var revenue, cost float64
+	for i := range items {
+		m := &items[i]
+		revenue += m.Revenue
+		cost += m.IngredientCost
+		m.GrossMargin = roundMoney(m.Revenue - m.IngredientCost)
+		m.MarginPercent = marginPercent(m.Revenue, m.IngredientCost)
+		m.BelowThreshold = m.MarginPercent < threshold
+		m.Revenue = roundMoney(m.Revenue)
+		m.IngredientCost = roundMoney(m.IngredientCost)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"threshold_percent": threshold,
+		"revenue":           roundMoney(revenue),
+		"ingredient_cost":   roundMoney(cost),
+		"gross_margin":      roundMoney(revenue - cost),
+		"margin_percent":    marginPercent(revenue, cost),
+		"items":             items,
+	})
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"frappuccino/internal/repository"
+)
+
+// errorBody is the JSON body of every error response. Code is stable and
+// meant for programs, Message for people. Details adds what is specific to
+// this failure, e.g. the ingredient that ran out, and Fields lists the
+// request fields at fault.
+type errorBody struct {
+	Code    string                  `json:"code"`
+	Message string                  `json:"message"`
+	Details string                  `json:"details,omitempty"`
+	Fields  []repository.FieldError `json:"fields,omitempty"`
+}
+
+// statusCodes is the code of failures that have no more specific one.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            "invalid_request",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusConflict:              "conflict",
+	http.StatusRequestEntityTooLarge: "body_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+	http.StatusUnprocessableEntity:   "unprocessable",
+	http.StatusInternalServerError:   "internal_error",
+}
+
+func writeErrorBody(w http.ResponseWriter, status int, body errorBody) {
+	if body.Code == "" {
+		body.Code = statusCodes[status]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// httpError reports a failure the handler found itself. It is http.Error
+// with a JSON body.
+func httpError(w http.ResponseWriter, msg string, status int) {
+	writeErrorBody(w, status, errorBody{Message: msg})
+}
+
+// RouteErrors wraps mux so that requests it has no route for, or no route
+// for their method, get the same JSON error body as every other failure.
+func RouteErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		// The mux answers with a plain text 404 or 405; keep only its status
+		// and the Allow header of a 405.
+		miss := &routeMiss{header: http.Header{}, status: http.StatusNotFound}
+		h.ServeHTTP(miss, r)
+		if allow := miss.header.Get("Allow"); allow != "" {
+			w.Header().Set("Allow", allow)
+		}
+		httpError(w, http.StatusText(miss.status), miss.status)
+	})
+}
+
+// NotFound answers 404 for a path that must not fall through to a wildcard
+// route, e.g. a literal report path when the storage serves no reports.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	httpError(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+}
+
+// routeMiss records the status the mux gives a request it cannot route.
+type routeMiss struct {
+	header http.Header
+	status int
+}
+
+func (m *routeMiss) Header() http.Header         { return m.header }
+func (m *routeMiss) Write(b []byte) (int, error) { return len(b), nil }
+func (m *routeMiss) WriteHeader(status int)      { m.status = status }
+
+// invalidBody reports a request body that could not be decoded, pointing at
+// the field of the wrong type when there is one.
+func invalidBody(w http.ResponseWriter, err error) {
+	body := errorBody{Code: "invalid_body", Message: "Invalid request body"}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var sizeErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &sizeErr):
+		writeErrorBody(w, http.StatusRequestEntityTooLarge, errorBody{
+			Message: fmt.Sprintf("Request body is larger than %d bytes", sizeErr.Limit),
+		})
+		return
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		body.Fields = []repository.FieldError{{Field: field, Message: "is not a known field"}}
+	case errors.Is(err, errTrailingData):
+		body.Details = "the body must hold a single JSON value"
+	case errors.Is(err, errPatchNotObject):
+		body.Details = "a merge patch must be a JSON object"
+	case errors.Is(err, io.EOF):
+		body.Details = "the body is empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		body.Details = "the JSON ends unexpectedly"
+	case errors.As(err, &syntaxErr):
+		body.Details = fmt.Sprintf("malformed JSON at byte %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		body.Fields = []repository.FieldError{{Field: typeErr.Field, Message: "must be " + jsonType(typeErr.Type)}}
+	default:
+		body.Details = "the body does not match the expected format"
+	}
+	writeErrorBody(w, http.StatusBadRequest, body)
+}
+
+// jsonType describes the JSON value expected for a Go type.
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// writeError writes the response for an error returned by a service or
+// repository. Errors the client can act on, including constraint violations
+// reported by the database, are described; anything else is logged and
+// reported as msg with status 500.
+func writeError(w http.ResponseWriter, err error, msg string) {
+	var e *repository.Error
+	if !errors.As(err, &e) {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			e = dbError(pqErr)
+		}
+	}
+	if e == nil {
+		log.Println(msg+":", err)
+		writeErrorBody(w, http.StatusInternalServerError, errorBody{Message: msg})
+		return
+	}
+
+	var status int
+	switch e.Kind {
+	case repository.ErrNotFound:
+		status = http.StatusNotFound
+	case repository.ErrConflict:
+		status = http.StatusConflict
+	case repository.ErrUnprocessable:
+		status = http.StatusUnprocessableEntity
+	default:
+		status = http.StatusBadRequest
+	}
+	body := errorBody{Code: e.Code, Message: e.Message, Fields: e.Fields}
+	// Errors are wrapped as "<message>: <details>"
+	if text := err.Error(); text != e.Message {
+		body.Details = strings.TrimPrefix(text, e.Message+": ")
+	}
+	writeErrorBody(w, status, body)
+}
+
+var (
+	// keyPattern matches the key in the detail of a constraint violation,
+	// e.g. `Key (name, size)=(Latte, small) already exists.`
+	keyPattern = regexp.MustCompile(`^Key \((.+?)\)=`)
+	// tablePattern matches the table named in the detail of a foreign key
+	// violation.
+	tablePattern = regexp.MustCompile(`table "(\w+)"`)
+	// valuePattern matches the type and value in the message of an invalid
+	// input, e.g. `invalid input value for enum item_size: "huge"`.
+	valuePattern = regexp.MustCompile(`for (?:enum|type) (\w+(?: \w+)*): (".*")$`)
+	// castPattern matches what wraps a column in an index expression, e.g.
+	// lower(name::text).
+	castPattern = regexp.MustCompile(`::[\w ]+|\w+\(|[()]`)
+)
+
+// keyColumns returns the columns of the key a constraint violation is about.
+func keyColumns(pqErr *pq.Error) []string {
+	m := keyPattern.FindStringSubmatch(pqErr.Detail)
+	if m == nil {
+		if pqErr.Column != "" {
+			return []string{pqErr.Column}
+		}
+		return nil
+	}
+	var columns []string
+	for _, c := range strings.Split(m[1], ", ") {
+		columns = append(columns, castPattern.ReplaceAllString(c, ""))
+	}
+	return columns
+}
+
+// humanize turns a table or constraint name into words.
+func humanize(name string) string {
+	return strings.ReplaceAll(name, "_", " ")
+}
+
+// dbError describes a constraint violation or invalid input reported by
+// PostgreSQL that the repository did not explain itself. It returns nil for
+// any other database error, which stays internal.
+func dbError(pqErr *pq.Error) *repository.Error {
+	switch pqErr.Code {
+	case "23505": // unique_violation
+		columns := keyColumns(pqErr)
+		e := &repository.Error{Kind: repository.ErrConflict, Code: "duplicate", Message: "A record with these values already exists"}
+		if len(columns) > 0 {
+			e.Message = fmt.Sprintf("A record with this %s already exists", strings.Join(columns, " and "))
+		}
+		for _, c := range columns {
+			e.Fields = append(e.Fields, repository.FieldError{Field: c, Message: "must be unique"})
+		}
+		return e
+
+	case "23503": // foreign_key_violation
+		table := "another record"
+		if m := tablePattern.FindStringSubmatch(pqErr.Detail); m != nil {
+			table = humanize(m[1])
+		}
+		// Deleting a row that others refer to, or referring to a missing one
+		if strings.Contains(pqErr.Detail, "is still referenced") {
+			return &repository.Error{Kind: repository.ErrConflict, Code: "still_referenced",
+				Message: "The record is still used by " + table}
+		}
+		e := &repository.Error{Kind: repository.ErrUnprocessable, Code: "unknown_reference",
+			Message: "The request refers to " + table + " that do not exist"}
+		for _, c := range keyColumns(pqErr) {
+			e.Fields = append(e.Fields, repository.FieldError{Field: c, Message: "does not exist"})
+		}
+		return e
+
+	case "23514": // check_violation
+		e := &repository.Error{Kind: repository.ErrUnprocessable, Code: "check_violation",
+			Message: "A value is out of the allowed range"}
+		// Postgres names column checks <table>_<column>_check
+		if column := strings.TrimSuffix(strings.TrimPrefix(pqErr.Constraint, pqErr.Table+"_"), "_check"); column != pqErr.Constraint {
+			e.Message = column + " is out of the allowed range"
+			e.Fields = []repository.FieldError{{Field: column, Message: "is out of the allowed range"}}
+		} else if pqErr.Constraint != "" {
+			e.Message = "The values break the rule " + humanize(pqErr.Constraint)
+		}
+		return e
+
+	case "23502": // not_null_violation
+		return &repository.Error{Kind: repository.ErrUnprocessable, Code: "missing_value",
+			Message: pqErr.Column + " is required",
+			Fields:  []repository.FieldError{{Field: pqErr.Column, Message: "is required"}}}
+
+	case "22P02": // invalid_text_representation, e.g. a value outside an enum
+		e := &repository.Error{Kind: repository.ErrInvalid, Code: "invalid_value", Message: "A value has the wrong format"}
+		if m := valuePattern.FindStringSubmatch(pqErr.Message); m != nil {
+			e.Message = fmt.Sprintf("%s is not a valid %s", m[2], humanize(m[1]))
+		}
+		return e
+
+	case "22007", "22008": // invalid_datetime_format, datetime_field_overflow
+		return &repository.Error{Kind: repository.ErrInvalid, Code: "invalid_date",
+			Message: "Dates must be given as YYYY-MM-DD"}
+	}
+	return nil
+}
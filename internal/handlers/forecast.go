@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"frappuccino/internal/service"
+)
+
+// DemandForecast projects the consumption of every ingredient over the next
+// ?days= days (7 by default) from the last ?history= days of sales (28 by
+// default), and compares it with the stock on hand. Ingredients that run out
+// soonest come first.
+func DemandForecast(forecasts *service.Forecasts) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		days := 7
+		if v := r.URL.Query().Get("days"); v != "" {
+			d, err := strconv.Atoi(v)
+			if err != nil || d < 1 || d > 90 {
+				httpError(w, "days must be an integer between 1 and 90", http.StatusBadRequest)
+				return
+			}
+			days = d
+		}
+		history := 28
+		if v := r.URL.Query().Get("history"); v != "" {
+			h, err := strconv.Atoi(v)
+			if err != nil || h < 7 || h > 365 {
+				httpError(w, "history must be an integer between 7 and 365", http.StatusBadRequest)
+				return
+			}
+			history = h
+		}
+
+		today, ingredients, err := forecasts.Demand(r.Context(), days, history)
+		if err != nil {
+			writeError(w, err, "Failed to compute forecast")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"from":         today.Format("2006-01-02"),
+			"days":         days,
+			"history_days": history,
+			"ingredients":  ingredients,
+		})
+	}
+}
@@ -1,68 +1,35 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
-	"fmt"
-	"log"
 	"net/http"
 
 	"frappuccino/internal/db"
+	"frappuccino/internal/service"
 )
 
-func CreateInventoryItem(dbc *sql.DB) http.HandlerFunc {
+func CreateInventoryItem(inventory *service.Inventory) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
 		if r.Header.Get("Content-Type") != "application/json" {
-			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
 			return
 		}
 
 		var item db.Inventory
-		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
-			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		if err := decodeJSON(w, r, &item); err != nil {
+			invalidBody(w, err)
 			return
 		}
 		defer r.Body.Close()
 
-		// Validate required fields
-		if item.Name == "" {
-			http.Error(w, "name is required", http.StatusBadRequest)
-			return
-		}
-		if item.Stock < 0 {
-			http.Error(w, "stock cannot be negative", http.StatusBadRequest)
-			return
-		}
-		if item.Price <= 0 {
-			http.Error(w, "price must be greater than 0", http.StatusBadRequest)
-			return
-		}
-		if item.UnitType == "" {
-			http.Error(w, "unit_type is required", http.StatusBadRequest)
-			return
-		}
-
-		query := `
-			INSERT INTO inventory (id,name, stock, price, unit_type, last_updated)
-			VALUES ($1, $2, $3, $4, $5,NOW())
-			RETURNING id
-		`
-		var id string
-		err := dbc.QueryRowContext(r.Context(), query,
-			item.ID,
-			item.Name,
-			item.Stock,
-			item.Price,
-			item.UnitType,
-		).Scan(&id)
+		id, err := inventory.Create(r.Context(), item)
 		if err != nil {
-			http.Error(w, "Failed to create inventory item: "+err.Error(), http.StatusInternalServerError)
-			log.Println(err)
+			writeError(w, err, "Failed to create inventory item")
 			return
 		}
 
@@ -72,77 +39,40 @@ func CreateInventoryItem(dbc *sql.DB) http.HandlerFunc {
 	}
 }
 
-func GetInventoryItems(dbc *sql.DB) http.HandlerFunc {
+func GetInventoryItems(inventory *service.Inventory) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		query := "SELECT id, name, stock, price, unit_type, last_updated FROM inventory"
-		rows, err := dbc.QueryContext(r.Context(), query)
+		items, err := inventory.List(r.Context())
 		if err != nil {
-			http.Error(w, "Failed to fetch inventory items", http.StatusInternalServerError)
+			writeError(w, err, "Failed to fetch inventory items")
 			return
 		}
-		defer rows.Close()
-
-		var items []db.Inventory
-		for rows.Next() {
-			var item db.Inventory
-			if err := rows.Scan(
-				&item.ID,
-				&item.Name,
-				&item.Stock,
-				&item.Price,
-				&item.UnitType,
-				&item.LastUpdated,
-			); err != nil {
-				http.Error(w, "Failed to scan inventory item", http.StatusInternalServerError)
-				return
-			}
-			items = append(items, item)
-		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(items)
 	}
 }
 
-func GetInventoryItemByID(dbc *sql.DB) http.HandlerFunc {
+func GetInventoryItemByID(inventory *service.Inventory) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		path := r.URL.Path
-		var id string
-		_, err := fmt.Sscanf(path, "/inventory/%s", &id)
-		if err != nil {
-			http.Error(w, "Invalid inventory item ID", http.StatusBadRequest)
+		id := r.PathValue("id")
+		if id == "" {
+			httpError(w, "Invalid inventory item ID", http.StatusBadRequest)
 			return
 		}
 
-		query := `
-			SELECT id, name, stock, price, unit_type, last_updated 
-			FROM inventory 
-			WHERE id = $1
-		`
-		var item db.Inventory
-		err = dbc.QueryRowContext(r.Context(), query, id).Scan(
-			&item.ID,
-			&item.Name,
-			&item.Stock,
-			&item.Price,
-			&item.UnitType,
-			&item.LastUpdated,
-		)
-		if err == sql.ErrNoRows {
-			http.Error(w, "Inventory item not found", http.StatusNotFound)
-			return
-		} else if err != nil {
-			http.Error(w, "Failed to fetch inventory item", http.StatusInternalServerError)
+		item, err := inventory.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to fetch inventory item")
 			return
 		}
 
@@ -151,100 +81,134 @@ func GetInventoryItemByID(dbc *sql.DB) http.HandlerFunc {
 	}
 }
 
-func UpdateInventoryItem(dbc *sql.DB) http.HandlerFunc {
+func UpdateInventoryItem(inventory *service.Inventory) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPut {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
 		if r.Header.Get("Content-Type") != "application/json" {
-			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
 			return
 		}
 
-		path := r.URL.Path
-		var id string
-		_, err := fmt.Sscanf(path, "/inventory/%s", &id)
-		if err != nil {
-			http.Error(w, "Invalid inventory item ID", http.StatusBadRequest)
+		id := r.PathValue("id")
+		if id == "" {
+			httpError(w, "Invalid inventory item ID", http.StatusBadRequest)
 			return
 		}
 
 		var item db.Inventory
-		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
-			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		if err := decodeJSON(w, r, &item); err != nil {
+			invalidBody(w, err)
 			return
 		}
 		defer r.Body.Close()
 
-		// Validate required fields
-		if item.Name == "" {
-			http.Error(w, "name is required", http.StatusBadRequest)
+		if err := inventory.Update(r.Context(), id, item); err != nil {
+			writeError(w, err, "Failed to update inventory item")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode("The inventory was updated succesfully")
+	}
+}
+
+// PatchInventoryItem updates the fields of an inventory item given in a JSON
+// Merge Patch and returns the item. A null reorder, batch_tracking, allergens
+// or nutrition clears it.
+func PatchInventoryItem(inventory *service.Inventory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
-		if item.Stock < 0 {
-			http.Error(w, "stock cannot be negative", http.StatusBadRequest)
+
+		if !isPatchContentType(r) {
+			httpError(w, "Content-Type must be "+mergePatchType, http.StatusUnsupportedMediaType)
 			return
 		}
-		if item.Price <= 0 {
-			http.Error(w, "price must be greater than 0", http.StatusBadRequest)
+
+		id := r.PathValue("id")
+		if id == "" {
+			httpError(w, "Invalid inventory item ID", http.StatusBadRequest)
+			return
+		}
+
+		patch, err := decodePatch(w, r)
+		if err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		current, err := inventory.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to get inventory item")
 			return
 		}
-		if item.UnitType == "" {
-			http.Error(w, "unit_type is required", http.StatusBadRequest)
+		var item db.Inventory
+		if err := applyPatch(current, patch, &item); err != nil {
+			invalidBody(w, err)
 			return
 		}
+		// Update keeps these when nil, so members left out of the patch are
+		// left nil and null ones are replaced by empty values
+		if !patchSets(patch, "reorder") {
+			item.Reorder = nil
+		} else if patchClears(patch, "reorder") {
+			item.Reorder = &db.ReorderPolicy{}
+		}
+		if !patchSets(patch, "batch_tracking") {
+			item.BatchTracking = nil
+		} else if patchClears(patch, "batch_tracking") {
+			item.BatchTracking = &db.BatchTracking{}
+		}
+		if !patchSets(patch, "allergens") {
+			item.Allergens = nil
+		} else if patchClears(patch, "allergens") {
+			item.Allergens = []string{}
+		}
+		if !patchSets(patch, "nutrition") {
+			item.Nutrition = nil
+		} else if patchClears(patch, "nutrition") {
+			item.Nutrition = &db.Nutrition{}
+		}
 
-		query := `
-			UPDATE inventory 
-			SET name = $2, stock = $3, price = $4, unit_type = $5, last_updated = NOW()
-			WHERE id = $1
-			RETURNING id
-		`
-		err = dbc.QueryRowContext(r.Context(), query,
-			id,
-			item.Name,
-			item.Stock,
-			item.Price,
-			item.UnitType,
-		).Scan(&id)
+		if err := inventory.Update(r.Context(), id, item); err != nil {
+			writeError(w, err, "Failed to update inventory item")
+			return
+		}
+		updated, err := inventory.Get(r.Context(), id)
 		if err != nil {
-			http.Error(w, "Failed to update inventory item: "+err.Error(), http.StatusInternalServerError)
+			writeError(w, err, "Failed to get inventory item")
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode("The inventory was updated succesfully")
+		json.NewEncoder(w).Encode(updated)
 	}
 }
 
-func DeleteInventoryItem(dbc *sql.DB) http.HandlerFunc {
+func DeleteInventoryItem(inventory *service.Inventory) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodDelete {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		path := r.URL.Path
-		var id string
-		_, err := fmt.Sscanf(path, "/inventory/%s", &id)
-		if err != nil {
-			http.Error(w, "Invalid inventory item ID", http.StatusBadRequest)
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		query := "DELETE FROM inventory WHERE id = $1"
-		result, err := dbc.ExecContext(r.Context(), query, id)
-		if err != nil {
-			http.Error(w, "Failed to delete inventory item", http.StatusInternalServerError)
+		id := r.PathValue("id")
+		if id == "" {
+			httpError(w, "Invalid inventory item ID", http.StatusBadRequest)
 			return
 		}
 
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			http.Error(w, "Inventory item not found", http.StatusNotFound)
+		if err := inventory.Delete(r.Context(), id); err != nil {
+			writeError(w, err, "Failed to delete inventory item")
 			return
 		}
 
@@ -253,59 +217,36 @@ func DeleteInventoryItem(dbc *sql.DB) http.HandlerFunc {
 	}
 }
 
-// func RestockInventoryItem(dbc *sql.DB) http.HandlerFunc {
-// 	return func(w http.ResponseWriter, r *http.Request) {
-// 		if r.Method != http.MethodPost {
-// 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-// 			return
-// 		}
-
-// 		path := r.URL.Path
-// 		var id string
-// 		_, err := fmt.Sscanf(path, "/inventory/restock/%s", &id)
-// 		if err != nil {
-// 			http.Error(w, "Invalid inventory item ID", http.StatusBadRequest)
-// 			return
-// 		}
-
-// 		type RestockRequest struct {
-// 			Amount float64 `json:"amount"`
-// 		}
-// 		var req RestockRequest
-// 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-// 			http.Error(w, "Invalid request body", http.StatusBadRequest)
-// 			return
-// 		}
-// 		defer r.Body.Close()
-
-// 		if req.Amount <= 0 {
-// 			http.Error(w, "Amount must be greater than 0", http.StatusBadRequest)
-// 			return
-// 		}
-
-// 		query := `
-// 			UPDATE inventory
-// 			SET stock = stock + $2, last_updated = NOW()
-// 			WHERE id = $1
-// 			RETURNING id, stock
-// 		`
-// 		var (
-// 			updatedID string
-// 			newStock  float64
-// 		)
-// 		err = dbc.QueryRowContext(r.Context(), query, id, req.Amount).Scan(&updatedID, &newStock)
-// 		if err == sql.ErrNoRows {
-// 			http.Error(w, "Inventory item not found", http.StatusNotFound)
-// 			return
-// 		} else if err != nil {
-// 			http.Error(w, "Failed to restock item", http.StatusInternalServerError)
-// 			return
-// 		}
-
-// 		w.Header().Set("Content-Type", "application/json")
-// 		json.NewEncoder(w).Encode(map[string]interface{}{
-// 			"id":    updatedID,
-// 			"stock": newStock,
-// 		})
-// 	}
-// }
+// RestockInventoryItem adds stock received for an inventory item. The amount
+// may be given in any compatible unit or in the item's purchase unit, e.g.
+// {"amount": 2, "unit": "case"}.
+func RestockInventoryItem(inventory *service.Inventory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.PathValue("id")
+		if id == "" {
+			httpError(w, "Invalid inventory item ID", http.StatusBadRequest)
+			return
+		}
+
+		var req db.Restock
+		if err := decodeJSON(w, r, &req); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		result, err := inventory.Restock(r.Context(), id, req)
+		if err != nil {
+			writeError(w, err, "Failed to restock item")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/service"
+)
+
+func CreateCustomer(customers *service.Customers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var customer db.Customer
+		if err := decodeJSON(w, r, &customer); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		id, err := customers.Create(r.Context(), customer)
+		if err != nil {
+			writeError(w, err, "Failed to create customer")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+func GetCustomerByID(customers *service.Customers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid customer ID", http.StatusBadRequest)
+			return
+		}
+
+		customer, err := customers.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to fetch customer")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(customer)
+	}
+}
+
+func GetCustomerLoyalty(customers *service.Customers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		customerID, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid customer ID", http.StatusBadRequest)
+			return
+		}
+
+		account, err := customers.Loyalty(r.Context(), customerID)
+		if err != nil {
+			writeError(w, err, "Failed to fetch loyalty account")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(account)
+	}
+}
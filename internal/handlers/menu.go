@@ -1,66 +1,38 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"frappuccino/internal/db"
-
-	"github.com/lib/pq"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/service"
 )
 
-func CreateMenuItem(dbc *sql.DB) http.HandlerFunc {
+func CreateMenuItem(menu *service.Menu) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
 		if r.Header.Get("Content-Type") != "application/json" {
-			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
 			return
 		}
 
 		var item db.MenuItem
-		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
-			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		if err := decodeJSON(w, r, &item); err != nil {
+			invalidBody(w, err)
 			return
 		}
 		defer r.Body.Close()
 
-		// Validate required fields
-		if item.Name == "" {
-			http.Error(w, "name is required", http.StatusBadRequest)
-			return
-		}
-		if item.Price <= 0 {
-			http.Error(w, "price must be greater than 0", http.StatusBadRequest)
-			return
-		}
-		if item.Category == "" {
-			http.Error(w, "category is required", http.StatusBadRequest)
-			return
-		}
-
-		query := `
-			INSERT INTO menu_items (id,name, description, price, allergens, category, size)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-			RETURNING id
-		`
-		var id string
-		err := dbc.QueryRowContext(r.Context(), query,
-			item.ID,
-			item.Name,
-			item.Description,
-			item.Price,
-			pq.Array(item.Allergens),
-			item.Category,
-			item.Size,
-		).Scan(&id)
+		id, err := menu.Create(r.Context(), item)
 		if err != nil {
-			http.Error(w, "Failed to create menu item: "+err.Error(), http.StatusInternalServerError)
+			writeError(w, err, "Failed to create menu item")
 			return
 		}
 
@@ -70,228 +42,183 @@ func CreateMenuItem(dbc *sql.DB) http.HandlerFunc {
 	}
 }
 
-func GetMenuItems(dbc *sql.DB) http.HandlerFunc {
+// GetMenuItems returns the menu as products with their size variants, in
+// category display order. ?category=<id> limits it to that category and its
+// subcategories, and ?exclude_allergens=milk,gluten leaves out every item
+// containing one of the listed allergens.
+func GetMenuItems(menu *service.Menu) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		query := "SELECT id, name, description, price, allergens, category, size FROM menu_items"
-		rows, err := dbc.QueryContext(r.Context(), query)
-		if err != nil {
-			http.Error(w, "Failed to fetch menu items", http.StatusInternalServerError)
-			return
-		}
-		defer rows.Close()
-
-		var items []db.MenuItem
-		for rows.Next() {
-			var item db.MenuItem
-			var allergens []string
-			if err := rows.Scan(
-				&item.ID,
-				&item.Name,
-				&item.Description,
-				&item.Price,
-				pq.Array(&allergens),
-				&item.Category,
-				&item.Size,
-			); err != nil {
-				http.Error(w, "Failed to scan menu item", http.StatusInternalServerError)
+		var filter repository.MenuFilter
+		if v := r.URL.Query().Get("category"); v != "" {
+			if _, err := fmt.Sscanf(v, "%d", &filter.CategoryID); err != nil || filter.CategoryID <= 0 {
+				httpError(w, "category must be a category ID", http.StatusBadRequest)
 				return
 			}
-			item.Allergens = allergens
-			items = append(items, item)
+		}
+		if v := r.URL.Query().Get("exclude_allergens"); v != "" {
+			filter.ExcludeAllergens = strings.Split(v, ",")
+		}
+
+		products, err := menu.List(r.Context(), filter)
+		if err != nil {
+			writeError(w, err, "Failed to fetch menu items")
+			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(items)
+		json.NewEncoder(w).Encode(products)
 	}
 }
 
-func GetMenuItemByID(dbc *sql.DB) http.HandlerFunc {
+func GetMenuItemByID(menu *service.Menu) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		path := r.URL.Path
-		var id string
-		_, err := fmt.Sscanf(path, "/menu/%s", &id)
-		if err != nil {
-			http.Error(w, "Invalid menu item ID", http.StatusBadRequest)
+		id := r.PathValue("id")
+		if id == "" {
+			httpError(w, "Invalid menu item ID", http.StatusBadRequest)
 			return
 		}
 
-		query := `
-			SELECT id, name, description, price, allergens, category, size 
-			FROM menu_items 
-			WHERE id = $1
-		`
-		var item db.MenuItem
-		var allergens []string
-		err = dbc.QueryRowContext(r.Context(), query, id).Scan(
-			&item.ID,
-			&item.Name,
-			&item.Description,
-			&item.Price,
-			pq.Array(&allergens),
-			&item.Category,
-			&item.Size,
-		)
-		if err == sql.ErrNoRows {
-			http.Error(w, "Menu item not found", http.StatusNotFound)
-			return
-		} else if err != nil {
-			http.Error(w, "Failed to fetch menu item", http.StatusInternalServerError)
+		item, err := menu.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to fetch menu item")
 			return
 		}
-		item.Allergens = allergens
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(item)
 	}
 }
 
-func UpdateMenuItem(dbc *sql.DB) http.HandlerFunc {
+func UpdateMenuItem(menu *service.Menu) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPut {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
 		if r.Header.Get("Content-Type") != "application/json" {
-			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
 			return
 		}
 
-		path := r.URL.Path
-		var id string
-		_, err := fmt.Sscanf(path, "/menu/%s", &id)
-		if err != nil {
-			http.Error(w, "Invalid menu item ID", http.StatusBadRequest)
+		id := r.PathValue("id")
+		if id == "" {
+			httpError(w, "Invalid menu item ID", http.StatusBadRequest)
 			return
 		}
 
 		var item db.MenuItem
-		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
-			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		if err := decodeJSON(w, r, &item); err != nil {
+			invalidBody(w, err)
 			return
 		}
 		defer r.Body.Close()
 
-		// Validate required fields
-		if item.Name == "" {
-			http.Error(w, "name is required", http.StatusBadRequest)
+		if err := menu.Update(r.Context(), id, item); err != nil {
+			writeError(w, err, "Failed to update menu item")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"id": id})
+	}
+}
+
+// PatchMenuItem updates the fields of a menu item given in a JSON Merge Patch
+// and returns the item. Bundle components are replaced as a whole, and a null
+// components list turns a bundle into a plain item.
+func PatchMenuItem(menu *service.Menu) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !isPatchContentType(r) {
+			httpError(w, "Content-Type must be "+mergePatchType, http.StatusUnsupportedMediaType)
+			return
+		}
+
+		id := r.PathValue("id")
+		if id == "" {
+			httpError(w, "Invalid menu item ID", http.StatusBadRequest)
 			return
 		}
-		if item.Price <= 0 {
-			http.Error(w, "price must be greater than 0", http.StatusBadRequest)
+
+		patch, err := decodePatch(w, r)
+		if err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		current, err := menu.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to get menu item")
 			return
 		}
-		if item.Category == "" {
-			http.Error(w, "category is required", http.StatusBadRequest)
+		var item db.MenuItem
+		if err := applyPatch(current, patch, &item); err != nil {
+			invalidBody(w, err)
 			return
 		}
+		// A category given by name replaces the current one, which the
+		// repository would otherwise resolve by id
+		if patchSets(patch, "category") && !patchSets(patch, "category_id") {
+			item.CategoryID = 0
+		}
+		// Components left out of the patch stay as they are
+		if !patchSets(patch, "components") {
+			item.Components = nil
+		} else if patchClears(patch, "components") {
+			item.Components = []db.BundleComponent{}
+		}
 
-		query := `
-			UPDATE menu_items 
-			SET name = $2, description = $3, price = $4, allergens = $5, category = $6, size = $7
-			WHERE id = $1
-			RETURNING id
-		`
-		err = dbc.QueryRowContext(r.Context(), query,
-			id,
-			item.Name,
-			item.Description,
-			item.Price,
-			pq.Array(item.Allergens),
-			item.Category,
-			item.Size,
-		).Scan(&id)
+		if err := menu.Update(r.Context(), id, item); err != nil {
+			writeError(w, err, "Failed to update menu item")
+			return
+		}
+		updated, err := menu.Get(r.Context(), id)
 		if err != nil {
-			http.Error(w, "Failed to update menu item: "+err.Error(), http.StatusInternalServerError)
+			writeError(w, err, "Failed to get menu item")
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{"id": id})
+		json.NewEncoder(w).Encode(updated)
 	}
 }
 
-func DeleteMenuItem(dbc *sql.DB) http.HandlerFunc {
+func DeleteMenuItem(menu *service.Menu) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodDelete {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		path := r.URL.Path
-		var id string
-		_, err := fmt.Sscanf(path, "/menu/%s", &id)
-		if err != nil {
-			http.Error(w, "Invalid menu item ID", http.StatusBadRequest)
+		id := r.PathValue("id")
+		if id == "" {
+			httpError(w, "Invalid menu item ID", http.StatusBadRequest)
 			return
 		}
 
-		query := "DELETE FROM menu_items WHERE id = $1"
-		result, err := dbc.ExecContext(r.Context(), query, id)
-		if err != nil {
-			http.Error(w, "Failed to delete menu item", http.StatusInternalServerError)
-			return
-		}
-
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			http.Error(w, "Menu item not found", http.StatusNotFound)
+		if err := menu.Delete(r.Context(), id); err != nil {
+			writeError(w, err, "Failed to delete menu item")
 			return
 		}
 
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
-
-// func ToggleMenuItemAvailability(dbc *sql.DB) http.HandlerFunc {
-// 	return func(w http.ResponseWriter, r *http.Request) {
-// 		if r.Method != http.MethodPost {
-// 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-// 			return
-// 		}
-
-// 		path := r.URL.Path
-// 		var id string
-// 		_, err := fmt.Sscanf(path, "/menu_items/toggle/%s", &id)
-// 		if err != nil {
-// 			http.Error(w, "Invalid menu item ID", http.StatusBadRequest)
-// 			return
-// 		}
-
-// 		query := `
-// 			UPDATE menu_items
-// 			SET is_available = NOT is_available
-// 			WHERE id = $1
-// 			RETURNING id, is_available
-// 		`
-// 		var (
-// 			updatedID     string
-// 			isAvailable bool
-// 		)
-// 		err = dbc.QueryRowContext(r.Context(), query, id).Scan(&updatedID, &isAvailable)
-// 		if err == sql.ErrNoRows {
-// 			http.Error(w, "Menu item not found", http.StatusNotFound)
-// 			return
-// 		} else if err != nil {
-// 			http.Error(w, "Failed to toggle availability", http.StatusInternalServerError)
-// 			return
-// 		}
-
-// 		w.Header().Set("Content-Type", "application/json")
-// 		json.NewEncoder(w).Encode(map[string]interface{}{
-// 			"id":           updatedID,
-// 			"is_available": isAvailable,
-// 		})
-// 	}
-// }
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+// validateModifierGroup returns the invalid fields of a modifier group.
+func validateModifierGroup(g db.ModifierGroup) error {
+	errs := validate.Struct(g)
+	if g.MinSelections > g.MaxSelections {
+		errs.Add("min_selections", "cannot be greater than max_selections")
+	} else if len(g.Modifiers) > 0 && g.MinSelections > len(g.Modifiers) {
+		errs.Add("min_selections", "cannot be greater than the number of modifiers")
+	}
+	for i, m := range g.Modifiers {
+		for j, ing := range m.Ingredients {
+			if ing.QuantityDelta == 0 {
+				errs.Add(fmt.Sprintf("modifiers[%d].ingredients[%d].quantity_delta", i, j), "cannot be 0")
+			}
+		}
+	}
+	return errs.Err()
+}
+
+func CreateModifierGroup(repo repository.ModifierRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		group := db.ModifierGroup{MaxSelections: 1}
+		if err := decodeJSON(w, r, &group); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		if err := validateModifierGroup(group); err != nil {
+			writeError(w, err, "Invalid modifier group")
+			return
+		}
+		for i := range group.Modifiers {
+			group.Modifiers[i].ID = 0
+		}
+
+		id, err := repo.Create(r.Context(), group)
+		if err != nil {
+			writeError(w, err, "Failed to create modifier group")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+func GetModifierGroups(repo repository.ModifierRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		groups, err := repo.List(r.Context(), r.URL.Query().Get("menu_item_id"))
+		if err != nil {
+			writeError(w, err, "Failed to fetch modifier groups")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(groups)
+	}
+}
+
+func UpdateModifierGroup(repo repository.ModifierRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid modifier group ID", http.StatusBadRequest)
+			return
+		}
+
+		var group db.ModifierGroup
+		if err := decodeJSON(w, r, &group); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		if err := validateModifierGroup(group); err != nil {
+			writeError(w, err, "Invalid modifier group")
+			return
+		}
+
+		if err := repo.Update(r.Context(), id, group); err != nil {
+			writeError(w, err, "Failed to update modifier group")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+func DeleteModifierGroup(repo repository.ModifierRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid modifier group ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.Delete(r.Context(), id); err != nil {
+			writeError(w, err, "Failed to delete modifier group")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"frappuccino/internal/service"
+)
+
+// ExportMenu returns the menu with calorie and allergen labels, grouped by
+// category. ?format=csv returns a spreadsheet instead of printable text.
+func ExportMenu(menu *service.Menu) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format != "" && format != "text" && format != "csv" {
+			httpError(w, "format must be text or csv", http.StatusBadRequest)
+			return
+		}
+
+		labels, err := menu.Labels(r.Context())
+		if err != nil {
+			writeError(w, err, "Failed to fetch menu items")
+			return
+		}
+
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", `attachment; filename="menu.csv"`)
+			cw := csv.NewWriter(w)
+			cw.Write([]string{"category", "id", "name", "size", "price", "calories", "protein", "carbohydrates", "fat", "up_to", "allergens"})
+			for _, l := range labels {
+				cw.Write([]string{
+					l.Category, l.ID, l.Name, l.Size,
+					strconv.FormatFloat(l.Price, 'f', 2, 64),
+					strconv.FormatFloat(l.Nutrition.Calories, 'f', -1, 64),
+					strconv.FormatFloat(l.Nutrition.Protein, 'f', -1, 64),
+					strconv.FormatFloat(l.Nutrition.Carbohydrates, 'f', -1, 64),
+					strconv.FormatFloat(l.Nutrition.Fat, 'f', -1, 64),
+					strconv.FormatBool(l.Nutrition.UpTo),
+					strings.Join(l.Allergens, " "),
+				})
+			}
+			cw.Flush()
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "MENU")
+		category := ""
+		for _, l := range labels {
+			if l.Category != category {
+				category = l.Category
+				fmt.Fprintf(tw, "\n%s\n", strings.ToUpper(category))
+			}
+			calories := fmt.Sprintf("%.0f kcal", l.Nutrition.Calories)
+			if l.Nutrition.UpTo {
+				calories = "up to " + calories
+			}
+			allergens := "-"
+			if len(l.Allergens) > 0 {
+				allergens = strings.Join(l.Allergens, ", ")
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%.2f\t%s\tP %.1fg  C %.1fg  F %.1fg\tAllergens: %s\n",
+				l.Name, l.Size, l.Price, calories,
+				l.Nutrition.Protein, l.Nutrition.Carbohydrates, l.Nutrition.Fat, allergens)
+		}
+		tw.Flush()
+	}
+}
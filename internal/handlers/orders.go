@@ -1,139 +1,131 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
-	"fmt"
-	"log"
 	"net/http"
+	"strconv"
 
 	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/service"
 )
 
-func UpdateOrderByID(dbc *sql.DB) http.HandlerFunc {
+func UpdateOrderByID(orders *service.Orders) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Check method first
 		if r.Method != http.MethodPut {
-			http.Error(w, "Invalid Method Request", http.StatusMethodNotAllowed)
+			httpError(w, "Invalid Method Request", http.StatusMethodNotAllowed)
 			return
 		}
 
 		// Check content type
 		contentType := r.Header.Get("Content-Type")
 		if contentType != "application/json" {
-			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
 			return
 		}
 
 		// Extract order ID from URL
-		path := r.URL.Path
-		var orderID string
-		_, err := fmt.Sscanf(path, "/orders/%s", &orderID)
-		if err != nil {
-			http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		orderID, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid order ID", http.StatusBadRequest)
 			return
 		}
 
 		// Parse request body
 		var order db.Order
-		if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
-			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		if err := decodeJSON(w, r, &order); err != nil {
+			invalidBody(w, err)
 			return
 		}
 		defer r.Body.Close()
 
-		// Validate required fields
-		if order.CustomerID == 0 {
-			http.Error(w, "customer_id is required", http.StatusBadRequest)
+		if err := orders.Update(r.Context(), orderID, order); err != nil {
+			writeError(w, err, "Failed to update order")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"order_id": strconv.Itoa(orderID)})
+	}
+}
+
+// PatchOrder updates the fields of an order given in a JSON Merge Patch and
+// returns the order. A null special_instructions removes them.
+func PatchOrder(orders *service.Orders) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			httpError(w, "Invalid Method Request", http.StatusMethodNotAllowed)
 			return
 		}
-		if order.TotalAmount <= 0 {
-			http.Error(w, "total_amount must be greater than 0", http.StatusBadRequest)
+
+		if !isPatchContentType(r) {
+			httpError(w, "Content-Type must be "+mergePatchType, http.StatusUnsupportedMediaType)
 			return
 		}
-		if order.PaymentMethod == "" {
-			http.Error(w, "payment_method is required", http.StatusBadRequest)
+
+		orderID, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid order ID", http.StatusBadRequest)
 			return
 		}
 
-		// Set default status if not provided
-		if order.Status == "" {
-			order.Status = "open"
+		patch, err := decodePatch(w, r)
+		if err != nil {
+			invalidBody(w, err)
+			return
 		}
+		defer r.Body.Close()
 
-		// Prepare special instructions
-		var specialInstructions []byte
-		if order.SpecialInstructions != nil {
-			specialInstructions, err = json.Marshal(order.SpecialInstructions)
-			if err != nil {
-				http.Error(w, "Invalid special_instructions format", http.StatusBadRequest)
-				return
-			}
+		current, err := orders.Get(r.Context(), orderID)
+		if err != nil {
+			writeError(w, err, "Failed to get order")
+			return
+		}
+		var order db.Order
+		if err := applyPatch(current, patch, &order); err != nil {
+			invalidBody(w, err)
+			return
 		}
 
-		// Execute update query
-		query := `UPDATE orders 
-                 SET customer_id = $2, total_amount = $3, status = $4, 
-                     special_instructions = $5, payment_method = $6, updated_at = NOW()
-                 WHERE id = $1
-                 RETURNING id`
-
-		err = dbc.QueryRowContext(r.Context(), query,
-			orderID,             // $1
-			order.CustomerID,    // $2
-			order.TotalAmount,   // $3
-			order.Status,        // $4
-			specialInstructions, // $5 (JSONB)
-			order.PaymentMethod, // $6
-		).Scan(&orderID)
+		if err := orders.Update(r.Context(), orderID, order); err != nil {
+			writeError(w, err, "Failed to update order")
+			return
+		}
+		updated, err := orders.Get(r.Context(), orderID)
 		if err != nil {
-			http.Error(w, "Failed to update order: "+err.Error(), http.StatusInternalServerError)
+			writeError(w, err, "Failed to get order")
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{"order_id": orderID})
+		json.NewEncoder(w).Encode(updated)
 	}
 }
 
-func DeleteOrder(dbc *sql.DB) http.HandlerFunc {
+func DeleteOrder(orders *service.Orders) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		path := r.URL.Path
-		var OrderID string
-		_, err := fmt.Sscanf(path, "/orders/%s", &OrderID)
-		if err != nil {
-			http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		orderID, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid order ID", http.StatusBadRequest)
 			return
 		}
 		if r.Method != http.MethodDelete {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 		// Verify content type
 		if r.Header.Get("Content-Type") != "application/json" {
-			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
 			return
 		}
 
 		defer r.Body.Close()
 
-		query := `DELETE FROM orders WHERE id = $1`
-		result, err := dbc.ExecContext(r.Context(), query, OrderID)
-		if err != nil {
-			http.Error(w, "Failed to delete order: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		// Check if any rows were affected
-		rowsAffected, err := result.RowsAffected()
-		if err != nil {
-			http.Error(w, "Failed to check deletion: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		if rowsAffected == 0 {
-			http.Error(w, "Order not found", http.StatusNotFound)
+		if err := orders.Delete(r.Context(), orderID); err != nil {
+			writeError(w, err, "Failed to delete order")
 			return
 		}
 
@@ -142,133 +134,72 @@ func DeleteOrder(dbc *sql.DB) http.HandlerFunc {
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]string{
 			"message":  "Order deleted successfully",
-			"order_id": OrderID,
+			"order_id": strconv.Itoa(orderID),
 		})
 	}
 }
 
-func CreateOrder(dbc *sql.DB) http.HandlerFunc {
+// CreateOrder places an order. Orders with items are priced from the menu
+// and go through promotions; the response then reports the amounts charged.
+func CreateOrder(orders *service.Orders) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 		// Verify content type
 		if r.Header.Get("Content-Type") != "application/json" {
-			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
 			return
 		}
 		var order db.Order
-		if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
-			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		if err := decodeJSON(w, r, &order); err != nil {
+			invalidBody(w, err)
 			return
 		}
 		defer r.Body.Close()
-		// Validate required fields
-		if order.CustomerID == 0 {
-			http.Error(w, "customer_id is required", http.StatusBadRequest)
-			return
-		}
-		if order.TotalAmount <= 0 {
-			http.Error(w, "total_amount must be greater than 0", http.StatusBadRequest)
-			return
-		}
-		if order.PaymentMethod == "" {
-			http.Error(w, "payment_method is required", http.StatusBadRequest)
-			return
-		}
-		// Set default status if not provided
-		if order.Status == "" {
-			order.Status = "open"
-		}
-		// Insert into database
-		query := `
-            INSERT INTO orders (customer_id, total_amount, status, special_instructions, payment_method)
-            VALUES ($1, $2, $3, $4, $5)
-            RETURNING id
-        `
-		var orderID int
-		err := dbc.QueryRowContext(r.Context(), query,
-			order.CustomerID,
-			order.TotalAmount,
-			order.Status,
-			order.SpecialInstructions, // This will be stored as JSONB in PostgreSQL
-			order.PaymentMethod,
-		).Scan(&orderID)
+
+		receipt, err := orders.Create(r.Context(), order)
 		if err != nil {
-			http.Error(w, "Failed to create order: "+err.Error(), http.StatusInternalServerError)
+			writeError(w, err, "Failed to create order")
 			return
 		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(map[string]int{"order_id": orderID})
+		if len(order.Items) == 0 {
+			json.NewEncoder(w).Encode(map[string]int{"order_id": receipt.OrderID})
+			return
+		}
+		json.NewEncoder(w).Encode(receipt)
 	}
 }
 
-func GetOrders(dbс *sql.DB) http.HandlerFunc {
+func GetOrders(orders *service.Orders) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		query := "SELECT id, customer_id, total_amount, status, payment_method, created_at, updated_at FROM orders"
-		rows, err := dbс.Query(query)
+		list, err := orders.List(r.Context())
 		if err != nil {
-			http.Error(w, "Failed to fetch orders", http.StatusInternalServerError)
+			writeError(w, err, "Failed to fetch orders")
 			return
 		}
-		defer rows.Close()
-
-		var orders []db.Order
-		for rows.Next() {
-			var order db.Order
-			if err := rows.Scan(
-				&order.ID,
-				&order.CustomerID,
-				&order.TotalAmount,
-				&order.Status,
-				&order.PaymentMethod,
-				&order.CreatedAt,
-				&order.UpdatedAt,
-			); err != nil {
-				http.Error(w, "Failed to scan order", http.StatusInternalServerError)
-				return
-			}
-			orders = append(orders, order)
-		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(orders)
+		json.NewEncoder(w).Encode(list)
 	}
 }
 
-func GetOrderByID(dbс *sql.DB) http.HandlerFunc {
+func GetOrderByID(orders *service.Orders) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract the "id" parameter from the URL path
-		path := r.URL.Path
-		var orderID string
-		_, err := fmt.Sscanf(path, "/orders/%s", &orderID)
-		if err != nil {
-			http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		orderID, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid order ID", http.StatusBadRequest)
 			return
 		}
 
-		query := `
-            SELECT id, customer_id, total_amount, status, payment_method, created_at, updated_at 
-            FROM orders 
-            WHERE id = $1
-        `
-		var order db.Order
-		err = dbс.QueryRow(query, orderID).Scan(
-			&order.ID,
-			&order.CustomerID,
-			&order.TotalAmount,
-			&order.Status,
-			&order.PaymentMethod,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-		)
-		if err == sql.ErrNoRows {
-			http.Error(w, "Order not found", http.StatusNotFound)
-			return
-		} else if err != nil {
-			http.Error(w, "Failed to fetch order", http.StatusInternalServerError)
+		order, err := orders.Get(r.Context(), orderID)
+		if err != nil {
+			writeError(w, err, "Failed to fetch order")
 			return
 		}
 
@@ -277,33 +208,17 @@ func GetOrderByID(dbс *sql.DB) http.HandlerFunc {
 	}
 }
 
-func CloseOrder(dbс *sql.DB) http.HandlerFunc {
+func CloseOrder(orders *service.Orders) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract the "id" parameter from the URL path
-		path := r.URL.Path
-		var orderID string
-		_, err := fmt.Sscanf(path, "/orders/close/%s", &orderID)
-		if err != nil {
-			http.Error(w, "Invalid order ID", http.StatusBadRequest)
-			log.Println(err)
+		orderID, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid order ID", http.StatusBadRequest)
 			return
 		}
 
-		// Обновляем статус заказа на "closed"
-		query := `
-            UPDATE orders 
-            SET status = 'closed', updated_at = NOW() 
-            WHERE id = $1 AND status = 'open'
-        `
-		result, err := dbс.Exec(query, orderID)
-		if err != nil {
-			http.Error(w, "Failed to close order", http.StatusInternalServerError)
-			return
-		}
-
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			http.Error(w, "Order not found or already closed", http.StatusNotFound)
+		if err := orders.Close(r.Context(), orderID); err != nil {
+			writeError(w, err, "Failed to close order")
 			return
 		}
 
@@ -312,57 +227,16 @@ func CloseOrder(dbс *sql.DB) http.HandlerFunc {
 	}
 }
 
-func GetNumberOfOrderedItems(db *sql.DB) http.HandlerFunc {
+func GetNumberOfOrderedItems(reports repository.ReportRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		startDate := r.URL.Query().Get("startDate")
 		endDate := r.URL.Query().Get("endDate")
 
-		query := `
-            SELECT 
-                order_items.menu_item_id, 
-                SUM(order_items.quantity) AS total_quantity,  
-                orders.created_at
-            FROM 
-                order_items
-            JOIN 
-                orders ON order_items.order_id = orders.id
-            WHERE 
-                orders.created_at BETWEEN $1 AND $2
-            GROUP BY 
-                order_items.menu_item_id, orders.created_at;
-
-        `
-		rows, err := db.Query(query, startDate, endDate)
+		items, err := reports.OrderedItemCounts(r.Context(), startDate, endDate)
 		if err != nil {
-			http.Error(w, "Failed to fetch ordered items", http.StatusInternalServerError)
-			log.Println(err)
+			writeError(w, err, "Failed to fetch ordered items")
 			return
 		}
-		defer rows.Close()
-
-		type OrderItem struct {
-			ID        string `json:"menuItemID"`
-			Total     int    `json:"totalQuantity"`
-			CreatedAt string `json:"createdAt"`
-		}
-		var items []OrderItem
-		for rows.Next() {
-			var menuItemID string
-			var totalQuantity int
-			var createdAt string
-
-			if err := rows.Scan(&menuItemID, &totalQuantity, &createdAt); err != nil {
-				http.Error(w, "Failed to scan ordered items", http.StatusInternalServerError)
-				log.Println(err)
-				return
-			}
-			item := OrderItem{
-				ID:        menuItemID,
-				Total:     totalQuantity,
-				CreatedAt: createdAt,
-			}
-			items = append(items, item)
-		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(items)
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// mergePatchType is the media type of a JSON Merge Patch (RFC 7386).
+const mergePatchType = "application/merge-patch+json"
+
+// errPatchNotObject is a merge patch that is not a JSON object. Such a patch
+// would replace the whole resource, which PUT is for.
+var errPatchNotObject = errors.New("the merge patch is not a JSON object")
+
+// isPatchContentType reports whether a PATCH request carries a merge patch.
+func isPatchContentType(r *http.Request) bool {
+	return r.Header.Get("Content-Type") == mergePatchType
+}
+
+// decodePatch decodes the merge patch in the request body.
+func decodePatch(w http.ResponseWriter, r *http.Request) (map[string]interface{}, error) {
+	var patch interface{}
+	if err := decodeJSON(w, r, &patch); err != nil {
+		return nil, err
+	}
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return nil, errPatchNotObject
+	}
+	return members, nil
+}
+
+// applyPatch merges patch into the JSON of current and decodes the result
+// into v. Fields v does not have are refused like in any request body.
+func applyPatch(current interface{}, patch map[string]interface{}, v interface{}) error {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	var merged interface{}
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber() // keep numbers as they were
+	if err := dec.Decode(&merged); err != nil {
+		return err
+	}
+	if doc, err = json.Marshal(mergePatch(merged, patch)); err != nil {
+		return err
+	}
+	dec = json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// mergePatch applies patch to doc as RFC 7386 describes: the members of an
+// object patch replace those of doc, null members remove them and object
+// members are merged in turn. Any other patch replaces doc.
+func mergePatch(doc, patch interface{}) interface{} {
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	target, ok := doc.(map[string]interface{})
+	if !ok {
+		target = map[string]interface{}{}
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(target, name)
+			continue
+		}
+		target[name] = mergePatch(target[name], value)
+	}
+	return target
+}
+
+// patchSets reports whether patch has a member name, and patchClears whether
+// that member is null.
+func patchSets(patch map[string]interface{}, name string) bool {
+	_, ok := patch[name]
+	return ok
+}
+
+func patchClears(patch map[string]interface{}, name string) bool {
+	value, ok := patch[name]
+	return ok && value == nil
+}
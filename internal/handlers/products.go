@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+// validateMenuProduct returns the invalid fields of a menu product.
+func validateMenuProduct(p db.MenuProduct) error {
+	errs := validate.Struct(p)
+	if p.CategoryID == 0 && p.Category == "" {
+		errs.Add("category_id", "is required")
+	}
+	seen := make(map[string]bool)
+	for i, ing := range p.Ingredients {
+		if ing.IngredientID != "" && seen[ing.IngredientID] {
+			errs.Add(fmt.Sprintf("ingredients[%d].ingredient_id", i), "lists %s twice", ing.IngredientID)
+		}
+		seen[ing.IngredientID] = true
+	}
+	return errs.Err()
+}
+
+func CreateMenuProduct(repo repository.ProductRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var product db.MenuProduct
+		if err := decodeJSON(w, r, &product); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		if err := validateMenuProduct(product); err != nil {
+			writeError(w, err, "Invalid menu product")
+			return
+		}
+
+		id, err := repo.Create(r.Context(), product)
+		if err != nil {
+			writeError(w, err, "Failed to create menu product")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+func GetMenuProductByID(repo repository.ProductRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid menu product ID", http.StatusBadRequest)
+			return
+		}
+
+		product, err := repo.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to fetch menu product")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(product)
+	}
+}
+
+func UpdateMenuProduct(repo repository.ProductRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid menu product ID", http.StatusBadRequest)
+			return
+		}
+
+		var product db.MenuProduct
+		if err := decodeJSON(w, r, &product); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		if err := validateMenuProduct(product); err != nil {
+			writeError(w, err, "Invalid menu product")
+			return
+		}
+
+		if err := repo.Update(r.Context(), id, product); err != nil {
+			writeError(w, err, "Failed to update menu product")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+func DeleteMenuProduct(repo repository.ProductRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid menu product ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.Delete(r.Context(), id); err != nil {
+			writeError(w, err, "Failed to delete menu product")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
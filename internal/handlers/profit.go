@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// reportPeriods are the supported report periods.
+var reportPeriods = map[string]bool{"day": true, "month": true}
+
+// periodTotals adds rounding and summing to the trading figures.
+type periodTotals db.PeriodTotals
+
+func (t *periodTotals) add(o periodTotals) {
+	t.Orders += o.Orders
+	t.UnitsSold += o.UnitsSold
+	t.Sales += o.Sales
+	t.Discounts += o.Discounts
+	t.Revenue += o.Revenue
+	t.COGS += o.COGS
+	t.WriteOffs += o.WriteOffs
+}
+
+func (t *periodTotals) finish() {
+	t.Sales = roundMoney(t.Sales)
+	t.Discounts = roundMoney(t.Discounts)
+	t.Revenue = roundMoney(t.Revenue)
+	t.COGS = roundMoney(t.COGS)
+	t.WriteOffs = roundMoney(t.WriteOffs)
+	t.GrossProfit = roundMoney(t.Revenue - t.COGS - t.WriteOffs)
+	t.GrossMarginPercent = marginPercent(t.Revenue, t.COGS+t.WriteOffs)
+}
+
+// profitReport serves the COGS and P&L reports, which differ only in the
+// fields they return.
+func profitReport(reports repository.ReportRepository, render func(periods []periodTotals, total periodTotals) interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		period := r.URL.Query().Get("period")
+		if period == "" {
+			period = "day"
+		}
+		if !reportPeriods[period] {
+			httpError(w, "period must be day or month", http.StatusBadRequest)
+			return
+		}
+
+		rows, err := reports.TradingTotals(r.Context(), period,
+			r.URL.Query().Get("startDate"), r.URL.Query().Get("endDate"))
+		if err != nil {
+			writeError(w, err, "Failed to compute report")
+			return
+		}
+
+		totals := make([]periodTotals, 0, len(rows))
+		total := periodTotals{Period: "total"}
+		for _, row := range rows {
+			p := periodTotals(row)
+			total.add(p)
+			p.finish()
+			totals = append(totals, p)
+		}
+		total.finish()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(render(totals, total))
+	}
+}
+
+// COGSReport returns the cost of goods sold per day or month (?period=day|month)
+func COGSReport(reports repository.ReportRepository) http.HandlerFunc {
+	type cogsRow struct {
+		Period    string  `json:"period"`
+		Orders    int     `json:"orders"`
+		UnitsSold float64 `json:"units_sold"`
+		COGS      float64 `json:"cogs"`
+	}
+	return profitReport(reports, func(periods []periodTotals, total periodTotals) interface{} {
+		rows := make([]cogsRow, 0, len(periods))
+		for _, p := range periods {
+			rows = append(rows, cogsRow{p.Period, p.Orders, p.UnitsSold, p.COGS})
+		}
+		return map[string]interface{}{
+			"periods": rows,
+			"total":   cogsRow{total.Period, total.Orders, total.UnitsSold, total.COGS},
+		}
+	})
+}
+
+// ProfitAndLoss returns revenue, COGS, write-offs and gross profit per day or month
+func ProfitAndLoss(reports repository.ReportRepository) http.HandlerFunc {
+	return profitReport(reports, func(periods []periodTotals, total periodTotals) interface{} {
+		return map[string]interface{}{
+			"periods": periods,
+			"total":   total,
+		}
+	})
+}
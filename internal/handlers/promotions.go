@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+// validatePromotion returns the invalid fields of a promotion.
+func validatePromotion(p db.Promotion) error {
+	errs := validate.Struct(p)
+	switch p.DiscountType {
+	case "percentage":
+		if p.Value <= 0 || p.Value > 100 {
+			errs.Add("value", "must be between 0 and 100 for percentage discounts")
+		}
+	case "fixed":
+		if p.Value <= 0 {
+			errs.Add("value", "must be greater than 0 for fixed discounts")
+		}
+	case "buy_x_get_y":
+		if p.BuyQuantity <= 0 {
+			errs.Add("buy_quantity", "must be greater than 0")
+		}
+		if p.GetQuantity <= 0 {
+			errs.Add("get_quantity", "must be greater than 0")
+		}
+	}
+	if (p.StartTime == "") != (p.EndTime == "") {
+		errs.Add("start_time", "must be set together with end_time")
+	} else if p.StartTime != "" {
+		if !validClock(p.StartTime) {
+			errs.Add("start_time", "must be in HH:MM format")
+		}
+		if !validClock(p.EndTime) {
+			errs.Add("end_time", "must be in HH:MM format")
+		}
+	}
+	if p.ValidFrom != nil && p.ExpiresAt != nil && !p.ExpiresAt.After(*p.ValidFrom) {
+		errs.Add("expires_at", "must be after valid_from")
+	}
+	return errs.Err()
+}
+
+// validClock reports whether clock is a time of day as "15:04" or "15:04:05".
+func validClock(clock string) bool {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if _, err := time.Parse(layout, clock); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func CreatePromotion(repo repository.PromotionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		promo := db.Promotion{IsActive: true}
+		if err := decodeJSON(w, r, &promo); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		if err := validatePromotion(promo); err != nil {
+			writeError(w, err, "Invalid promotion")
+			return
+		}
+
+		id, err := repo.Create(r.Context(), promo)
+		if err != nil {
+			writeError(w, err, "Failed to create promotion")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+func GetPromotions(repo repository.PromotionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		promos, err := repo.List(r.Context())
+		if err != nil {
+			writeError(w, err, "Failed to fetch promotions")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(promos)
+	}
+}
+
+func GetPromotionByID(repo repository.PromotionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid promotion ID", http.StatusBadRequest)
+			return
+		}
+
+		promo, err := repo.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to fetch promotion")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(promo)
+	}
+}
+
+func UpdatePromotion(repo repository.PromotionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid promotion ID", http.StatusBadRequest)
+			return
+		}
+
+		promo := db.Promotion{IsActive: true}
+		if err := decodeJSON(w, r, &promo); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		if err := validatePromotion(promo); err != nil {
+			writeError(w, err, "Invalid promotion")
+			return
+		}
+
+		if err := repo.Update(r.Context(), id, promo); err != nil {
+			writeError(w, err, "Failed to update promotion")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+func DeletePromotion(repo repository.PromotionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid promotion ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.Delete(r.Context(), id); err != nil {
+			writeError(w, err, "Failed to delete promotion")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
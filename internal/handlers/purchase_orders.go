@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+// purchaseOrderStatuses lists the valid values of purchase_orders.status.
+var purchaseOrderStatuses = map[string]bool{
+	"draft": true, "sent": true, "partially_received": true, "received": true,
+}
+
+// validatePurchaseOrder returns the invalid fields of a purchase order.
+func validatePurchaseOrder(po db.PurchaseOrder) error {
+	errs := validate.Struct(po)
+	seen := make(map[string]bool)
+	for i, item := range po.Items {
+		if item.InventoryID != "" && seen[item.InventoryID] {
+			errs.Add(fmt.Sprintf("items[%d].inventory_id", i), "lists %s twice", item.InventoryID)
+		}
+		seen[item.InventoryID] = true
+	}
+	return errs.Err()
+}
+
+func decodePurchaseOrder(w http.ResponseWriter, r *http.Request) (db.PurchaseOrder, bool) {
+	var po db.PurchaseOrder
+	if r.Header.Get("Content-Type") != "application/json" {
+		httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return po, false
+	}
+	if err := decodeJSON(w, r, &po); err != nil {
+		invalidBody(w, err)
+		return po, false
+	}
+	defer r.Body.Close()
+
+	if err := validatePurchaseOrder(po); err != nil {
+		writeError(w, err, "Invalid purchase order")
+		return po, false
+	}
+	return po, true
+}
+
+func CreatePurchaseOrder(repo repository.PurchaseOrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		po, ok := decodePurchaseOrder(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := repo.Create(r.Context(), po)
+		if err != nil {
+			writeError(w, err, "Failed to save purchase order")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+// GetPurchaseOrders lists purchase orders, newest first, optionally filtered
+// by ?status= and ?supplier_id=.
+func GetPurchaseOrders(repo repository.PurchaseOrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		status := r.URL.Query().Get("status")
+		if status != "" && !purchaseOrderStatuses[status] {
+			httpError(w, "status must be one of: draft, sent, partially_received, received", http.StatusBadRequest)
+			return
+		}
+		var supplierID int
+		if v := r.URL.Query().Get("supplier_id"); v != "" {
+			if _, err := fmt.Sscanf(v, "%d", &supplierID); err != nil || supplierID <= 0 {
+				httpError(w, "Invalid supplier_id", http.StatusBadRequest)
+				return
+			}
+		}
+
+		orders, err := repo.List(r.Context(), status, supplierID)
+		if err != nil {
+			writeError(w, err, "Failed to fetch purchase orders")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(orders)
+	}
+}
+
+func GetPurchaseOrderByID(repo repository.PurchaseOrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid purchase order ID", http.StatusBadRequest)
+			return
+		}
+
+		po, err := repo.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to fetch purchase order")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(po)
+	}
+}
+
+// UpdatePurchaseOrder replaces the supplier, notes and items of a draft.
+func UpdatePurchaseOrder(repo repository.PurchaseOrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid purchase order ID", http.StatusBadRequest)
+			return
+		}
+
+		po, ok := decodePurchaseOrder(w, r)
+		if !ok {
+			return
+		}
+
+		if err := repo.Update(r.Context(), id, po); err != nil {
+			writeError(w, err, "Failed to save purchase order")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+// DeletePurchaseOrder deletes a draft purchase order.
+func DeletePurchaseOrder(repo repository.PurchaseOrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid purchase order ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.Delete(r.Context(), id); err != nil {
+			writeError(w, err, "Failed to delete purchase order")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// SendPurchaseOrder marks a draft as sent to the supplier.
+func SendPurchaseOrder(repo repository.PurchaseOrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid purchase order ID", http.StatusBadRequest)
+			return
+		}
+
+		status, err := repo.Send(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to send purchase order")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "status": status})
+	}
+}
+
+// ReceivePurchaseOrder posts a delivery: stock and cost of the items are
+// updated and 'added' inventory transactions are recorded. The body may list
+// the items delivered ({"items": [{"inventory_id", "quantity", "unit",
+// "unit_cost", "expires_on"}]}); without it the whole outstanding order is
+// received.
+func ReceivePurchaseOrder(repo repository.PurchaseOrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid purchase order ID", http.StatusBadRequest)
+			return
+		}
+
+		var req struct {
+			Items []db.PurchaseOrderItem `json:"items"`
+		}
+		if err := decodeJSON(w, r, &req); err != nil && err != io.EOF {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		po, err := repo.Receive(r.Context(), id, req.Items)
+		if err != nil {
+			writeError(w, err, "Failed to receive purchase order")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(po)
+	}
+}
+
+// SuggestedPurchaseOrders proposes what to order from each supplier: items
+// whose stock plus what is already on order (drafts included) is at or below
+// their reorder level are topped up to their par level, rounded up to whole
+// purchase units. Items can be posted to /purchase-orders as they are.
+func SuggestedPurchaseOrders(repo repository.PurchaseOrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		orders, err := repo.Suggested(r.Context())
+		if err != nil {
+			writeError(w, err, "Failed to fetch inventory")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(orders)
+	}
+}
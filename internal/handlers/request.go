@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// maxBodySize is the largest request body accepted, in bytes.
+const maxBodySize = 1 << 20
+
+// errTrailingData is a body holding more than one JSON value.
+var errTrailingData = errors.New("trailing data after the JSON value")
+
+// decodeJSON decodes the request body into v. Fields v does not have, data
+// after the value and bodies over maxBodySize are refused. An empty body is
+// io.EOF, which callers with an optional body ignore.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errTrailingData
+	}
+	return nil
+}
+
+// pathID returns the {id} wildcard of the request path. It reports false
+// when the id is not a positive integer.
+func pathID(r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	return id, err == nil && id > 0
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+// OpenStockTake starts a count. Only one stock take can be open at a time.
+func OpenStockTake(repo repository.StockTakeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			Notes string `json:"notes"`
+		}
+		if err := decodeJSON(w, r, &req); err != nil && err != io.EOF {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		id, err := repo.Open(r.Context(), req.Notes)
+		if err != nil {
+			writeError(w, err, "Failed to open stock take")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+// GetStockTakes lists stock takes, newest first, without their lines.
+func GetStockTakes(repo repository.StockTakeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		stockTakes, err := repo.List(r.Context())
+		if err != nil {
+			writeError(w, err, "Failed to fetch stock takes")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stockTakes)
+	}
+}
+
+// GetStockTakeByID returns a stock take with its variances: a preview while
+// it is open and the variance report once committed.
+func GetStockTakeByID(repo repository.StockTakeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid stock take ID", http.StatusBadRequest)
+			return
+		}
+
+		st, err := repo.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to fetch stock take")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(st)
+	}
+}
+
+// SubmitStockCounts records counted quantities on an open stock take
+// ({"counts": [{"inventory_id", "quantity", "unit"}]}). Counting an item
+// again replaces its earlier count.
+func SubmitStockCounts(repo repository.StockTakeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid stock take ID", http.StatusBadRequest)
+			return
+		}
+
+		var req struct {
+			Counts []db.StockCount `json:"counts" validate:"required"`
+		}
+		if err := decodeJSON(w, r, &req); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		if err := validate.Struct(req).Err(); err != nil {
+			writeError(w, err, "Invalid stock counts")
+			return
+		}
+
+		st, err := repo.SubmitCounts(r.Context(), id, req.Counts)
+		if err != nil {
+			writeError(w, err, "Failed to save counts")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(st)
+	}
+}
+
+// CommitStockTake applies the counts of an open stock take to inventory and
+// returns the variance report.
+func CommitStockTake(repo repository.StockTakeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid stock take ID", http.StatusBadRequest)
+			return
+		}
+
+		st, err := repo.Commit(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to commit stock take")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(st)
+	}
+}
+
+// DeleteStockTake discards an open stock take and its counts.
+func DeleteStockTake(repo repository.StockTakeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid stock take ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.Delete(r.Context(), id); err != nil {
+			writeError(w, err, "Failed to delete stock take")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+// decodeSupplier reads and validates a supplier from the request body. It
+// writes the error response and returns false when the body is invalid.
+func decodeSupplier(w http.ResponseWriter, r *http.Request) (db.Supplier, bool) {
+	var s db.Supplier
+	if r.Header.Get("Content-Type") != "application/json" {
+		httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return s, false
+	}
+	if err := decodeJSON(w, r, &s); err != nil {
+		invalidBody(w, err)
+		return s, false
+	}
+	defer r.Body.Close()
+
+	s.Name = strings.TrimSpace(s.Name)
+	if err := validate.Struct(s).Err(); err != nil {
+		writeError(w, err, "Invalid supplier")
+		return s, false
+	}
+	return s, true
+}
+
+func CreateSupplier(repo repository.SupplierRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		s, ok := decodeSupplier(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := repo.Create(r.Context(), s)
+		if err != nil {
+			writeError(w, err, "Failed to create supplier")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+func GetSuppliers(repo repository.SupplierRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		suppliers, err := repo.List(r.Context())
+		if err != nil {
+			writeError(w, err, "Failed to fetch suppliers")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(suppliers)
+	}
+}
+
+func GetSupplierByID(repo repository.SupplierRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid supplier ID", http.StatusBadRequest)
+			return
+		}
+
+		s, err := repo.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, err, "Failed to fetch supplier")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s)
+	}
+}
+
+func UpdateSupplier(repo repository.SupplierRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid supplier ID", http.StatusBadRequest)
+			return
+		}
+
+		s, ok := decodeSupplier(w, r)
+		if !ok {
+			return
+		}
+
+		if err := repo.Update(r.Context(), id, s); err != nil {
+			writeError(w, err, "Failed to update supplier")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}
+}
+
+func DeleteSupplier(repo repository.SupplierRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "Invalid supplier ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.Delete(r.Context(), id); err != nil {
+			writeError(w, err, "Failed to delete supplier")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
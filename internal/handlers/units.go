@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+func GetUnits(repo repository.UnitRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		units, err := repo.List(r.Context())
+		if err != nil {
+			writeError(w, err, "Failed to fetch units")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(units)
+	}
+}
+
+func CreateUnit(repo repository.UnitRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			httpError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var unit db.Unit
+		if err := decodeJSON(w, r, &unit); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		defer r.Body.Close()
+
+		unit.Code = strings.ToLower(strings.TrimSpace(unit.Code))
+		unit.Dimension = strings.ToLower(strings.TrimSpace(unit.Dimension))
+		errs := validate.Struct(unit)
+		if strings.ContainsAny(unit.Code, ", ") {
+			errs.Add("code", "cannot contain commas or spaces")
+		}
+		if err := errs.Err(); err != nil {
+			writeError(w, err, "Invalid unit")
+			return
+		}
+
+		if err := repo.Create(r.Context(), unit); err != nil {
+			writeError(w, err, "Failed to create unit")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(unit)
+	}
+}
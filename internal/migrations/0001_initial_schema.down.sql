@@ -0,0 +1,46 @@
+DROP VIEW IF EXISTS order_item_sales, menu_item_nutrition, menu_item_allergens, menu_item_recipes, category_tree;
+
+DROP TABLE IF EXISTS
+    loyalty_transactions,
+    order_item_modifiers,
+    menu_item_modifier_groups,
+    modifier_ingredients,
+    modifiers,
+    modifier_groups,
+    order_item_components,
+    order_promotions,
+    promotions,
+    inventory_transactions,
+    price_history,
+    order_status_history,
+    bundle_components,
+    product_ingredients,
+    menu_item_ingredients,
+    order_items,
+    menu_items,
+    menu_products,
+    categories,
+    orders,
+    customers,
+    stock_take_counts,
+    stock_takes,
+    inventory_batches,
+    purchase_order_items,
+    purchase_orders,
+    inventory_allergens,
+    allergens,
+    inventory,
+    suppliers,
+    units;
+
+DROP FUNCTION IF EXISTS stock_quantity(TEXT, NUMERIC, TEXT);
+
+DROP TYPE IF EXISTS
+    stock_take_status,
+    purchase_order_status,
+    loyalty_transaction_type,
+    discount_type,
+    transaction_type,
+    item_size,
+    payment_method,
+    order_status;
@@ -0,0 +1,479 @@
+-- Initial schema with the allergen and unit registries.
+
+CREATE TYPE order_status AS ENUM ('open', 'closed');
+CREATE TYPE payment_method AS ENUM ('cash', 'card', 'kaspi_qr');
+CREATE TYPE item_size AS ENUM ('small', 'medium', 'large');
+CREATE TYPE transaction_type AS ENUM ('added', 'written off', 'sale', 'created', 'adjustment');
+CREATE TYPE discount_type AS ENUM ('percentage', 'fixed', 'buy_x_get_y');
+CREATE TYPE loyalty_transaction_type AS ENUM ('earned', 'redeemed', 'reward', 'adjusted');
+CREATE TYPE purchase_order_status AS ENUM ('draft', 'sent', 'partially_received', 'received');
+CREATE TYPE stock_take_status AS ENUM ('open', 'committed');
+
+-- Units of measure. Quantities convert between units of the same dimension;
+-- factor is the size of the unit in the base unit of its dimension (g, ml, piece)
+CREATE TABLE IF NOT EXISTS units (
+    code TEXT PRIMARY KEY CHECK (code = LOWER(code)),
+    name TEXT NOT NULL,
+    dimension TEXT NOT NULL,
+    factor NUMERIC(14, 6) NOT NULL CHECK (factor > 0)
+);
+
+CREATE TABLE IF NOT EXISTS suppliers (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    contact_name TEXT,
+    email TEXT,
+    phone TEXT,
+    lead_time_days INT NOT NULL DEFAULT 0 CHECK (lead_time_days >= 0),
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX unique_supplier_name ON suppliers (LOWER(name));
+
+CREATE TABLE IF NOT EXISTS inventory (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    stock NUMERIC(10, 2) NOT NULL,
+    price NUMERIC(10, 2) NOT NULL CHECK (price >= 0),
+    -- stock unit: stock, price and nutrition are per unit_type
+    unit_type TEXT NOT NULL REFERENCES units(code),
+    -- unit the item is bought in, e.g. a case holding 12 l of milk
+    purchase_unit TEXT,
+    purchase_quantity NUMERIC(10, 4) CHECK (purchase_quantity > 0),
+    purchase_quantity_unit TEXT REFERENCES units(code),
+    CHECK ((purchase_unit IS NULL) = (purchase_quantity IS NULL)
+        AND (purchase_unit IS NULL) = (purchase_quantity_unit IS NULL)),
+    -- preferred supplier; stock at or below reorder_level is topped up to par_level
+    supplier_id INT REFERENCES suppliers(id) ON DELETE SET NULL,
+    reorder_level NUMERIC(10, 2) CHECK (reorder_level >= 0),
+    par_level NUMERIC(10, 2) CHECK (par_level > 0 AND par_level >= reorder_level),
+    CHECK ((reorder_level IS NULL) = (par_level IS NULL)),
+    -- batch-tracked items keep their stock in inventory_batches; receipts
+    -- expire after shelf_life_days unless a date is given
+    track_batches BOOLEAN NOT NULL DEFAULT FALSE,
+    shelf_life_days INT CHECK (shelf_life_days > 0),
+    -- nutrition per unit_type
+    calories NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (calories >= 0),
+    protein NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (protein >= 0),
+    carbohydrates NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (carbohydrates >= 0),
+    fat NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (fat >= 0),
+    last_updated TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Controlled vocabulary of allergens that ingredients can be tagged with
+CREATE TABLE IF NOT EXISTS allergens (
+    code TEXT PRIMARY KEY CHECK (code = LOWER(code)),
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS inventory_allergens (
+    inventory_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    allergen TEXT NOT NULL REFERENCES allergens(code) ON DELETE RESTRICT,
+    PRIMARY KEY (inventory_id, allergen)
+);
+
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id SERIAL PRIMARY KEY,
+    supplier_id INT NOT NULL REFERENCES suppliers(id) ON DELETE RESTRICT,
+    status purchase_order_status NOT NULL DEFAULT 'draft',
+    notes TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    sent_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ
+);
+
+-- Quantities are in the stock unit of the item and unit_cost is per stock unit
+CREATE TABLE IF NOT EXISTS purchase_order_items (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    inventory_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE RESTRICT,
+    quantity NUMERIC(10, 4) NOT NULL CHECK (quantity > 0),
+    unit_cost NUMERIC(10, 4) NOT NULL CHECK (unit_cost >= 0),
+    received_quantity NUMERIC(10, 4) NOT NULL DEFAULT 0
+        CHECK (received_quantity >= 0 AND received_quantity <= quantity),
+    CONSTRAINT unique_purchase_order_item UNIQUE (purchase_order_id, inventory_id)
+);
+
+-- Lots of batch-tracked inventory items. quantity is what is left of the
+-- batch; the stock of a tracked item is the sum of its batches.
+CREATE TABLE IF NOT EXISTS inventory_batches (
+    id SERIAL PRIMARY KEY,
+    inventory_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    quantity NUMERIC(10, 2) NOT NULL CHECK (quantity >= 0),
+    received_quantity NUMERIC(10, 2) NOT NULL CHECK (received_quantity > 0),
+    unit_cost NUMERIC(10, 4) NOT NULL CHECK (unit_cost >= 0),
+    expires_on DATE,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    purchase_order_id INT REFERENCES purchase_orders(id) ON DELETE SET NULL
+);
+
+-- Physical inventory counts. Counts are compared with system stock until the
+-- stock take is committed, when the system quantity and cost are kept.
+CREATE TABLE IF NOT EXISTS stock_takes (
+    id SERIAL PRIMARY KEY,
+    status stock_take_status NOT NULL DEFAULT 'open',
+    notes TEXT,
+    opened_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    committed_at TIMESTAMPTZ
+);
+
+-- Only one count can be in progress at a time
+CREATE UNIQUE INDEX unique_open_stock_take ON stock_takes ((TRUE)) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS stock_take_counts (
+    stock_take_id INT NOT NULL REFERENCES stock_takes(id) ON DELETE CASCADE,
+    inventory_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    counted_quantity NUMERIC(10, 2) NOT NULL CHECK (counted_quantity >= 0),
+    counted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    system_quantity NUMERIC(10, 2), -- set on commit
+    unit_cost NUMERIC(10, 4),       -- set on commit
+    PRIMARY KEY (stock_take_id, inventory_id)
+);
+
+CREATE TABLE IF NOT EXISTS customers (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT UNIQUE,
+    preferences JSONB
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id SERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id),
+    total_amount NUMERIC(10, 2) NOT NULL CHECK (total_amount >= 0),
+    status order_status NOT NULL DEFAULT 'open',
+    special_instructions JSONB,
+    payment_method payment_method NOT NULL,
+    discount_amount NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (discount_amount >= 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Menu categories; children are shown under their parent ordered by sort_order
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    parent_id INT REFERENCES categories(id) ON DELETE RESTRICT,
+    sort_order INT NOT NULL DEFAULT 0,
+    CONSTRAINT category_not_own_parent CHECK (parent_id <> id)
+);
+
+CREATE UNIQUE INDEX unique_category_name ON categories (LOWER(name));
+
+-- Every category with its position in the tree: ancestor_ids and
+-- ancestor_names run from the root down to the category itself, and
+-- sort_path orders the tree depth-first by sort_order
+CREATE VIEW category_tree AS
+WITH RECURSIVE tree AS (
+    SELECT id, name, parent_id, sort_order, 0 AS depth,
+        ARRAY[id] AS ancestor_ids, ARRAY[name] AS ancestor_names, ARRAY[sort_order, id] AS sort_path
+    FROM categories
+    WHERE parent_id IS NULL
+    UNION ALL
+    SELECT c.id, c.name, c.parent_id, c.sort_order, t.depth + 1,
+        t.ancestor_ids || c.id, t.ancestor_names || c.name, t.sort_path || ARRAY[c.sort_order, c.id]
+    FROM categories c
+    JOIN tree t ON c.parent_id = t.id
+)
+SELECT * FROM tree;
+
+CREATE TABLE IF NOT EXISTS menu_products (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    category_id INT NOT NULL REFERENCES categories(id) ON DELETE RESTRICT
+);
+
+CREATE TABLE IF NOT EXISTS menu_items (
+    id TEXT PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES menu_products(id) ON DELETE RESTRICT,
+    name TEXT NOT NULL,
+    description TEXT,
+    price NUMERIC(10, 2) NOT NULL CHECK (price >= 0),
+    category_id INT NOT NULL REFERENCES categories(id) ON DELETE RESTRICT,
+    size item_size NOT NULL,
+    recipe_scale NUMERIC(6, 3) NOT NULL DEFAULT 1 CHECK (recipe_scale > 0),
+    CONSTRAINT unique_menu_item_size UNIQUE (name, size),
+    CONSTRAINT unique_product_size UNIQUE (product_id, size)
+);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    menu_item_id TEXT NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    quantity NUMERIC NOT NULL CHECK (quantity > 0),
+    price_at_order NUMERIC(10, 2) NOT NULL CHECK (price_at_order >= 0),
+    -- ingredient cost of one unit at the time of sale
+    unit_cost NUMERIC(10, 4) CHECK (unit_cost >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS menu_item_ingredients (
+    id SERIAL PRIMARY KEY,
+    menu_item_id TEXT NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    ingredient_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    quantity NUMERIC(10, 5) NOT NULL CHECK (quantity > 0),
+    unit TEXT REFERENCES units(code), -- stock unit of the ingredient when NULL
+    CONSTRAINT unique_menu_item_ingredient UNIQUE (menu_item_id, ingredient_id)
+);
+
+-- Recipe shared by every size of a product; variants scale it by recipe_scale
+CREATE TABLE IF NOT EXISTS product_ingredients (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES menu_products(id) ON DELETE CASCADE,
+    ingredient_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    quantity NUMERIC(10, 5) NOT NULL CHECK (quantity > 0),
+    unit TEXT REFERENCES units(code), -- stock unit of the ingredient when NULL
+    CONSTRAINT unique_product_ingredient UNIQUE (product_id, ingredient_id)
+);
+
+-- stock_quantity converts a quantity of an ingredient given in unit to the
+-- ingredient's stock unit. A NULL unit means the stock unit.
+CREATE FUNCTION stock_quantity(TEXT, NUMERIC, TEXT) RETURNS NUMERIC
+LANGUAGE SQL STABLE AS $$
+    SELECT $2 * COALESCE(u.factor / s.factor, 1)
+    FROM inventory i
+    JOIN units s ON s.code = i.unit_type
+    LEFT JOIN units u ON u.code = $3
+    WHERE i.id = $1
+$$;
+
+-- Effective recipe of a menu item in stock units: the scaled product recipe
+-- plus any ingredients attached to the variant itself
+CREATE VIEW menu_item_recipes AS
+SELECT menu_item_id, ingredient_id, SUM(quantity) AS quantity
+FROM (
+    SELECT menu_item_id, ingredient_id, stock_quantity(ingredient_id, quantity, unit)
+    FROM menu_item_ingredients
+    UNION ALL
+    SELECT mi.id, pi.ingredient_id, stock_quantity(pi.ingredient_id, pi.quantity, pi.unit) * mi.recipe_scale
+    FROM product_ingredients pi
+    JOIN menu_items mi ON mi.product_id = pi.product_id
+) recipe
+GROUP BY menu_item_id, ingredient_id;
+
+-- Components of bundle menu items: a fixed menu item or a choice slot that
+-- accepts any item of a category or its subcategories, optionally of one size
+CREATE TABLE IF NOT EXISTS bundle_components (
+    id SERIAL PRIMARY KEY,
+    bundle_id TEXT NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    menu_item_id TEXT REFERENCES menu_items(id) ON DELETE RESTRICT,
+    choice_category_id INT REFERENCES categories(id) ON DELETE RESTRICT,
+    choice_size item_size,
+    quantity INT NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    CONSTRAINT bundle_component_kind CHECK ((menu_item_id IS NULL) <> (choice_category_id IS NULL)),
+    CONSTRAINT bundle_component_not_self CHECK (menu_item_id IS NULL OR menu_item_id <> bundle_id)
+);
+
+-- Allergens of a menu item, derived from the ingredients of its recipe. A
+-- bundle has the allergens of its fixed components and of every item that
+-- may be chosen for its choice slots.
+CREATE VIEW menu_item_allergens AS
+SELECT r.menu_item_id, ia.allergen
+FROM menu_item_recipes r
+JOIN inventory_allergens ia ON ia.inventory_id = r.ingredient_id
+UNION
+SELECT b.bundle_id, ia.allergen
+FROM bundle_components b
+JOIN menu_items mi ON mi.id = b.menu_item_id
+    OR (b.choice_category_id IS NOT NULL
+        AND (b.choice_size IS NULL OR mi.size = b.choice_size)
+        AND mi.category_id IN (SELECT id FROM category_tree WHERE b.choice_category_id = ANY(ancestor_ids)))
+JOIN menu_item_recipes r ON r.menu_item_id = mi.id
+JOIN inventory_allergens ia ON ia.inventory_id = r.ingredient_id;
+
+-- Nutrition of a menu item's own recipe; bundles are computed from their components
+CREATE VIEW menu_item_nutrition AS
+SELECT r.menu_item_id,
+    SUM(r.quantity * i.calories) AS calories,
+    SUM(r.quantity * i.protein) AS protein,
+    SUM(r.quantity * i.carbohydrates) AS carbohydrates,
+    SUM(r.quantity * i.fat) AS fat
+FROM menu_item_recipes r
+JOIN inventory i ON i.id = r.ingredient_id
+GROUP BY r.menu_item_id;
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    previous_status order_status NOT NULL,
+    new_status order_status NOT NULL,
+    changed_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS price_history (
+    id SERIAL PRIMARY KEY,
+    menu_item_id TEXT NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    old_price NUMERIC(10, 2) NOT NULL CHECK (old_price >= 0),
+    new_price NUMERIC(10, 2) NOT NULL CHECK (new_price >= 0),
+    changed_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS inventory_transactions (
+    id SERIAL PRIMARY KEY,
+    inventory_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    change_amount NUMERIC NOT NULL,
+    transaction_type transaction_type NOT NULL,
+    -- set for stock received against a purchase order
+    purchase_order_id INT REFERENCES purchase_orders(id) ON DELETE SET NULL,
+    batch_id INT REFERENCES inventory_batches(id) ON DELETE SET NULL,
+    stock_take_id INT REFERENCES stock_takes(id) ON DELETE SET NULL,
+    changed_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS promotions (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    discount_type discount_type NOT NULL,
+    value NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (value >= 0),
+    code TEXT UNIQUE,
+    usage_limit INT CHECK (usage_limit > 0),
+    times_used INT NOT NULL DEFAULT 0 CHECK (times_used >= 0),
+    category_id INT REFERENCES categories(id) ON DELETE RESTRICT,
+    menu_item_id TEXT REFERENCES menu_items(id) ON DELETE CASCADE,
+    buy_quantity INT CHECK (buy_quantity > 0),
+    get_quantity INT CHECK (get_quantity > 0),
+    start_time TIME,
+    end_time TIME,
+    valid_from TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT promotion_percentage_range CHECK (discount_type <> 'percentage' OR value <= 100),
+    CONSTRAINT promotion_buy_x_get_y CHECK (discount_type <> 'buy_x_get_y' OR (buy_quantity IS NOT NULL AND get_quantity IS NOT NULL)),
+    CONSTRAINT promotion_time_window CHECK ((start_time IS NULL) = (end_time IS NULL))
+);
+
+CREATE TABLE IF NOT EXISTS order_promotions (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    promotion_id INT NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    discount_amount NUMERIC(10, 2) NOT NULL CHECK (discount_amount >= 0),
+    applied_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Menu items sold as part of a bundle order line, with the share of the
+-- bundle revenue attributed to them
+CREATE TABLE IF NOT EXISTS order_item_components (
+    id SERIAL PRIMARY KEY,
+    order_item_id INT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    menu_item_id TEXT NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    quantity NUMERIC NOT NULL CHECK (quantity > 0),
+    allocated_revenue NUMERIC(10, 2) NOT NULL CHECK (allocated_revenue >= 0)
+);
+
+-- Sales per menu item with bundle lines broken down into their components
+CREATE VIEW order_item_sales AS
+SELECT oi.order_id, oi.id AS order_item_id, oi.menu_item_id, oi.quantity,
+    oi.quantity * oi.price_at_order AS revenue, NULL::TEXT AS bundle_id
+FROM order_items oi
+WHERE NOT EXISTS (SELECT 1 FROM order_item_components c WHERE c.order_item_id = oi.id)
+UNION ALL
+SELECT oi.order_id, oi.id, c.menu_item_id, c.quantity, c.allocated_revenue, oi.menu_item_id
+FROM order_items oi
+JOIN order_item_components c ON c.order_item_id = oi.id;
+
+CREATE TABLE IF NOT EXISTS modifier_groups (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    min_selections INT NOT NULL DEFAULT 0 CHECK (min_selections >= 0),
+    max_selections INT NOT NULL DEFAULT 1 CHECK (max_selections >= 1),
+    CONSTRAINT modifier_group_selection_range CHECK (max_selections >= min_selections)
+);
+
+CREATE TABLE IF NOT EXISTS modifiers (
+    id SERIAL PRIMARY KEY,
+    group_id INT NOT NULL REFERENCES modifier_groups(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    price_delta NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    CONSTRAINT unique_modifier_name UNIQUE (group_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS modifier_ingredients (
+    id SERIAL PRIMARY KEY,
+    modifier_id INT NOT NULL REFERENCES modifiers(id) ON DELETE CASCADE,
+    ingredient_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    quantity_delta NUMERIC(10, 5) NOT NULL CHECK (quantity_delta <> 0),
+    unit TEXT REFERENCES units(code), -- stock unit of the ingredient when NULL
+    CONSTRAINT unique_modifier_ingredient UNIQUE (modifier_id, ingredient_id)
+);
+
+CREATE TABLE IF NOT EXISTS menu_item_modifier_groups (
+    menu_item_id TEXT NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    group_id INT NOT NULL REFERENCES modifier_groups(id) ON DELETE CASCADE,
+    PRIMARY KEY (menu_item_id, group_id)
+);
+
+CREATE TABLE IF NOT EXISTS order_item_modifiers (
+    id SERIAL PRIMARY KEY,
+    order_item_id INT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    modifier_id INT REFERENCES modifiers(id) ON DELETE SET NULL,
+    name TEXT NOT NULL,
+    price_delta NUMERIC(10, 2) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS loyalty_transactions (
+    id SERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    order_id INT REFERENCES orders(id) ON DELETE SET NULL,
+    transaction_type loyalty_transaction_type NOT NULL,
+    points INT NOT NULL DEFAULT 0,
+    punches INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_orders_customer_id ON orders(customer_id);
+CREATE INDEX idx_orders_status ON orders(status);
+CREATE INDEX idx_order_items_order_id ON order_items(order_id);
+CREATE INDEX idx_order_items_menu_item_id ON order_items(menu_item_id);
+CREATE INDEX idx_menu_items_name ON menu_items(name);
+CREATE INDEX idx_menu_items_product_id ON menu_items(product_id);
+CREATE INDEX idx_menu_items_category_id ON menu_items(category_id);
+CREATE INDEX idx_categories_parent_id ON categories(parent_id);
+CREATE INDEX idx_bundle_components_bundle_id ON bundle_components(bundle_id);
+CREATE INDEX idx_order_item_components_order_item_id ON order_item_components(order_item_id);
+CREATE INDEX idx_inventory_name ON inventory(name);
+CREATE INDEX idx_inventory_price ON inventory(price);
+CREATE INDEX idx_inventory_stock_level ON inventory(stock);
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id);
+CREATE INDEX idx_price_history_menu_item_id ON price_history(menu_item_id);
+CREATE INDEX idx_menu_items_description ON menu_items (description);
+CREATE INDEX idx_customers_name ON customers (name);
+CREATE INDEX idx_order_promotions_order_id ON order_promotions(order_id);
+CREATE INDEX idx_order_promotions_promotion_id ON order_promotions(promotion_id);
+CREATE INDEX idx_loyalty_transactions_customer_id ON loyalty_transactions(customer_id);
+CREATE INDEX idx_inventory_allergens_allergen ON inventory_allergens(allergen);
+CREATE INDEX idx_modifiers_group_id ON modifiers(group_id);
+CREATE INDEX idx_inventory_supplier_id ON inventory(supplier_id);
+CREATE INDEX idx_purchase_orders_supplier_id ON purchase_orders(supplier_id);
+CREATE INDEX idx_purchase_orders_status ON purchase_orders(status);
+CREATE INDEX idx_purchase_order_items_inventory_id ON purchase_order_items(inventory_id);
+CREATE INDEX idx_inventory_batches_inventory_id ON inventory_batches(inventory_id);
+CREATE INDEX idx_inventory_batches_expires_on ON inventory_batches(expires_on) WHERE quantity > 0;
+CREATE INDEX idx_order_item_modifiers_order_item_id ON order_item_modifiers(order_item_id);
+
+INSERT INTO allergens (code, name) VALUES
+('gluten', 'Cereals containing gluten'),
+('crustaceans', 'Crustaceans'),
+('eggs', 'Eggs'),
+('fish', 'Fish'),
+('peanuts', 'Peanuts'),
+('soybeans', 'Soybeans'),
+('milk', 'Milk'),
+('nuts', 'Tree nuts'),
+('celery', 'Celery'),
+('mustard', 'Mustard'),
+('sesame', 'Sesame seeds'),
+('sulphites', 'Sulphur dioxide and sulphites'),
+('lupin', 'Lupin'),
+('molluscs', 'Molluscs');
+
+INSERT INTO units (code, name, dimension, factor) VALUES
+('g', 'Gram', 'mass', 1),
+('kg', 'Kilogram', 'mass', 1000),
+('ml', 'Millilitre', 'volume', 1),
+('l', 'Litre', 'volume', 1000),
+('piece', 'Piece', 'count', 1),
+('dozen', 'Dozen', 'count', 12),
+('loaf', 'Loaf', 'loaf', 1);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_loyalty_transactions_earned_order;
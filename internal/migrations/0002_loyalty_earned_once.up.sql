@@ -0,0 +1,4 @@
+-- An order earns loyalty once, however often it is closed.
+
+CREATE UNIQUE INDEX idx_loyalty_transactions_earned_order
+    ON loyalty_transactions(order_id) WHERE transaction_type = 'earned';
@@ -0,0 +1,242 @@
+// Package migrations applies the versioned database schema embedded in the
+// binary. Migration files are named <version>_<name>.up.sql with a matching
+// .down.sql; applied versions are recorded in schema_migrations.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockID keys the advisory lock that keeps two instances from migrating at
+// the same time.
+const lockID = 7290315
+
+var ErrAlreadySeeded = errors.New("database already has data")
+
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// Status is a migration with the time it was applied, if it was.
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// load reads the embedded migrations in version order.
+func load() ([]Migration, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	seen := map[int]bool{}
+	for _, file := range names {
+		base := strings.TrimSuffix(file, ".up.sql")
+		v, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(v)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: name must be <version>_<name>.up.sql", file)
+		}
+		if seen[version] {
+			return nil, fmt.Errorf("migration %s: duplicate version %d", file, version)
+		}
+		seen[version] = true
+
+		up, err := files.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		down, err := files.ReadFile(base + ".down.sql")
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", file, err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, up: string(up), down: string(down)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// withLock runs fn on a single connection holding the migration lock, after
+// making sure schema_migrations exists.
+func withLock(ctx context.Context, dbc *sql.DB, fn func(conn *sql.Conn) error) error {
+	conn, err := dbc.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`)
+	if err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func applied(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		versions[version] = at
+	}
+	return versions, rows.Err()
+}
+
+// run applies one migration script and records the change, in one transaction.
+func run(ctx context.Context, conn *sql.Conn, m Migration, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Up applies every pending migration in order and returns those applied.
+func Up(ctx context.Context, dbc *sql.DB) ([]Migration, error) {
+	migrations, err := load()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	err = withLock(ctx, dbc, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if _, ok := versions[m.Version]; ok {
+				continue
+			}
+			if err := run(ctx, conn, m, m.up,
+				"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name); err != nil {
+				return err
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// those reverted.
+func Down(ctx context.Context, dbc *sql.DB, steps int) ([]Migration, error) {
+	migrations, err := load()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	err = withLock(ctx, dbc, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			m := migrations[i]
+			if _, ok := versions[m.Version]; !ok {
+				continue
+			}
+			if err := run(ctx, conn, m, m.down,
+				"DELETE FROM schema_migrations WHERE version = $1", m.Version); err != nil {
+				return err
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Statuses lists every known migration with the time it was applied.
+func Statuses(ctx context.Context, dbc *sql.DB) ([]Status, error) {
+	migrations, err := load()
+	if err != nil {
+		return nil, err
+	}
+	var statuses []Status
+	err = withLock(ctx, dbc, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			s := Status{Version: m.Version, Name: m.Name}
+			if at, ok := versions[m.Version]; ok {
+				s.AppliedAt = &at
+			}
+			statuses = append(statuses, s)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Seed loads the demo data into a migrated database. It fails with
+// ErrAlreadySeeded when the inventory already has items.
+func Seed(ctx context.Context, dbc *sql.DB) error {
+	script, err := files.ReadFile("seed.sql")
+	if err != nil {
+		return err
+	}
+	tx, err := dbc.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var seeded bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM inventory)").Scan(&seeded); err != nil {
+		return err
+	}
+	if seeded {
+		return ErrAlreadySeeded
+	}
+	if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+		return fmt.Errorf("seed: %w", err)
+	}
+	return tx.Commit()
+}
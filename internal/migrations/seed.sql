@@ -0,0 +1,445 @@
+-- Demo data: suppliers, inventory, menu, customers and order history.
+-- Load it into an empty database with "main seed".
+
+INSERT INTO suppliers (name, contact_name, email, phone, lead_time_days) VALUES
+('Highland Roasters', 'Aigerim Sadykova', 'orders@highlandroasters.kz', '+7 701 555 0101', 3),
+('Steppe Dairy', 'Marat Akhmetov', 'sales@steppedairy.kz', '+7 702 555 0102', 1),
+('Almaty Fresh Market', 'Dana Omarova', 'supply@almatyfresh.kz', '+7 705 555 0103', 1),
+('Golden Crust Bakery', 'Timur Bekov', 'wholesale@goldencrust.kz', '+7 707 555 0104', 2);
+
+-- Insert mock data into the inventory table
+INSERT INTO inventory (id, name, stock, unit_type, price, calories, protein, carbohydrates, fat) VALUES
+('1', 'Espresso Beans', 150, 'kg', 12.0, 100, 12, 0, 0),
+('2', 'Milk', 100, 'l', 2.0, 640, 33, 48, 36),
+('3', 'Chocolate Syrup', 50, 'l', 8.0, 2800, 20, 650, 10),
+('4', 'Bread', 200, 'loaf', 1.2, 2000, 80, 380, 25),
+('5', 'Cheese', 100, 'kg', 10.0, 4000, 250, 13, 330),
+('6', 'Lettuce', 50, 'kg', 3.0, 150, 14, 29, 2),
+('7', 'Chicken', 80, 'kg', 12.0, 1650, 310, 0, 36),
+('8', 'Tomatoes', 40, 'kg', 4.0, 180, 9, 39, 2),
+('9', 'Avocados', 60, 'kg', 5.0, 1600, 20, 85, 147),
+('10', 'Olive Oil', 20, 'l', 10.0, 8200, 0, 0, 910),
+('11', 'Butter', 30, 'kg', 7.0, 7170, 9, 1, 810),
+('12', 'Spinach', 45, 'kg', 3.0, 230, 29, 36, 4),
+('13', 'Pasta', 200, 'kg', 2.0, 3710, 130, 750, 15),
+('14', 'Fruit Mixture', 100, 'kg', 5.5, 500, 7, 120, 3),
+('15', 'Yogurt', 50, 'l', 4.0, 610, 35, 47, 33),
+('16', 'Croissants', 120, 'piece', 1.5, 230, 5, 26, 12),
+('17', 'Bagels', 180, 'piece', 1.8, 270, 10, 53, 2),
+('18', 'Cream', 30, 'l', 3.0, 3400, 21, 28, 360),
+('19', 'Sugar', 150, 'kg', 1.2, 3870, 0, 1000, 0),
+('20', 'Coffee Cups', 1000, 'piece', 0.05, 0, 0, 0, 0),
+('21', 'Flour', 300, 'kg', 1.0, 3640, 100, 760, 10),
+('22', 'Ham', 70, 'kg', 8.0, 1450, 210, 15, 60),
+('23', 'Oat Milk', 40, 'l', 3.5, 480, 10, 66, 15),
+('24', 'Vanilla Syrup', 20, 'l', 9.0, 3200, 0, 800, 0),
+('25', 'Caramel Syrup', 20, 'l', 9.0, 3300, 0, 820, 0);
+
+UPDATE inventory SET purchase_unit = 'case', purchase_quantity = 12, purchase_quantity_unit = 'l'
+WHERE id IN ('2', '23');
+UPDATE inventory SET purchase_unit = 'box', purchase_quantity = 4, purchase_quantity_unit = 'dozen'
+WHERE id = '16';
+
+UPDATE inventory i SET supplier_id = s.supplier_id, reorder_level = s.reorder_level, par_level = s.par_level
+FROM (VALUES
+    ('1', 1, 160, 250),
+    ('2', 2, 120, 200),
+    ('3', 1, 20, 60),
+    ('15', 2, 20, 60),
+    ('18', 2, 15, 40),
+    ('23', 2, 30, 72),
+    ('24', 1, 10, 30),
+    ('25', 1, 10, 30),
+    ('4', 4, 50, 220),
+    ('16', 4, 100, 192),
+    ('17', 4, 50, 200),
+    ('6', 3, 20, 60),
+    ('8', 3, 20, 50),
+    ('9', 3, 30, 70)
+) AS s(id, supplier_id, reorder_level, par_level)
+WHERE i.id = s.id;
+
+UPDATE inventory SET track_batches = TRUE, shelf_life_days = s.days
+FROM (VALUES ('2', 7), ('15', 14), ('18', 10)) AS s(id, days)
+WHERE inventory.id = s.id;
+
+-- Batches add up to the stock of the tracked items
+INSERT INTO inventory_batches (inventory_id, quantity, received_quantity, unit_cost, expires_on, received_at) VALUES
+('2', 36, 48, 2.00, CURRENT_DATE + 1, NOW() - INTERVAL '6 days'),
+('2', 64, 72, 2.00, CURRENT_DATE + 5, NOW() - INTERVAL '2 days'),
+('15', 12, 24, 4.00, CURRENT_DATE + 2, NOW() - INTERVAL '12 days'),
+('15', 38, 40, 4.00, CURRENT_DATE + 12, NOW() - INTERVAL '2 days'),
+('18', 30, 36, 3.00, CURRENT_DATE + 6, NOW() - INTERVAL '4 days');
+
+INSERT INTO purchase_orders (supplier_id, status, notes, created_at, updated_at, sent_at) VALUES
+(2, 'sent', 'Weekly dairy', '2024-02-18 09:00:00+00', '2024-02-18 10:00:00+00', '2024-02-18 10:00:00+00'),
+(3, 'draft', NULL, '2024-02-19 08:30:00+00', '2024-02-19 08:30:00+00', NULL);
+
+INSERT INTO purchase_order_items (purchase_order_id, inventory_id, quantity, unit_cost) VALUES
+(1, '2', 48, 1.90),
+(1, '18', 12, 2.80),
+(2, '6', 20, 2.90),
+(2, '8', 10, 3.80);
+
+INSERT INTO categories (name, parent_id, sort_order) VALUES
+('Beverage', NULL, 1),
+('Pastry', NULL, 2),
+('Food', NULL, 3),
+('Combo', NULL, 4),
+('Hot Coffee', 1, 1),
+('Smoothies', 1, 2);
+
+INSERT INTO menu_products (name, description, category_id) VALUES
+('Cappuccino', 'Espresso with steamed milk and thick foam', 5),
+('Americano', 'Espresso diluted with hot water', 5),
+('Flat White', 'Espresso with smooth steamed milk', 5),
+('Cheese Croissant', 'Croissant filled with cheese', 2),
+('Chocolate Croissant', 'Croissant filled with chocolate', 2),
+('Muffin', 'Soft baked muffin', 2),
+('Sandwich', 'Ham and cheese sandwich', 3),
+('Espresso', 'Strong black coffee brewed by forcing steam through finely ground coffee beans', 5),
+('Latte', 'Espresso with steamed milk and a light layer of foam', 5),
+('Mocha', 'Espresso with chocolate syrup, steamed milk, and whipped cream', 5),
+('Grilled Cheese Sandwich', 'Cheese sandwich with toasted bread', 3),
+('Chicken Salad', 'Fresh salad with grilled chicken and dressing', 3),
+('Pasta Primavera', 'Pasta with fresh vegetables in a light sauce', 3),
+('Avocado Toast', 'Toasted bread with mashed avocado, sprinkled with chili flakes', 3),
+('Mixed Berry Smoothie', 'Blended mixed berries with yogurt', 6),
+('Croissant', 'Flaky, buttery pastry', 2),
+('Bagel with Cream Cheese', 'Soft bagel with a layer of cream cheese', 2),
+('Coffee & Croissant', 'Any medium coffee with a butter croissant', 4);
+
+INSERT INTO inventory_allergens (inventory_id, allergen) VALUES
+('2', 'milk'),
+('3', 'milk'),
+('3', 'soybeans'),
+('4', 'gluten'),
+('5', 'milk'),
+('11', 'milk'),
+('13', 'gluten'),
+('13', 'eggs'),
+('15', 'milk'),
+('16', 'gluten'),
+('16', 'milk'),
+('16', 'eggs'),
+('17', 'gluten'),
+('17', 'sesame'),
+('18', 'milk'),
+('21', 'gluten'),
+('22', 'sulphites'),
+('23', 'gluten');
+
+INSERT INTO menu_items (id, product_id, name, description, price, category_id, size, recipe_scale) VALUES
+('1', 1, 'Cappuccino', 'Espresso with steamed milk and thick foam', 4.00, 5, 'medium', 1),
+('2', 2, 'Americano', 'Espresso diluted with hot water', 3.50, 5, 'medium', 1),
+('3', 3, 'Flat White', 'Espresso with smooth steamed milk', 4.20, 5, 'medium', 1),
+('4', 4, 'Cheese Croissant', 'Croissant filled with cheese', 3.00, 2, 'small', 1),
+('5', 5, 'Chocolate Croissant', 'Croissant filled with chocolate', 3.50, 2, 'small', 1),
+('6', 6, 'Muffin', 'Soft baked muffin', 2.80, 2, 'small', 1),
+('7', 7, 'Sandwich', 'Ham and cheese sandwich', 5.50, 3, 'medium', 1),
+('8', 8, 'Espresso', 'Strong black coffee brewed by forcing steam through finely ground coffee beans', 2.50, 5, 'small', 1),
+('9', 9, 'Latte', 'Espresso with steamed milk and a light layer of foam', 3.80, 5, 'medium', 1),
+('10', 10, 'Mocha', 'Espresso with chocolate syrup, steamed milk, and whipped cream', 5.00, 5, 'medium', 1),
+('11', 11, 'Grilled Cheese Sandwich', 'Cheese sandwich with toasted bread', 5.80, 3, 'medium', 1),
+('12', 12, 'Chicken Salad', 'Fresh salad with grilled chicken and dressing', 7.00, 3, 'large', 1),
+('13', 13, 'Pasta Primavera', 'Pasta with fresh vegetables in a light sauce', 9.00, 3, 'large', 1),
+('14', 14, 'Avocado Toast', 'Toasted bread with mashed avocado, sprinkled with chili flakes', 6.00, 3, 'medium', 1),
+('15', 15, 'Mixed Berry Smoothie', 'Blended mixed berries with yogurt', 4.50, 6, 'large', 1),
+('16', 16, 'Croissant', 'Flaky, buttery pastry', 2.00, 2, 'small', 1),
+('17', 17, 'Bagel with Cream Cheese', 'Soft bagel with a layer of cream cheese', 2.50, 2, 'small', 1),
+('18', 9, 'Latte', 'Espresso with steamed milk and a light layer of foam', 3.20, 5, 'small', 0.75),
+('19', 9, 'Latte', 'Espresso with steamed milk and a light layer of foam', 4.50, 5, 'large', 1.25),
+('20', 1, 'Cappuccino', 'Espresso with steamed milk and thick foam', 4.80, 5, 'large', 1.25),
+('21', 18, 'Coffee & Croissant', 'Any medium coffee with a butter croissant', 5.00, 4, 'medium', 1);
+
+INSERT INTO bundle_components (bundle_id, name, menu_item_id, choice_category_id, choice_size, quantity) VALUES
+('21', 'Coffee', NULL, 5, 'medium', 1),
+('21', 'Croissant', '16', NULL, NULL, 1);
+
+INSERT INTO product_ingredients (product_id, ingredient_id, quantity) VALUES
+(8, '1', 0.02000),
+(1, '1', 0.02000),
+(1, '2', 0.05000),
+(9, '1', 0.02000),
+(9, '2', 0.08000),
+(2, '1', 0.03000),
+(3, '1', 0.02000),
+(3, '2', 0.06000),
+(4, '4', 0.10000),
+(4, '11', 0.05000),
+(4, '5', 0.05000),
+(5, '4', 0.10000),
+(5, '11', 0.05000),
+(5, '3', 0.05000),
+(6, '4', 0.10000),
+(6, '11', 0.05000),
+(6, '19', 0.05000),
+(17, '4', 0.12000),
+(17, '11', 0.03000),
+(17, '5', 0.05000),
+(7, '4', 0.15000),
+(7, '5', 0.05000),
+(7, '22', 0.08000),
+(10, '1', 0.02000),
+(10, '2', 0.06000),
+(10, '3', 0.03000),
+(10, '18', 0.02000),
+(11, '4', 0.15000),
+(11, '5', 0.06000),
+(11, '11', 0.01000),
+(12, '7', 0.12000),
+(12, '6', 0.08000),
+(12, '8', 0.05000),
+(12, '10', 0.01000),
+(13, '13', 0.12000),
+(13, '12', 0.05000),
+(13, '8', 0.05000),
+(13, '5', 0.02000),
+(13, '10', 0.01000),
+(14, '4', 0.10000),
+(14, '9', 0.10000),
+(14, '10', 0.00500),
+(15, '14', 0.15000),
+(15, '15', 0.10000),
+(16, '16', 1.00000);
+
+INSERT INTO modifier_groups (name, min_selections, max_selections) VALUES
+('Milk', 0, 1),
+('Extra Shot', 0, 1),
+('Syrup', 0, 2);
+
+INSERT INTO modifiers (group_id, name, price_delta) VALUES
+(1, 'Oat milk', 0.50),
+(2, 'Extra shot of espresso', 0.70),
+(3, 'Vanilla syrup', 0.40),
+(3, 'Caramel syrup', 0.40),
+(3, 'Chocolate syrup', 0.40);
+
+INSERT INTO modifier_ingredients (modifier_id, ingredient_id, quantity_delta, unit) VALUES
+(1, '2', -60, 'ml'),
+(1, '23', 60, 'ml'),
+(2, '1', 20, 'g'),
+(3, '24', 20, 'ml'),
+(4, '25', 20, 'ml'),
+(5, '3', 20, 'ml');
+
+-- Recipes state their unit so they keep their meaning if a stock unit changes
+UPDATE product_ingredients pi SET unit = i.unit_type
+FROM inventory i WHERE i.id = pi.ingredient_id AND pi.unit IS NULL;
+
+INSERT INTO menu_item_modifier_groups (menu_item_id, group_id) VALUES
+('1', 1), ('1', 2), ('1', 3),
+('2', 2), ('2', 3),
+('3', 1), ('3', 2), ('3', 3),
+('8', 2),
+('9', 1), ('9', 2), ('9', 3),
+('10', 1), ('10', 2), ('10', 3),
+('18', 1), ('18', 2), ('18', 3),
+('19', 1), ('19', 2), ('19', 3),
+('20', 1), ('20', 2), ('20', 3);
+
+-- Insert customers (now with 30 records to match all orders)
+INSERT INTO customers (name, email, preferences) VALUES
+('John Smith', 'john_smith@gmail.com', '{"note":"subscribe_to_newsletters"}'),
+('Emily Johnson', 'emily_johnson@gmail.com', '{"note":"prefers_clothing_discounts"}'),
+('Michael Williams', 'michael_williams@gmail.com', '{"note":"not_interested_in_ads"}'),
+('Sarah Brown', 'sarah_brown@gmail.com', '{"note":"interested_in_electronics_promotions"}'),
+('David Jones', 'david_jones@gmail.com', '{"note":"wants_product_updates"}'),
+('Olivia Garcia', 'olivia_garcia@gmail.com', '{"note":"prefers_home_goods"}'),
+('James Martinez', 'james_martinez@gmail.com', '{"note":"interested_in_eco_friendly_products"}'),
+('Sophia Rodriguez', 'sophia_rodriguez@gmail.com', '{"note":"interested_in_new_books"}'),
+('Daniel Wilson', 'daniel_wilson@gmail.com', '{"note":"wants_travel_promotions"}'),
+('Isabella Moore', 'isabella_moore@gmail.com', '{"note":"prefers_cosmetics_discounts"}'),
+('William Taylor', 'william_taylor@gmail.com', '{"note":"interested_in_sports_and_fitness"}'),
+('Charlotte Anderson', 'charlotte_anderson@gmail.com', '{"note":"not_interested_in_newsletters"}'),
+('Lucas Thomas', 'lucas_thomas@gmail.com', '{"note":"interested_in_pets"}'),
+('Mia Jackson', 'mia_jackson@gmail.com', '{"note":"prefers_baby_products"}'),
+('Henry White', 'henry_white@gmail.com', '{"note":"looking_for_travel_deals"}'),
+('Emma Harris', 'emma_harris@gmail.com', '{"note":"interested_in_fashion"}'),
+('Alexander Clark', 'alexander_clark@gmail.com', '{"note":"prefers_tech_products"}'),
+('Ava Lewis', 'ava_lewis@gmail.com', '{"note":"wants_food_delivery_deals"}'),
+('Benjamin Walker', 'benjamin_walker@gmail.com', '{"note":"interested_in_diy"}'),
+('Chloe Hall', 'chloe_hall@gmail.com', '{"note":"prefers_beauty_products"}'),
+('Jacob Allen', 'jacob_allen@gmail.com', '{"note":"interested_in_gaming"}'),
+('Abigail Young', 'abigail_young@gmail.com', '{"note":"wants_book_recommendations"}'),
+('Matthew Hernandez', 'matthew_hernandez@gmail.com', '{"note":"interested_in_fitness"}'),
+('Elizabeth King', 'elizabeth_king@gmail.com', '{"note":"prefers_home_decor"}'),
+('Ethan Wright', 'ethan_wright@gmail.com', '{"note":"interested_in_cars"}'),
+('Sofia Lopez', 'sofia_lopez@gmail.com', '{"note":"wants_recipe_ideas"}'),
+('Andrew Hill', 'andrew_hill@gmail.com', '{"note":"interested_in_photography"}'),
+('Madison Scott', 'madison_scott@gmail.com', '{"note":"prefers_pet_products"}'),
+('Joshua Green', 'joshua_green@gmail.com', '{"note":"interested_in_music"}'),
+('Victoria Adams', 'victoria_adams@gmail.com', '{"note":"wants_travel_tips"}');
+
+-- Now all orders can be inserted without foreign key violations
+INSERT INTO orders (customer_id, total_amount, status, special_instructions, payment_method, created_at, updated_at) VALUES
+(1, 7.80, 'open', '{"note":"Add extra milk"}', 'card', '2024-01-10 08:45:00', '2024-01-10 08:50:00'),
+(2, 12.00, 'closed', '{"note":"Extra cheese"}', 'cash', '2024-01-12 12:30:00', '2024-01-12 12:35:00'),
+(3, 8.50, 'open', '{"note":"No sugar"}', 'card', '2024-01-14 15:15:00', '2024-01-14 15:20:00'),
+(4, 15.00, 'closed', '{"note":"Add extra chicken"}', 'cash', '2024-01-16 13:25:00', '2024-01-16 13:30:00'),
+(5, 10.00, 'open', '{"note":"Extra avocado"}', 'cash', '2024-01-18 16:00:00', '2024-01-18 16:05:00'),
+(6, 6.80, 'closed', '{"note":"Add cream"}', 'card', '2024-01-20 18:00:00', '2024-01-20 18:05:00'),
+(7, 5.20, 'open', '{"note":"No tomatoes"}', 'cash', '2024-01-22 10:45:00', '2024-01-22 10:50:00'),
+(8, 20.00, 'closed', '{"note":"Add extra shot of espresso"}', 'card', '2024-01-25 11:00:00', '2024-01-25 11:05:00'),
+(9, 14.00, 'open', '{"note":"Spicy chicken"}', 'card', '2024-01-27 14:30:00', '2024-01-27 14:35:00'),
+(10, 9.50, 'closed', '{"note":"Add extra cinnamon"}', 'cash', '2024-01-29 17:00:00', '2024-01-29 17:05:00'),
+(11, 11.20, 'open', '{"note":"No onions"}', 'card', '2024-02-01 09:00:00', '2024-02-01 09:05:00'),
+(12, 7.90, 'closed', '{"note":"Extra fruit"}', 'card', '2024-02-02 14:10:00', '2024-02-02 14:15:00'),
+(13, 5.60, 'open', '{"note":"No dairy"}', 'cash', '2024-02-04 18:45:00', '2024-02-04 18:50:00'),
+(14, 13.00, 'closed', '{"note":"Extra avocado"}', 'card', '2024-02-06 12:30:00', '2024-02-06 12:35:00'),
+(15, 9.80, 'open', '{"note":"Add extra sugar"}', 'cash', '2024-02-08 10:00:00', '2024-02-08 10:05:00'),
+(16, 12.50, 'closed', '{"note":"No cream"}', 'card', '2024-02-10 16:30:00', '2024-02-10 16:35:00'),
+(17, 8.60, 'open', '{"note":"Add extra toast"}', 'cash', '2024-02-12 14:00:00', '2024-02-12 14:05:00'),
+(18, 7.30, 'closed', '{"note":"Add extra yogurt"}', 'card', '2024-02-14 13:00:00', '2024-02-14 13:05:00'),
+(19, 18.20, 'open', '{"note":"No onions, extra cheese"}', 'card', '2024-02-16 15:30:00', '2024-02-16 15:35:00'),
+(20, 14.80, 'closed', '{"note":"Extra sauce"}', 'cash', '2024-02-18 10:45:00', '2024-02-18 10:50:00'),
+(21, 16.00, 'open', '{"note":"More tomatoes"}', 'card', '2024-02-20 08:30:00', '2024-02-20 08:35:00'),
+(22, 13.50, 'closed', '{"note":"Spicy salsa"}', 'cash', '2024-02-22 17:00:00', '2024-02-22 17:05:00'),
+(23, 9.00, 'open', '{"note":"No cream"}', 'card', '2024-02-24 14:30:00', '2024-02-24 14:35:00'),
+(24, 7.10, 'closed', '{"note":"Extra cheese"}', 'cash', '2024-02-26 10:00:00', '2024-02-26 10:05:00'),
+(25, 15.30, 'open', '{"note":"Extra avocado"}', 'card', '2024-02-28 13:45:00', '2024-02-28 13:50:00'),
+(26, 8.90, 'closed', '{"note":"No spices"}', 'cash', '2024-03-01 17:30:00', '2024-03-01 17:35:00'),
+(27, 6.40, 'open', '{"note":"More lettuce"}', 'card', '2024-03-03 09:00:00', '2024-03-03 09:05:00'),
+(28, 11.70, 'closed', '{"note":"No sugar"}', 'card', '2024-03-05 16:00:00', '2024-03-05 16:05:00'),
+(29, 10.50, 'open', '{"note":"Less salt"}', 'cash', '2024-03-07 10:30:00', '2024-03-07 10:35:00'),
+(30, 8.80, 'closed', '{"note":"Extra cinnamon"}', 'card', '2024-03-09 14:15:00', '2024-03-09 14:20:00');
+
+INSERT INTO order_items (order_id, menu_item_id, quantity, price_at_order) VALUES
+(1, '8', 2, 3.50),
+(1, '4', 1, 2.50),
+(2, '1', 1, 4.50),
+(2, '6', 2, 2.80),
+(3, '9', 1, 4.00),
+(3, '5', 1, 3.00),
+(4, '2', 2, 3.00),
+(4, '17', 1, 2.60),
+(5, '3', 1, 4.20),
+(5, '7', 1, 5.50),
+(6, '4', 1, 2.50),
+(6, '6', 2, 2.80),
+(7, '9', 1, 4.00),
+(7, '7', 1, 5.50),
+(8, '2', 1, 3.00),
+(8, '6', 2, 2.80),
+(9, '8', 1, 3.50),
+(9, '5', 1, 3.00),
+(10, '1', 1, 4.50),
+(10, '4', 1, 2.50),
+(16, '8', 2, 3.50),
+(16, '4', 1, 2.50),
+(17, '9', 1, 4.00),
+(17, '6', 2, 2.80),
+(18, '2', 1, 3.00),
+(18, '17', 1, 2.60),
+(19, '3', 1, 4.20),
+(19, '7', 1, 5.50),
+(20, '8', 1, 3.50),
+(20, '4', 1, 2.50),
+(21, '1', 1, 4.50),
+(21, '4', 1, 3.00),
+(22, '9', 1, 4.00),
+(22, '6', 2, 2.80),
+(23, '8', 2, 3.50),
+(23, '7', 1, 5.50),
+(24, '2', 1, 3.00),
+(24, '6', 2, 2.80),
+(25, '9', 1, 4.00),
+(25, '4', 1, 2.50),
+(26, '3', 1, 4.20),
+(26, '7', 1, 5.50),
+(27, '1', 1, 4.50),
+(27, '4', 1, 2.50),
+(28, '9', 1, 4.00),
+(28, '7', 1, 5.50),
+(29, '8', 1, 3.50),
+(29, '5', 1, 3.00),
+(30, '1', 1, 4.50),
+(30, '4', 1, 2.50);
+
+-- Cost the seeded order lines at the current ingredient prices
+UPDATE order_items oi SET unit_cost = COALESCE((
+    SELECT SUM(r.quantity * i.price)
+    FROM menu_item_recipes r
+    JOIN inventory i ON i.id = r.ingredient_id
+    WHERE r.menu_item_id = oi.menu_item_id), 0);
+
+INSERT INTO inventory_transactions (inventory_id, change_amount, transaction_type, changed_at) VALUES
+('1', -1.0, 'sale', '2024-01-10'),
+('2', -2.0, 'sale', '2024-01-12'),
+('3', -0.5, 'sale', '2024-01-14'),
+('4', -1.0, 'sale', '2024-01-16'),
+('5', -2.0, 'sale', '2024-01-18'),
+('6', -0.2, 'sale', '2024-01-20'),
+('7', -1.0, 'sale', '2024-01-22'),
+('8', -0.5, 'sale', '2024-01-25'),
+('9', -3.0, 'sale', '2024-01-27'),
+('10', -1.0, 'sale', '2024-01-29'),
+('11', -0.5, 'sale', '2024-02-01'),
+('12', -2.5, 'sale', '2024-02-02'),
+('13', -0.2, 'sale', '2024-02-04'),
+('14', -1.5, 'sale', '2024-02-06'),
+('15', -2.5, 'sale', '2024-02-08'),
+('16', -0.1, 'sale', '2024-02-10'),
+('17', -1.5, 'sale', '2024-02-12'),
+('18', -0.3, 'sale', '2024-02-14'),
+('19', -4.0, 'sale', '2024-02-16'),
+('20', -1.2, 'sale', '2024-02-18'),
+('16', -0.5, 'written off', '2024-01-31'),
+('2', -1.0, 'written off', '2024-02-15');
+
+INSERT INTO order_status_history (order_id, previous_status, new_status, changed_at) VALUES
+(1, 'open', 'closed', '2024-01-10'),
+(2, 'open', 'closed', '2024-01-12'),
+(3, 'open', 'closed', '2024-01-14'),
+(4, 'open', 'closed', '2024-01-16'),
+(5, 'open', 'closed', '2024-01-18'),
+(6, 'open', 'closed', '2024-01-20'),
+(7, 'open', 'closed', '2024-01-22'),
+(8, 'open', 'closed', '2024-01-25'),
+(9, 'open', 'closed', '2024-01-27'),
+(10, 'open', 'closed', '2024-01-29'),
+(11, 'open', 'closed', '2024-02-01'),
+(12, 'open', 'closed', '2024-02-02'),
+(13, 'open', 'closed', '2024-02-04'),
+(14, 'open', 'closed', '2024-02-06'),
+(15, 'open', 'closed', '2024-02-08'),
+(16, 'open', 'closed', '2024-02-10'),
+(17, 'open', 'closed', '2024-02-12'),
+(18, 'open', 'closed', '2024-02-14'),
+(19, 'open', 'closed', '2024-02-16'),
+(20, 'open', 'closed', '2024-02-18');
+
+INSERT INTO price_history (menu_item_id, old_price, new_price, changed_at) VALUES
+('8', 2.00, 2.50, '2024-01-01'),
+('9', 3.50, 3.80, '2024-01-01'),
+('10', 4.50, 5.00, '2024-01-01'),
+('11', 5.00, 5.80, '2024-01-01'),
+('12', 6.50, 7.00, '2024-01-01'),
+('13', 8.50, 9.00, '2024-01-01'),
+('14', 5.50, 6.00, '2024-01-01'),
+('3', 4.00, 4.50, '2024-01-01'),
+('4', 1.80, 2.00, '2024-01-01'),
+('6', 2.20, 2.50, '2024-01-01'),
+('11', 2.50, 3.00, '2024-02-01'),
+('14', 3.80, 4.00, '2024-02-01'),
+('12', 5.00, 5.50, '2024-02-01'),
+('15', 5.80, 6.00, '2024-02-01'),
+('4', 7.00, 7.50, '2024-02-01'),
+('5', 9.00, 9.50, '2024-02-01'),
+('5', 6.00, 6.20, '2024-02-01'),
+('1', 4.50, 5.00, '2024-02-01'),
+('5', 2.00, 2.20, '2024-02-01'),
+('9', 2.50, 2.80, '2024-02-01');
+
+INSERT INTO promotions (name, discount_type, value, code, usage_limit, category_id, menu_item_id, buy_quantity, get_quantity, start_time, end_time, expires_at) VALUES
+('Happy Hour', 'percentage', 20, NULL, NULL, 1, NULL, NULL, NULL, '15:00', '17:00', NULL),
+('Welcome 10%', 'percentage', 10, 'WELCOME10', 100, NULL, NULL, NULL, NULL, NULL, NULL, '2026-12-31 23:59:59'),
+('Fixed 2 Off', 'fixed', 2, 'TAKE2', 50, NULL, NULL, NULL, NULL, NULL, NULL, '2026-12-31 23:59:59'),
+('Croissant 2+1', 'buy_x_get_y', 0, NULL, NULL, NULL, '16', 2, 1, NULL, NULL, NULL);
+
+INSERT INTO loyalty_transactions (customer_id, order_id, transaction_type, points, punches, created_at) VALUES
+(2, 2, 'earned', 12, 1, '2024-01-12 12:35:00'),
+(4, 4, 'earned', 15, 2, '2024-01-16 13:30:00'),
+(6, 6, 'earned', 6, 0, '2024-01-20 18:05:00'),
+(8, 8, 'earned', 20, 1, '2024-01-25 11:05:00'),
+(10, 10, 'earned', 9, 1, '2024-01-29 17:05:00');
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of failure. Repositories and services return them wrapped in an
+// *Error, whose message is meant for the client; any other error is an
+// internal failure.
+var (
+	ErrNotFound = errors.New("not found")
+	ErrInvalid  = errors.New("invalid")
+	ErrConflict = errors.New("conflict")
+	// ErrUnprocessable is a well-formed request the data cannot take, e.g.
+	// one referring to a row that does not exist.
+	ErrUnprocessable = errors.New("unprocessable")
+)
+
+// Error is a failure the client can act on. Kind is ErrNotFound, ErrInvalid,
+// ErrConflict or ErrUnprocessable. Code names the failure for clients that
+// handle it, e.g. "insufficient_stock"; when empty it follows from Kind.
+// Fields lists the request fields at fault, if known.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+// FieldError explains why one field of a request is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func (e *Error) Unwrap() error { return e.Kind }
+
+func NotFound(format string, args ...interface{}) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func Invalid(format string, args ...interface{}) error {
+	return &Error{Kind: ErrInvalid, Message: fmt.Sprintf(format, args...)}
+}
+
+func Conflict(format string, args ...interface{}) error {
+	return &Error{Kind: ErrConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+// Failures callers tell apart. They are usually wrapped with details, e.g.
+// fmt.Errorf("%w: ingredient %s", ErrInsufficientStock, id), and still match
+// their kind. A reference to a row that does not exist is ErrUnprocessable,
+// like the foreign key violations the database reports.
+var (
+	ErrUnknownMenuItem     = &Error{Kind: ErrUnprocessable, Code: "unknown_menu_item", Message: "menu item not found"}
+	ErrInvalidQuantity     = &Error{Kind: ErrInvalid, Code: "invalid_quantity", Message: "quantity must be greater than 0"}
+	ErrNoItems             = &Error{Kind: ErrInvalid, Code: "no_items", Message: "order has no items"}
+	ErrInvalidModifiers    = &Error{Kind: ErrInvalid, Code: "invalid_modifiers", Message: "invalid modifiers"}
+	ErrInvalidBundle       = &Error{Kind: ErrInvalid, Code: "invalid_bundle", Message: "invalid bundle"}
+	ErrInvalidPromoCode    = &Error{Kind: ErrInvalid, Code: "invalid_promo_code", Message: "promo code is invalid or expired"}
+	ErrPromoCodeUsedUp     = &Error{Kind: ErrConflict, Code: "promo_code_used_up", Message: "promo code usage limit reached"}
+	ErrInsufficientStock   = &Error{Kind: ErrConflict, Code: "insufficient_stock", Message: "insufficient stock"}
+	ErrUnknownCustomer     = &Error{Kind: ErrUnprocessable, Code: "unknown_customer", Message: "customer not found"}
+	ErrInsufficientPoints  = &Error{Kind: ErrInvalid, Code: "insufficient_points", Message: "insufficient loyalty points"}
+	ErrInvalidRedeemAmount = &Error{Kind: ErrInvalid, Code: "invalid_redeem_amount", Message: "redeem_points cannot be negative"}
+	ErrUnknownCategory     = &Error{Kind: ErrUnprocessable, Code: "unknown_category", Message: "category not found"}
+	ErrUnknownAllergen     = &Error{Kind: ErrInvalid, Code: "unknown_allergen", Message: "unknown allergen"}
+	ErrInvalidUnit         = &Error{Kind: ErrInvalid, Code: "invalid_unit", Message: "invalid unit"}
+	ErrUnknownIngredient   = &Error{Kind: ErrUnprocessable, Code: "unknown_ingredient", Message: "unknown ingredient"}
+	ErrInvalidExpiry       = &Error{Kind: ErrInvalid, Code: "invalid_expiry", Message: "expires_on must be a date in YYYY-MM-DD format"}
+	ErrInvalidReceipt      = &Error{Kind: ErrInvalid, Code: "invalid_receipt", Message: "invalid receipt"}
+	ErrPurchaseOrderStatus = &Error{Kind: ErrConflict, Code: "purchase_order_status", Message: "purchase order status does not allow this"}
+	ErrUnknownSupplier     = &Error{Kind: ErrUnprocessable, Code: "unknown_supplier", Message: "supplier not found"}
+	ErrUnknownProduct      = &Error{Kind: ErrUnprocessable, Code: "unknown_product", Message: "menu product not found"}
+)
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// checkAllergens fails with ErrUnknownAllergen when any code is not in the registry.
+func (s *store) checkAllergens(codes []string) error {
+	var unknown []string
+	for _, code := range codes {
+		if _, ok := s.allergens[code]; !ok {
+			unknown = append(unknown, code)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", repository.ErrUnknownAllergen, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+type allergenRepo struct{ s *store }
+
+func (r *allergenRepo) List(ctx context.Context) ([]db.Allergen, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	allergens := make([]db.Allergen, 0, len(r.s.allergens))
+	for _, a := range r.s.allergens {
+		allergens = append(allergens, a)
+	}
+	sort.Slice(allergens, func(i, j int) bool { return allergens[i].Code < allergens[j].Code })
+	return allergens, nil
+}
+
+func (r *allergenRepo) Create(ctx context.Context, a db.Allergen) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.allergens[a.Code]; ok {
+		return repository.Conflict("Allergen %s already exists", a.Code)
+	}
+	r.s.allergens[a.Code] = a
+	return nil
+}
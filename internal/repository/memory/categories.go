@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// resolveCategory fills the name of a category given by id, or the id of a
+// category given by name. It fails with ErrUnknownCategory when there is no
+// such category and does nothing when neither is set.
+func (s *store) resolveCategory(id *int, name *string) error {
+	switch {
+	case *id != 0:
+		c, ok := s.categories[*id]
+		if !ok {
+			return fmt.Errorf("%w: %d", repository.ErrUnknownCategory, *id)
+		}
+		*name = c.Name
+	case *name != "":
+		c, ok := s.categoryByName(strings.TrimSpace(*name))
+		if !ok {
+			return fmt.Errorf("%w: %q", repository.ErrUnknownCategory, *name)
+		}
+		*id, *name = c.ID, c.Name
+	}
+	return nil
+}
+
+// categoryByName finds a category by its case-insensitive name.
+func (s *store) categoryByName(name string) (db.Category, bool) {
+	for _, c := range s.categories {
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+	return db.Category{}, false
+}
+
+// categoryTree returns the categories under parentID with their
+// subcategories, siblings ordered by sort_order.
+func (s *store) categoryTree(parentID int) []db.Category {
+	var children []db.Category
+	for _, c := range s.categories {
+		if c.ParentID == parentID {
+			c.Children = s.categoryTree(c.ID)
+			children = append(children, c)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].SortOrder != children[j].SortOrder {
+			return children[i].SortOrder < children[j].SortOrder
+		}
+		return children[i].ID < children[j].ID
+	})
+	return children
+}
+
+// validateCategoryParent checks that parentID exists and is not the category
+// itself or one of its subcategories.
+func (s *store) validateCategoryParent(id, parentID int) error {
+	if parentID == 0 {
+		return nil
+	}
+	if _, ok := s.categories[parentID]; !ok {
+		return fmt.Errorf("%w: parent %d", repository.ErrUnknownCategory, parentID)
+	}
+	if id != 0 && s.inCategory(parentID, id) {
+		return repository.Invalid("a category cannot be moved under itself or its subcategories")
+	}
+	return nil
+}
+
+type categoryRepo struct{ s *store }
+
+func (r *categoryRepo) Create(ctx context.Context, c db.Category) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := r.s.validateCategoryParent(0, c.ParentID); err != nil {
+		return 0, err
+	}
+	if _, ok := r.s.categoryByName(c.Name); ok {
+		return 0, repository.Conflict("Category %s already exists", c.Name)
+	}
+	r.s.lastCategoryID++
+	c.ID = r.s.lastCategoryID
+	c.Children = nil
+	r.s.categories[c.ID] = c
+	return c.ID, nil
+}
+
+// Tree returns the category tree in display order.
+func (r *categoryRepo) Tree(ctx context.Context) ([]db.Category, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return r.s.categoryTree(0), nil
+}
+
+// Get returns a category with its subcategories.
+func (r *categoryRepo) Get(ctx context.Context, id int) (db.Category, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.categories[id]
+	if !ok {
+		return db.Category{}, repository.NotFound("Category not found")
+	}
+	c.Children = r.s.categoryTree(id)
+	return c, nil
+}
+
+func (r *categoryRepo) Update(ctx context.Context, id int, c db.Category) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := r.s.validateCategoryParent(id, c.ParentID); err != nil {
+		return err
+	}
+	if other, ok := r.s.categoryByName(c.Name); ok && other.ID != id {
+		return repository.Conflict("Category %s already exists", c.Name)
+	}
+	if _, ok := r.s.categories[id]; !ok {
+		return repository.NotFound("Category not found")
+	}
+	c.ID = id
+	c.Children = nil
+	r.s.categories[id] = c
+	return nil
+}
+
+func (r *categoryRepo) Delete(ctx context.Context, id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.categories[id]; !ok {
+		return repository.NotFound("Category not found")
+	}
+	if r.s.categoryUsed(id) {
+		return repository.Conflict("Category is still used by subcategories, menu items or promotions")
+	}
+	delete(r.s.categories, id)
+	return nil
+}
+
+// categoryUsed reports whether a subcategory, product, menu item or
+// promotion refers to the category.
+func (s *store) categoryUsed(id int) bool {
+	for _, c := range s.categories {
+		if c.ParentID == id {
+			return true
+		}
+	}
+	for _, p := range s.products {
+		if p.CategoryID == id {
+			return true
+		}
+	}
+	for _, item := range s.menu {
+		if item.CategoryID == id {
+			return true
+		}
+	}
+	for _, p := range s.promotions {
+		if p.CategoryID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// errBatchTracking refuses batch tracking, which the store does not keep.
+var errBatchTracking = repository.Invalid("batch tracking is not supported by the in-memory store")
+
+// checkInventoryItem normalizes the stock unit and checks it, the purchase
+// unit, the allergens and the reorder policy of an item being written.
+func (s *store) checkInventoryItem(item *db.Inventory) error {
+	unit, err := s.unit(item.UnitType)
+	if err != nil {
+		return err
+	}
+	item.UnitType = unit.Code
+	if err := s.validatePurchaseUnit(item.UnitType, item.PurchaseUnit); err != nil {
+		return err
+	}
+	if err := s.checkAllergens(item.Allergens); err != nil {
+		return err
+	}
+	if item.Reorder != nil && item.Reorder.SupplierID != 0 {
+		return fmt.Errorf("%w: %d", repository.ErrUnknownSupplier, item.Reorder.SupplierID)
+	}
+	if item.BatchTracking != nil && item.BatchTracking.Enabled {
+		return errBatchTracking
+	}
+	return nil
+}
+
+// reorderPolicy returns the policy as it is stored: nothing without a par
+// level, since there are no suppliers.
+func reorderPolicy(p *db.ReorderPolicy) *db.ReorderPolicy {
+	if p == nil || p.ParLevel <= 0 {
+		return nil
+	}
+	return &db.ReorderPolicy{Level: p.Level, ParLevel: p.ParLevel}
+}
+
+// copyInventoryItem returns item with its own copies of the pointer and
+// slice fields, so callers cannot change the store.
+func copyInventoryItem(item db.Inventory) db.Inventory {
+	item.Allergens = append(make([]string, 0, len(item.Allergens)), item.Allergens...)
+	if item.Nutrition != nil {
+		n := *item.Nutrition
+		item.Nutrition = &n
+	}
+	if item.PurchaseUnit != nil {
+		p := *item.PurchaseUnit
+		item.PurchaseUnit = &p
+	}
+	if item.Reorder != nil {
+		p := *item.Reorder
+		item.Reorder = &p
+	}
+	item.BatchTracking, item.Batches = nil, nil
+	return item
+}
+
+type inventoryRepo struct{ s *store }
+
+// Create stores a new inventory item with its allergens and reorder policy,
+// and returns its id.
+func (r *inventoryRepo) Create(ctx context.Context, item db.Inventory) (string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := r.s.checkInventoryItem(&item); err != nil {
+		return "", err
+	}
+	if _, ok := r.s.inventory[item.ID]; ok {
+		return "", repository.Conflict("Inventory item %s already exists", item.ID)
+	}
+	if item.Nutrition == nil {
+		item.Nutrition = &db.Nutrition{}
+	}
+	item.Reorder = reorderPolicy(item.Reorder)
+	item.LastUpdated = time.Now()
+	r.s.inventory[item.ID] = copyInventoryItem(item)
+	return item.ID, nil
+}
+
+func (r *inventoryRepo) List(ctx context.Context) ([]db.Inventory, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var items []db.Inventory
+	for _, item := range r.s.inventory {
+		items = append(items, copyInventoryItem(item))
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+func (r *inventoryRepo) Get(ctx context.Context, id string) (db.Inventory, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	item, ok := r.s.inventory[id]
+	if !ok {
+		return db.Inventory{}, repository.NotFound("Inventory item not found")
+	}
+	return copyInventoryItem(item), nil
+}
+
+// Update replaces an inventory item. Nutrition, allergens and the reorder
+// policy are only replaced when they are set; kept reorder levels are
+// converted to a new stock unit.
+func (r *inventoryRepo) Update(ctx context.Context, id string, item db.Inventory) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := r.s.checkInventoryItem(&item); err != nil {
+		return err
+	}
+	current, ok := r.s.inventory[id]
+	if !ok {
+		return repository.NotFound("Inventory item not found")
+	}
+	if err := r.s.changeStockUnit(id, item.UnitType); err != nil {
+		return err
+	}
+	if current.Stock != 0 && r.s.units[current.UnitType].Dimension != r.s.units[item.UnitType].Dimension {
+		return repository.Conflict("the stock of ingredient %s is measured in %s, which cannot be converted to %s; write it off first",
+			id, current.UnitType, item.UnitType)
+	}
+	reorder := current.Reorder
+	if reorder != nil && current.UnitType != item.UnitType {
+		// The levels are kept in the stock unit, as in the database
+		level, err := r.s.convertQuantity(reorder.Level, current.UnitType, item.UnitType)
+		if err != nil {
+			return repository.Conflict("reorder levels of ingredient %s are kept in %s, which cannot be converted to %s",
+				id, current.UnitType, item.UnitType)
+		}
+		parLevel, _ := r.s.convertQuantity(reorder.ParLevel, current.UnitType, item.UnitType)
+		reorder = &db.ReorderPolicy{Level: level, ParLevel: parLevel}
+	}
+
+	item.ID = id
+	if item.Nutrition == nil {
+		item.Nutrition = current.Nutrition
+	}
+	if item.Allergens == nil {
+		item.Allergens = current.Allergens
+	}
+	if item.Reorder == nil {
+		item.Reorder = reorder
+	} else {
+		item.Reorder = reorderPolicy(item.Reorder)
+	}
+	item.LastUpdated = time.Now()
+	r.s.inventory[id] = copyInventoryItem(item)
+	return nil
+}
+
+// Delete removes an inventory item and, like the foreign keys in the
+// database, the recipe lines and modifier ingredients that use it.
+func (r *inventoryRepo) Delete(ctx context.Context, id string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.inventory[id]; !ok {
+		return repository.NotFound("Inventory item not found")
+	}
+	delete(r.s.inventory, id)
+	for productID, p := range r.s.products {
+		kept := p.Ingredients[:0:0]
+		for _, ing := range p.Ingredients {
+			if ing.IngredientID != id {
+				kept = append(kept, ing)
+			}
+		}
+		p.Ingredients = kept
+		r.s.products[productID] = p
+	}
+	for groupID, g := range r.s.modifiers {
+		g = modifierGroupView(g)
+		for i, m := range g.Modifiers {
+			kept := m.Ingredients[:0:0]
+			for _, ing := range m.Ingredients {
+				if ing.IngredientID != id {
+					kept = append(kept, ing)
+				}
+			}
+			g.Modifiers[i].Ingredients = kept
+		}
+		r.s.modifiers[groupID] = g
+	}
+	return nil
+}
+
+// Restock adds stock received for an inventory item, converting the amount
+// to the stock unit.
+func (r *inventoryRepo) Restock(ctx context.Context, id string, req db.Restock) (db.RestockResult, error) {
+	result := db.RestockResult{ID: id}
+	if req.ExpiresOn != "" {
+		if _, err := time.Parse("2006-01-02", req.ExpiresOn); err != nil {
+			return result, repository.ErrInvalidExpiry
+		}
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	item, ok := r.s.inventory[id]
+	if !ok {
+		return result, repository.NotFound("Inventory item not found")
+	}
+	amount, err := r.s.stockQuantity(id, req.Amount, req.Unit)
+	if err != nil {
+		return result, err
+	}
+	item.Stock += amount
+	item.LastUpdated = time.Now()
+	r.s.inventory[id] = item
+
+	result.Added = amount
+	result.Stock = item.Stock
+	result.UnitType = item.UnitType
+	return result, nil
+}
+
+// ExpiringBatches returns no batches, since no item is batch tracked.
+func (r *inventoryRepo) ExpiringBatches(ctx context.Context, days int) ([]db.InventoryBatch, error) {
+	return make([]db.InventoryBatch, 0), nil
+}
+
+func (r *inventoryRepo) WriteOffBatch(ctx context.Context, id int, quantity float64) (db.InventoryBatch, error) {
+	return db.InventoryBatch{}, repository.NotFound("Batch not found")
+}
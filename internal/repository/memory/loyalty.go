@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// loyaltyTotals returns the customer's point balance and collected punches.
+func (s *store) loyaltyTotals(customerID int) (points, punches int) {
+	for _, t := range s.loyalty {
+		if t.CustomerID == customerID {
+			points += t.Points
+			punches += t.Punches
+		}
+	}
+	return points, punches
+}
+
+type customerRepo struct{ s *store }
+
+func (r *customerRepo) Create(ctx context.Context, c db.Customer) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if c.Email != "" {
+		for _, other := range r.s.customers {
+			if other.Email == c.Email {
+				return 0, repository.Conflict("A customer with email %s already exists", c.Email)
+			}
+		}
+	}
+	r.s.lastCustomerID++
+	c.ID = r.s.lastCustomerID
+	c.Preferences = append(json.RawMessage(nil), c.Preferences...)
+	r.s.customers[c.ID] = c
+	return c.ID, nil
+}
+
+func (r *customerRepo) Get(ctx context.Context, id int) (db.Customer, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.customers[id]
+	if !ok {
+		return c, repository.NotFound("Customer not found")
+	}
+	c.Preferences = append(json.RawMessage(nil), c.Preferences...)
+	return c, nil
+}
+
+func (r *customerRepo) Loyalty(ctx context.Context, customerID int) (db.LoyaltyAccount, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	account := db.LoyaltyAccount{CustomerID: customerID, History: make([]db.LoyaltyTransaction, 0)}
+	if _, ok := r.s.customers[customerID]; !ok {
+		return account, repository.NotFound("Customer not found")
+	}
+	account.Balance, account.Punches = r.s.loyaltyTotals(customerID)
+
+	// Transactions are appended in order, so the newest come last
+	for i := len(r.s.loyalty) - 1; i >= 0; i-- {
+		t := r.s.loyalty[i]
+		if t.CustomerID != customerID {
+			continue
+		}
+		if t.OrderID != nil {
+			orderID := *t.OrderID
+			t.OrderID = &orderID
+		}
+		account.History = append(account.History, t.LoyaltyTransaction)
+	}
+	return account, nil
+}
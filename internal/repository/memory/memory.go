@@ -0,0 +1,208 @@
+// Package memory implements the repositories of orders, the menu, inventory
+// and customers in memory, with the categories, products, units, allergens,
+// modifier groups and promotions they depend on. It keeps the semantics of
+// package postgres: the same constraints, unique keys and cascading deletes,
+// and the same errors. Suppliers, purchase orders, stock takes and reports
+// are not implemented and are left nil. Bundles and batch tracking are
+// refused.
+//
+// The store starts empty apart from the units and allergens the initial
+// migration installs, and loses its data when the process exits.
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// New returns the repositories backed by a new, empty store.
+func New() *repository.Repositories {
+	s := &store{
+		units:      make(map[string]db.Unit),
+		allergens:  make(map[string]db.Allergen),
+		categories: make(map[int]db.Category),
+		products:   make(map[int]db.MenuProduct),
+		menu:       make(map[string]db.MenuItem),
+		inventory:  make(map[string]db.Inventory),
+		customers:  make(map[int]db.Customer),
+		orders:     make(map[int]*order),
+		modifiers:  make(map[int]db.ModifierGroup),
+		promotions: make(map[int]db.Promotion),
+	}
+	for _, u := range defaultUnits {
+		s.units[u.Code] = u
+	}
+	for _, a := range defaultAllergens {
+		s.allergens[a.Code] = a
+	}
+	return &repository.Repositories{
+		Orders:     &orderRepo{s: s},
+		Menu:       &menuRepo{s: s},
+		Inventory:  &inventoryRepo{s: s},
+		Customers:  &customerRepo{s: s},
+		Categories: &categoryRepo{s: s},
+		Products:   &productRepo{s: s},
+		Modifiers:  &modifierRepo{s: s},
+		Promotions: &promotionRepo{s: s},
+		Allergens:  &allergenRepo{s: s},
+		Units:      &unitRepo{s: s},
+	}
+}
+
+// defaultUnits and defaultAllergens are the registries the initial
+// migration fills in.
+var defaultUnits = []db.Unit{
+	{Code: "g", Name: "Gram", Dimension: "mass", Factor: 1},
+	{Code: "kg", Name: "Kilogram", Dimension: "mass", Factor: 1000},
+	{Code: "ml", Name: "Millilitre", Dimension: "volume", Factor: 1},
+	{Code: "l", Name: "Litre", Dimension: "volume", Factor: 1000},
+	{Code: "piece", Name: "Piece", Dimension: "count", Factor: 1},
+	{Code: "dozen", Name: "Dozen", Dimension: "count", Factor: 12},
+	{Code: "loaf", Name: "Loaf", Dimension: "loaf", Factor: 1},
+}
+
+var defaultAllergens = []db.Allergen{
+	{Code: "gluten", Name: "Cereals containing gluten"},
+	{Code: "crustaceans", Name: "Crustaceans"},
+	{Code: "eggs", Name: "Eggs"},
+	{Code: "fish", Name: "Fish"},
+	{Code: "peanuts", Name: "Peanuts"},
+	{Code: "soybeans", Name: "Soybeans"},
+	{Code: "milk", Name: "Milk"},
+	{Code: "nuts", Name: "Tree nuts"},
+	{Code: "celery", Name: "Celery"},
+	{Code: "mustard", Name: "Mustard"},
+	{Code: "sesame", Name: "Sesame seeds"},
+	{Code: "sulphites", Name: "Sulphur dioxide and sulphites"},
+	{Code: "lupin", Name: "Lupin"},
+	{Code: "molluscs", Name: "Molluscs"},
+}
+
+// store holds all data behind a single lock; every repository method runs
+// under it, which makes each call atomic like a transaction.
+type store struct {
+	mu sync.Mutex
+
+	units      map[string]db.Unit
+	allergens  map[string]db.Allergen
+	categories map[int]db.Category     // without Children
+	products   map[int]db.MenuProduct  // recipe in Ingredients, without Variants
+	menu       map[string]db.MenuItem  // stored columns only
+	inventory  map[string]db.Inventory // without Batches
+	customers  map[int]db.Customer
+	orders     map[int]*order
+	loyalty    []loyaltyEntry
+	modifiers  map[int]db.ModifierGroup // with MenuItemIDs and ingredient units set
+	promotions map[int]db.Promotion     // without the Category name
+	applied    []appliedPromotion
+
+	lastCategoryID, lastProductID, lastCustomerID, lastOrderID, lastLoyaltyID int
+	lastModifierGroupID, lastModifierID, lastPromotionID                      int
+}
+
+// order is a stored order with its lines.
+type order struct {
+	db.Order
+	Lines []orderLine
+}
+
+// orderLine is a stored line of an order.
+type orderLine struct {
+	MenuItemID string
+	Quantity   int
+	UnitPrice  float64
+}
+
+// appliedPromotion is a promotion applied to an order.
+type appliedPromotion struct {
+	OrderID        int
+	PromotionID    int
+	DiscountAmount float64
+}
+
+// loyaltyEntry is a loyalty transaction of a customer.
+type loyaltyEntry struct {
+	CustomerID int
+	db.LoyaltyTransaction
+}
+
+var (
+	itemSizes      = []string{"small", "medium", "large"}
+	orderStatuses  = []string{"open", "closed"}
+	paymentMethods = []string{"cash", "card", "kaspi_qr"}
+)
+
+// checkEnum fails with ErrInvalid unless v is one of the values of the
+// database enum.
+func checkEnum(field, v string, values []string) error {
+	for _, allowed := range values {
+		if v == allowed {
+			return nil
+		}
+	}
+	return &repository.Error{
+		Kind:    repository.ErrInvalid,
+		Code:    "invalid_value",
+		Message: fmt.Sprintf("%q is not a valid %s", v, field),
+		Fields:  []repository.FieldError{{Field: field, Message: "must be one of " + strings.Join(values, ", ")}},
+	}
+}
+
+// sizeRank orders sizes the way the item_size enum does.
+func sizeRank(size string) int {
+	for i, s := range itemSizes {
+		if s == size {
+			return i
+		}
+	}
+	return len(itemSizes)
+}
+
+// categoryNames returns the names of the category and its parents, the root
+// first, like the ancestor_names of the category_tree view.
+func (s *store) categoryNames(id int) []string {
+	var names []string
+	for _, c := range s.categoryPath(id) {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// categoryPath returns the category and its parents, the root first.
+func (s *store) categoryPath(id int) []db.Category {
+	var path []db.Category
+	for c, ok := s.categories[id]; ok; c, ok = s.categories[c.ParentID] {
+		path = append([]db.Category{c}, path...)
+	}
+	return path
+}
+
+// inCategory reports whether category id is rootID or one of its
+// subcategories.
+func (s *store) inCategory(id, rootID int) bool {
+	for _, c := range s.categoryPath(id) {
+		if c.ID == rootID {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryBefore orders categories depth-first by sort order, like the
+// sort_path of the category_tree view.
+func (s *store) categoryBefore(a, b int) bool {
+	pa, pb := s.categoryPath(a), s.categoryPath(b)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i].SortOrder != pb[i].SortOrder {
+			return pa[i].SortOrder < pb[i].SortOrder
+		}
+		if pa[i].ID != pb[i].ID {
+			return pa[i].ID < pb[i].ID
+		}
+	}
+	return len(pa) < len(pb)
+}
@@ -0,0 +1,252 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/service"
+)
+
+// newTestStore returns a store with a customer, a latte with an oat milk
+// modifier, a 10% promotion on coffee and a code for 1.00 off.
+func newTestStore(t *testing.T) *repository.Repositories {
+	t.Helper()
+	ctx := context.Background()
+	repos := New()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := repos.Customers.Create(ctx, db.Customer{Name: "Aigerim", Email: "aigerim@example.com"})
+	must(err)
+	coffee, err := repos.Categories.Create(ctx, db.Category{Name: "Coffee"})
+	must(err)
+	for _, item := range []db.Inventory{
+		{ID: "milk", Name: "Milk", Stock: 1, Price: 1, UnitType: "l"},
+		{ID: "oat_milk", Name: "Oat milk", Stock: 1000, Price: 0.01, UnitType: "ml"},
+		{ID: "espresso", Name: "Espresso beans", Stock: 100, Price: 0.05, UnitType: "g"},
+	} {
+		_, err := repos.Inventory.Create(ctx, item)
+		must(err)
+	}
+	latte, err := repos.Products.Create(ctx, db.MenuProduct{Name: "Latte", CategoryID: coffee, Ingredients: []db.RecipeIngredient{
+		{IngredientID: "milk", Quantity: 200, Unit: "ml"},
+		{IngredientID: "espresso", Quantity: 18},
+	}})
+	must(err)
+	_, err = repos.Menu.Create(ctx, db.MenuItem{ID: "latte", ProductID: latte, Price: 3, Size: "medium", RecipeScale: 1})
+	must(err)
+	_, err = repos.Modifiers.Create(ctx, db.ModifierGroup{
+		Name: "Milk", MaxSelections: 1, MenuItemIDs: []string{"latte"},
+		Modifiers: []db.Modifier{{Name: "Oat milk", PriceDelta: 0.5, Ingredients: []db.ModifierIngredient{
+			{IngredientID: "milk", QuantityDelta: -0.2, Unit: "l"},
+			{IngredientID: "oat_milk", QuantityDelta: 200},
+		}}},
+	})
+	must(err)
+	_, err = repos.Promotions.Create(ctx, db.Promotion{Name: "Coffee week", DiscountType: "percentage", Value: 10, CategoryID: coffee, IsActive: true})
+	must(err)
+	_, err = repos.Promotions.Create(ctx, db.Promotion{Name: "Welcome", DiscountType: "fixed", Value: 1, Code: "WELCOME", UsageLimit: 1, IsActive: true})
+	must(err)
+	return repos
+}
+
+func stock(t *testing.T, repos *repository.Repositories, id string) float64 {
+	t.Helper()
+	item, err := repos.Inventory.Get(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return item.Stock
+}
+
+func TestPlaceOrder(t *testing.T) {
+	ctx := context.Background()
+	repos := newTestStore(t)
+	orders := service.NewOrders(repos.Orders, time.UTC)
+
+	groups, err := repos.Modifiers.List(ctx, "latte")
+	if err != nil || len(groups) != 1 || len(groups[0].Modifiers) != 1 {
+		t.Fatalf("modifier groups of latte = %+v, %v", groups, err)
+	}
+	oat := groups[0].Modifiers[0].ID
+
+	receipt, err := orders.Create(ctx, db.Order{
+		CustomerID: 1, PaymentMethod: "card", PromoCode: "welcome",
+		Items: []db.OrderItem{{MenuItemID: "latte", Quantity: 2, Modifiers: []int{oat}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 7.00 less 0.70 off coffee and 1.00 off with the code
+	if receipt.TotalAmount != 5.3 || receipt.DiscountAmount != 1.7 || len(receipt.AppliedPromotions) != 2 {
+		t.Errorf("receipt = %+v", receipt)
+	}
+	if milk, oat := stock(t, repos, "milk"), stock(t, repos, "oat_milk"); milk != 1 || oat != 600 {
+		t.Errorf("stock = %v l milk and %v ml oat milk, want 1 and 600", milk, oat)
+	}
+
+	o, err := orders.Get(ctx, receipt.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(o.AppliedPromotions) != 2 || o.AppliedPromotions[1].Code != "WELCOME" {
+		t.Errorf("applied promotions = %+v", o.AppliedPromotions)
+	}
+	if p, _ := repos.Promotions.Get(ctx, 2); p.TimesUsed != 1 {
+		t.Errorf("code used %d times, want 1", p.TimesUsed)
+	}
+
+	_, err = orders.Create(ctx, db.Order{
+		CustomerID: 1, PaymentMethod: "card", PromoCode: "WELCOME",
+		Items: []db.OrderItem{{MenuItemID: "latte", Quantity: 1}},
+	})
+	if !errors.Is(err, repository.ErrPromoCodeUsedUp) {
+		t.Errorf("reusing the code: err = %v, want ErrPromoCodeUsedUp", err)
+	}
+}
+
+func TestPlaceOrderRollsBack(t *testing.T) {
+	ctx := context.Background()
+	repos := newTestStore(t)
+	orders := service.NewOrders(repos.Orders, time.UTC)
+
+	// Six lattes need 1.2 l of milk
+	_, err := orders.Create(ctx, db.Order{
+		CustomerID: 1, PaymentMethod: "cash", PromoCode: "WELCOME",
+		Items: []db.OrderItem{{MenuItemID: "latte", Quantity: 6}},
+	})
+	if !errors.Is(err, repository.ErrInsufficientStock) {
+		t.Fatalf("err = %v, want ErrInsufficientStock", err)
+	}
+	if list, _ := orders.List(ctx); len(list) != 0 {
+		t.Errorf("orders = %+v, want none", list)
+	}
+	if espresso := stock(t, repos, "espresso"); espresso != 100 {
+		t.Errorf("espresso stock = %v, want 100", espresso)
+	}
+	if p, _ := repos.Promotions.Get(ctx, 2); p.TimesUsed != 0 {
+		t.Errorf("code used %d times, want 0", p.TimesUsed)
+	}
+}
+
+func TestCustomers(t *testing.T) {
+	ctx := context.Background()
+	repos := newTestStore(t)
+
+	_, err := repos.Customers.Create(ctx, db.Customer{Name: "Someone", Email: "aigerim@example.com"})
+	if !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("taken email: err = %v, want a conflict", err)
+	}
+	id, err := repos.Customers.Create(ctx, db.Customer{Name: "Aigerim"})
+	if err != nil || id != 2 {
+		t.Fatalf("id = %d, err = %v; want 2", id, err)
+	}
+	if c, err := repos.Customers.Get(ctx, 2); err != nil || c.Name != "Aigerim" {
+		t.Errorf("customer 2 = %+v, %v", c, err)
+	}
+	if _, err := repos.Customers.Get(ctx, 3); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("missing customer: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	ctx := context.Background()
+	repos := newTestStore(t)
+
+	for name, err := range map[string]error{
+		"menu item name and size": func() error {
+			_, err := repos.Menu.Create(ctx, db.MenuItem{ID: "latte2", ProductID: 1, Price: 3, Size: "medium"})
+			return err
+		}(),
+		"modifier group name": func() error {
+			_, err := repos.Modifiers.Create(ctx, db.ModifierGroup{Name: "Milk", MaxSelections: 1, Modifiers: []db.Modifier{{Name: "Soy"}}})
+			return err
+		}(),
+		"modifier name": func() error {
+			_, err := repos.Modifiers.Create(ctx, db.ModifierGroup{Name: "Syrup", MaxSelections: 1, Modifiers: []db.Modifier{{Name: "Vanilla"}, {Name: "Vanilla"}}})
+			return err
+		}(),
+		"promo code": func() error {
+			_, err := repos.Promotions.Create(ctx, db.Promotion{Name: "Again", DiscountType: "fixed", Value: 1, Code: " WELCOME "})
+			return err
+		}(),
+		"category of a promotion": repos.Categories.Delete(ctx, 1),
+	} {
+		if !errors.Is(err, repository.ErrConflict) {
+			t.Errorf("%s: err = %v, want a conflict", name, err)
+		}
+	}
+}
+
+func TestDeleteCascades(t *testing.T) {
+	ctx := context.Background()
+	repos := newTestStore(t)
+	orders := service.NewOrders(repos.Orders, time.UTC)
+
+	receipt, err := orders.Create(ctx, db.Order{
+		CustomerID: 1, PaymentMethod: "cash",
+		Items: []db.OrderItem{{MenuItemID: "latte", Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	promo, err := repos.Promotions.Create(ctx, db.Promotion{Name: "Latte day", DiscountType: "fixed", Value: 1, MenuItemID: "latte", IsActive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repos.Inventory.Delete(ctx, "oat_milk"); err != nil {
+		t.Fatal(err)
+	}
+	groups, _ := repos.Modifiers.List(ctx, "")
+	if ings := groups[0].Modifiers[0].Ingredients; len(ings) != 1 || ings[0].IngredientID != "milk" {
+		t.Errorf("oat milk modifier ingredients = %+v, want only milk", ings)
+	}
+
+	if err := repos.Menu.Delete(ctx, "latte"); err != nil {
+		t.Fatal(err)
+	}
+	if groups, _ := repos.Modifiers.List(ctx, ""); len(groups[0].MenuItemIDs) != 0 {
+		t.Errorf("modifier group still offered on %v", groups[0].MenuItemIDs)
+	}
+	if _, err := repos.Promotions.Get(ctx, promo); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("promotion on the deleted item: err = %v, want ErrNotFound", err)
+	}
+
+	if err := repos.Promotions.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	o, err := orders.Get(ctx, receipt.OrderID)
+	if err != nil || len(o.AppliedPromotions) != 0 {
+		t.Errorf("order = %+v, %v; want no applied promotions", o, err)
+	}
+}
+
+func TestChangeStockUnit(t *testing.T) {
+	ctx := context.Background()
+	repos := newTestStore(t)
+
+	// Only the oat milk modifier measures oat milk, in ml
+	if err := repos.Inventory.Update(ctx, "oat_milk", db.Inventory{Name: "Oat milk", Price: 0.01, UnitType: "ml"}); err != nil {
+		t.Fatal(err)
+	}
+	err := repos.Inventory.Update(ctx, "oat_milk", db.Inventory{Name: "Oat milk", Price: 0.01, UnitType: "g"})
+	if !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("unit of another dimension: err = %v, want a conflict", err)
+	}
+	if err := repos.Inventory.Update(ctx, "oat_milk", db.Inventory{Name: "Oat milk", Stock: 1, Price: 10, UnitType: "l"}); err != nil {
+		t.Fatal(err)
+	}
+	groups, _ := repos.Modifiers.List(ctx, "")
+	if ing := groups[0].Modifiers[0].Ingredients[1]; ing.QuantityDelta != 200 || ing.Unit != "ml" {
+		t.Errorf("oat milk in the modifier = %+v, want 200 ml", ing)
+	}
+}
@@ -0,0 +1,377 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// errBundle refuses bundle components, which the store does not keep.
+var errBundle = repository.Invalid("bundles are not supported by the in-memory store")
+
+// recipe returns the effective recipe of a menu item in stock units: the
+// product recipe scaled to the variant. Lines that cannot be converted to the
+// stock unit are left out and the first such error is returned with the rest.
+func (s *store) recipe(item db.MenuItem) (map[string]float64, error) {
+	recipe := make(map[string]float64)
+	var firstErr error
+	for _, ing := range s.products[item.ProductID].Ingredients {
+		quantity, err := s.stockQuantity(ing.IngredientID, ing.Quantity, ing.Unit)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		recipe[ing.IngredientID] += quantity * item.RecipeScale
+	}
+	return recipe, firstErr
+}
+
+// describeMenuItem fills the derived fields of a stored menu item: the
+// category name and the allergens of its recipe.
+func (s *store) describeMenuItem(item db.MenuItem) db.MenuItem {
+	item.Category = s.categories[item.CategoryID].Name
+	seen := make(map[string]bool)
+	item.Allergens = make([]string, 0)
+	recipe, _ := s.recipe(item)
+	for id := range recipe {
+		for _, code := range s.inventory[id].Allergens {
+			if !seen[code] {
+				seen[code] = true
+				item.Allergens = append(item.Allergens, code)
+			}
+		}
+	}
+	sort.Strings(item.Allergens)
+	return item
+}
+
+// menuItemNutrition computes the nutrition of a menu item from its recipe.
+func (s *store) menuItemNutrition(item db.MenuItem) db.Nutrition {
+	var n db.Nutrition
+	recipe, _ := s.recipe(item)
+	for id, quantity := range recipe {
+		if per := s.inventory[id].Nutrition; per != nil {
+			n.Calories += quantity * per.Calories
+			n.Protein += quantity * per.Protein
+			n.Carbohydrates += quantity * per.Carbohydrates
+			n.Fat += quantity * per.Fat
+		}
+	}
+	round := func(v float64) float64 { return math.Round(v*10) / 10 }
+	n.Calories = math.Round(n.Calories)
+	n.Protein = round(n.Protein)
+	n.Carbohydrates = round(n.Carbohydrates)
+	n.Fat = round(n.Fat)
+	return n
+}
+
+// sortedMenu returns the stored menu items in menu order: by category, then
+// by name and size.
+func (s *store) sortedMenu() []db.MenuItem {
+	items := make([]db.MenuItem, 0, len(s.menu))
+	for _, item := range s.menu {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.CategoryID != b.CategoryID {
+			return s.categoryBefore(a.CategoryID, b.CategoryID)
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return sizeRank(a.Size) < sizeRank(b.Size)
+	})
+	return items
+}
+
+// resolveMenuProduct fills the product of a menu item. Without a product_id the
+// product with the item's name is used, and created if it does not exist yet:
+// the new product is returned for the caller to store once the item is
+// accepted. Name, description and category default to the product's.
+func (s *store) resolveMenuProduct(item *db.MenuItem) (*db.MenuProduct, error) {
+	if item.ProductID == 0 {
+		if item.Name == "" {
+			return nil, repository.Invalid("name is required")
+		}
+		if item.CategoryID == 0 {
+			return nil, repository.Invalid("category is required")
+		}
+		if p, ok := s.productByName(item.Name); ok {
+			item.ProductID = p.ID
+			return nil, nil
+		}
+		item.ProductID = s.lastProductID + 1
+		return &db.MenuProduct{
+			ID:          item.ProductID,
+			Name:        item.Name,
+			Description: item.Description,
+			CategoryID:  item.CategoryID,
+		}, nil
+	}
+
+	p, ok := s.products[item.ProductID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", repository.ErrUnknownProduct, item.ProductID)
+	}
+	if item.Name == "" {
+		item.Name = p.Name
+	}
+	if item.Description == "" {
+		item.Description = p.Description
+	}
+	if item.CategoryID == 0 {
+		item.CategoryID = p.CategoryID
+	}
+	return nil, nil
+}
+
+// checkMenuItemKeys enforces the unique keys of menu items: the id, the name
+// and size, and the product and size.
+func (s *store) checkMenuItemKeys(id string, item db.MenuItem, creating bool) error {
+	for otherID, other := range s.menu {
+		if otherID == id {
+			if creating {
+				return errMenuItemExists
+			}
+			continue
+		}
+		if other.Size == item.Size && (other.Name == item.Name || other.ProductID == item.ProductID) {
+			return errMenuItemExists
+		}
+	}
+	return nil
+}
+
+var errMenuItemExists = repository.Conflict("A menu item with this ID, or with this name and size, already exists")
+
+type menuRepo struct{ s *store }
+
+// List returns the menu as products with their size variants, in category
+// display order.
+func (r *menuRepo) List(ctx context.Context, f repository.MenuFilter) ([]db.MenuProduct, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := r.s.checkAllergens(f.ExcludeAllergens); err != nil {
+		return nil, err
+	}
+	excluded := make(map[string]bool, len(f.ExcludeAllergens))
+	for _, code := range f.ExcludeAllergens {
+		excluded[code] = true
+	}
+
+	var items []db.MenuItem
+	for _, item := range r.s.sortedMenu() {
+		if f.CategoryID != 0 && !r.s.inCategory(item.CategoryID, f.CategoryID) {
+			continue
+		}
+		item = r.s.describeMenuItem(item)
+		contains := false
+		for _, code := range item.Allergens {
+			contains = contains || excluded[code]
+		}
+		if !contains {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.CategoryID != b.CategoryID {
+			return r.s.categoryBefore(a.CategoryID, b.CategoryID)
+		}
+		pa, pb := r.s.products[a.ProductID], r.s.products[b.ProductID]
+		if pa.Name != pb.Name {
+			return pa.Name < pb.Name
+		}
+		if pa.ID != pb.ID {
+			return pa.ID < pb.ID
+		}
+		return sizeRank(a.Size) < sizeRank(b.Size)
+	})
+
+	products := make([]db.MenuProduct, 0)
+	for _, item := range items {
+		if n := len(products); n == 0 || products[n-1].ID != item.ProductID {
+			p := r.s.products[item.ProductID]
+			products = append(products, db.MenuProduct{
+				ID:          p.ID,
+				Name:        p.Name,
+				Description: p.Description,
+				CategoryID:  p.CategoryID,
+				Category:    r.s.categories[p.CategoryID].Name,
+				Variants:    make([]db.MenuItem, 0, 3),
+			})
+		}
+		last := &products[len(products)-1]
+		last.Variants = append(last.Variants, item)
+	}
+	return products, nil
+}
+
+// Get returns a menu item with its nutrition.
+func (r *menuRepo) Get(ctx context.Context, id string) (db.MenuItem, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	item, ok := r.s.menu[id]
+	if !ok {
+		return item, repository.NotFound("Menu item not found")
+	}
+	item = r.s.describeMenuItem(item)
+	nutrition := r.s.menuItemNutrition(item)
+	item.Nutrition = &nutrition
+	return item, nil
+}
+
+// Create stores a menu item. The product is resolved as described at
+// resolveMenuProduct.
+func (r *menuRepo) Create(ctx context.Context, item db.MenuItem) (string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if len(item.Components) > 0 {
+		return "", errBundle
+	}
+	if err := r.s.resolveCategory(&item.CategoryID, &item.Category); err != nil {
+		return "", err
+	}
+	if err := checkEnum("size", item.Size, itemSizes); err != nil {
+		return "", err
+	}
+	product, err := r.s.resolveMenuProduct(&item)
+	if err != nil {
+		return "", err
+	}
+	if err := r.s.checkMenuItemKeys(item.ID, item, true); err != nil {
+		return "", err
+	}
+	if product != nil {
+		r.s.lastProductID = product.ID
+		r.s.products[product.ID] = *product
+	}
+	r.s.menu[item.ID] = storedMenuItem(item)
+	return item.ID, nil
+}
+
+// Update replaces a menu item. product_id and recipe_scale keep their
+// current values when zero. Bundle components are refused.
+func (r *menuRepo) Update(ctx context.Context, id string, item db.MenuItem) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if len(item.Components) > 0 {
+		return errBundle
+	}
+	if err := r.s.resolveCategory(&item.CategoryID, &item.Category); err != nil {
+		return err
+	}
+	current, ok := r.s.menu[id]
+	if !ok {
+		return repository.NotFound("Menu item not found")
+	}
+	if err := checkEnum("size", item.Size, itemSizes); err != nil {
+		return err
+	}
+	if item.ProductID == 0 {
+		item.ProductID = current.ProductID
+	} else if _, ok := r.s.products[item.ProductID]; !ok {
+		return fmt.Errorf("%w: %d", repository.ErrUnknownProduct, item.ProductID)
+	}
+	if item.RecipeScale == 0 {
+		item.RecipeScale = current.RecipeScale
+	}
+	if err := r.s.checkMenuItemKeys(id, item, false); err != nil {
+		return err
+	}
+	item.ID = id
+	r.s.menu[id] = storedMenuItem(item)
+	return nil
+}
+
+// Delete removes a menu item and, like the foreign keys in the database, the
+// order lines that sold it, its promotions and its place in modifier groups.
+// The database refuses to delete a bundle component; the store keeps no
+// bundles, so nothing stops the delete here.
+func (r *menuRepo) Delete(ctx context.Context, id string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.menu[id]; !ok {
+		return repository.NotFound("Menu item not found")
+	}
+	delete(r.s.menu, id)
+	for _, o := range r.s.orders {
+		kept := o.Lines[:0:0]
+		for _, l := range o.Lines {
+			if l.MenuItemID != id {
+				kept = append(kept, l)
+			}
+		}
+		o.Lines = kept
+	}
+	for groupID, g := range r.s.modifiers {
+		kept := g.MenuItemIDs[:0:0]
+		for _, menuItemID := range g.MenuItemIDs {
+			if menuItemID != id {
+				kept = append(kept, menuItemID)
+			}
+		}
+		g.MenuItemIDs = kept
+		r.s.modifiers[groupID] = g
+	}
+	for promotionID, p := range r.s.promotions {
+		if p.MenuItemID == id {
+			r.s.deletePromotion(promotionID)
+		}
+	}
+	return nil
+}
+
+// Labels returns every menu item with its category path, allergens and
+// nutrition, in menu order.
+func (r *menuRepo) Labels(ctx context.Context) ([]db.MenuLabel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var labels []db.MenuLabel
+	for _, item := range r.s.sortedMenu() {
+		item = r.s.describeMenuItem(item)
+		var path []string
+		for _, c := range r.s.categoryPath(item.CategoryID) {
+			path = append(path, c.Name)
+		}
+		labels = append(labels, db.MenuLabel{
+			Category:  strings.Join(path, " > "),
+			ID:        item.ID,
+			Name:      item.Name,
+			Size:      item.Size,
+			Price:     item.Price,
+			Allergens: item.Allergens,
+			Nutrition: r.s.menuItemNutrition(item),
+		})
+	}
+	return labels, nil
+}
+
+// storedMenuItem keeps the columns of a menu item that are stored; the rest
+// is derived when it is read.
+func storedMenuItem(item db.MenuItem) db.MenuItem {
+	return db.MenuItem{
+		ID:          item.ID,
+		ProductID:   item.ProductID,
+		Name:        item.Name,
+		Description: item.Description,
+		Price:       item.Price,
+		CategoryID:  item.CategoryID,
+		Size:        item.Size,
+		RecipeScale: item.RecipeScale,
+	}
+}
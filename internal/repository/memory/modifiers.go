@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// checkModifierGroup enforces the constraints on a modifier group that is
+// saved as id (0 for a new one) and returns it as it is stored: modifiers
+// with the units of their ingredients set, and the menu items it is offered
+// on sorted and without duplicates. Modifiers keeping their id must already
+// belong to the group.
+func (s *store) checkModifierGroup(id int, g db.ModifierGroup) (db.ModifierGroup, error) {
+	for _, other := range s.modifiers {
+		if other.ID != id && other.Name == g.Name {
+			return g, repository.Conflict("Modifier group %s already exists", g.Name)
+		}
+	}
+	own := make(map[int]bool)
+	for _, m := range s.modifiers[id].Modifiers {
+		own[m.ID] = true
+	}
+
+	stored := db.ModifierGroup{ID: id, Name: g.Name, MinSelections: g.MinSelections, MaxSelections: g.MaxSelections}
+	names := make(map[string]bool)
+	for _, m := range g.Modifiers {
+		if m.ID != 0 && !own[m.ID] {
+			return g, fmt.Errorf("%w: modifier %d does not belong to this group", repository.ErrInvalidModifiers, m.ID)
+		}
+		if names[m.Name] {
+			return g, repository.Conflict("Modifier %s already exists in this group", m.Name)
+		}
+		names[m.Name] = true
+
+		ingredients := make([]db.ModifierIngredient, 0, len(m.Ingredients))
+		seen := make(map[string]bool)
+		for _, ing := range m.Ingredients {
+			unit, err := s.recipeUnit(ing.IngredientID, ing.Unit)
+			if err != nil {
+				return g, err
+			}
+			if seen[ing.IngredientID] {
+				return g, repository.Conflict("Ingredient %s is changed twice by modifier %s", ing.IngredientID, m.Name)
+			}
+			seen[ing.IngredientID] = true
+			ingredients = append(ingredients, db.ModifierIngredient{IngredientID: ing.IngredientID, QuantityDelta: ing.QuantityDelta, Unit: unit})
+		}
+		sort.Slice(ingredients, func(i, j int) bool { return ingredients[i].IngredientID < ingredients[j].IngredientID })
+		m.Ingredients = ingredients
+		stored.Modifiers = append(stored.Modifiers, m)
+	}
+
+	seen := make(map[string]bool)
+	for _, menuItemID := range g.MenuItemIDs {
+		if _, ok := s.menu[menuItemID]; !ok {
+			return g, fmt.Errorf("%w: %s", repository.ErrUnknownMenuItem, menuItemID)
+		}
+		if !seen[menuItemID] {
+			seen[menuItemID] = true
+			stored.MenuItemIDs = append(stored.MenuItemIDs, menuItemID)
+		}
+	}
+	sort.Strings(stored.MenuItemIDs)
+	return stored, nil
+}
+
+// saveModifierGroup stores a checked group, giving new modifiers an id.
+func (s *store) saveModifierGroup(g db.ModifierGroup) {
+	for i := range g.Modifiers {
+		if g.Modifiers[i].ID == 0 {
+			s.lastModifierID++
+			g.Modifiers[i].ID = s.lastModifierID
+		}
+	}
+	sort.Slice(g.Modifiers, func(i, j int) bool { return g.Modifiers[i].ID < g.Modifiers[j].ID })
+	s.modifiers[g.ID] = g
+}
+
+// modifierGroupView returns a copy of a stored group that the caller may change.
+func modifierGroupView(g db.ModifierGroup) db.ModifierGroup {
+	var v db.ModifierGroup
+	data, _ := json.Marshal(g)
+	json.Unmarshal(data, &v)
+	if v.Modifiers == nil {
+		v.Modifiers = make([]db.Modifier, 0)
+	}
+	return v
+}
+
+// saleModifierGroups returns the groups offered on a menu item with the
+// ingredient deltas of their modifiers in stock units.
+func (s *store) saleModifierGroups(menuItemID string) ([]db.ModifierGroup, error) {
+	var groups []db.ModifierGroup
+	for _, g := range s.sortedModifierGroups() {
+		offered := false
+		for _, id := range g.MenuItemIDs {
+			offered = offered || id == menuItemID
+		}
+		if !offered {
+			continue
+		}
+		g = modifierGroupView(g)
+		for i := range g.Modifiers {
+			for j := range g.Modifiers[i].Ingredients {
+				ing := &g.Modifiers[i].Ingredients[j]
+				delta, err := s.stockQuantity(ing.IngredientID, ing.QuantityDelta, ing.Unit)
+				if err != nil {
+					return nil, err
+				}
+				ing.QuantityDelta, ing.Unit = delta, ""
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+func (s *store) sortedModifierGroups() []db.ModifierGroup {
+	groups := make([]db.ModifierGroup, 0, len(s.modifiers))
+	for _, g := range s.modifiers {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return groups
+}
+
+type modifierRepo struct{ s *store }
+
+func (r *modifierRepo) Create(ctx context.Context, g db.ModifierGroup) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, err := r.s.checkModifierGroup(0, g)
+	if err != nil {
+		return 0, err
+	}
+	r.s.lastModifierGroupID++
+	stored.ID = r.s.lastModifierGroupID
+	r.s.saveModifierGroup(stored)
+	return stored.ID, nil
+}
+
+// List returns every modifier group, or only the ones offered on menuItemID
+// when it is set.
+func (r *modifierRepo) List(ctx context.Context, menuItemID string) ([]db.ModifierGroup, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var groups []db.ModifierGroup
+	for _, g := range r.s.sortedModifierGroups() {
+		if menuItemID != "" {
+			offered := false
+			for _, id := range g.MenuItemIDs {
+				offered = offered || id == menuItemID
+			}
+			if !offered {
+				continue
+			}
+		}
+		g = modifierGroupView(g)
+		if menuItemID != "" {
+			g.MenuItemIDs = nil
+		} else if g.MenuItemIDs == nil {
+			g.MenuItemIDs = make([]string, 0)
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+func (r *modifierRepo) Update(ctx context.Context, id int, g db.ModifierGroup) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.modifiers[id]; !ok {
+		return repository.NotFound("Modifier group not found")
+	}
+	stored, err := r.s.checkModifierGroup(id, g)
+	if err != nil {
+		return err
+	}
+	r.s.saveModifierGroup(stored)
+	return nil
+}
+
+// Delete removes a modifier group with its modifiers. Orders keep the name
+// and price of the modifiers they were sold with.
+func (r *modifierRepo) Delete(ctx context.Context, id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.modifiers[id]; !ok {
+		return repository.NotFound("Modifier group not found")
+	}
+	delete(r.s.modifiers, id)
+	return nil
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// checkOrder enforces the constraints on the columns of an order.
+func (s *store) checkOrder(o db.Order) error {
+	if _, ok := s.customers[o.CustomerID]; !ok {
+		return repository.ErrUnknownCustomer
+	}
+	if err := checkEnum("status", o.Status, orderStatuses); err != nil {
+		return err
+	}
+	if err := checkEnum("payment_method", o.PaymentMethod, paymentMethods); err != nil {
+		return err
+	}
+	if o.TotalAmount < 0 {
+		return repository.Invalid("total_amount cannot be negative")
+	}
+	return nil
+}
+
+// customerByName returns the id of the first customer with the name. It
+// fails with ErrUnknownCustomer when there is none.
+func (s *store) customerByName(name string) (int, error) {
+	id := 0
+	for cid, c := range s.customers {
+		if c.Name == name && (id == 0 || cid < id) {
+			id = cid
+		}
+	}
+	if id == 0 {
+		return 0, fmt.Errorf("%w: %q", repository.ErrUnknownCustomer, name)
+	}
+	return id, nil
+}
+
+// orderView returns an order as the repository reports it: the columns the
+// database lists, without items.
+func orderView(o *order) db.Order {
+	v := o.Order
+	v.SpecialInstructions = append(json.RawMessage(nil), o.SpecialInstructions...)
+	return v
+}
+
+type orderRepo struct{ s *store }
+
+func (r *orderRepo) List(ctx context.Context) ([]db.Order, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var orders []db.Order
+	for _, o := range r.s.orders {
+		orders = append(orders, orderView(o))
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders, nil
+}
+
+func (r *orderRepo) Get(ctx context.Context, id int) (db.Order, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	o, ok := r.s.orders[id]
+	if !ok {
+		return db.Order{}, repository.NotFound("Order not found")
+	}
+	v := orderView(o)
+	for _, a := range r.s.applied {
+		if a.OrderID == id {
+			p := r.s.promotions[a.PromotionID]
+			v.AppliedPromotions = append(v.AppliedPromotions, db.AppliedPromotion{
+				PromotionID:    p.ID,
+				Name:           p.Name,
+				Code:           p.Code,
+				DiscountAmount: a.DiscountAmount,
+			})
+		}
+	}
+	return v, nil
+}
+
+// Tx runs fn under the lock of the store. When fn fails, the orders,
+// stock, promotion uses and loyalty transactions are put back as they were; ids are not
+// reused, like sequences.
+func (r *orderRepo) Tx(ctx context.Context, fn func(repository.OrderTx) error) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	snap := r.s.snapshot()
+	if err := fn(&orderTx{s: r.s}); err != nil {
+		r.s.restore(snap)
+		return err
+	}
+	return nil
+}
+
+// Delete removes an order with its items and applied promotions. Loyalty
+// transactions of the order are kept without it, like the foreign keys in
+// the database do.
+func (r *orderRepo) Delete(ctx context.Context, id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.orders[id]; !ok {
+		return repository.NotFound("Order not found")
+	}
+	delete(r.s.orders, id)
+	kept := r.s.applied[:0:0]
+	for _, a := range r.s.applied {
+		if a.OrderID != id {
+			kept = append(kept, a)
+		}
+	}
+	r.s.applied = kept
+	for i := range r.s.loyalty {
+		if t := &r.s.loyalty[i]; t.OrderID != nil && *t.OrderID == id {
+			t.OrderID = nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// snapshot is what an order transaction may change: orders are replaced,
+// never changed in place, and loyalty transactions and promotion uses are
+// only appended, so copying the maps and remembering the length of the
+// ledgers is enough.
+type snapshot struct {
+	orders     map[int]*order
+	inventory  map[string]db.Inventory
+	promotions map[int]db.Promotion
+	loyalty    int
+	applied    int
+}
+
+func (s *store) snapshot() snapshot {
+	snap := snapshot{
+		orders:     make(map[int]*order, len(s.orders)),
+		inventory:  make(map[string]db.Inventory, len(s.inventory)),
+		promotions: make(map[int]db.Promotion, len(s.promotions)),
+		loyalty:    len(s.loyalty),
+		applied:    len(s.applied),
+	}
+	for id, o := range s.orders {
+		snap.orders[id] = o
+	}
+	for id, item := range s.inventory {
+		snap.inventory[id] = item
+	}
+	for id, p := range s.promotions {
+		snap.promotions[id] = p
+	}
+	return snap
+}
+
+func (s *store) restore(snap snapshot) {
+	s.orders = snap.orders
+	s.inventory = snap.inventory
+	s.promotions = snap.promotions
+	s.loyalty = s.loyalty[:snap.loyalty]
+	s.applied = s.applied[:snap.applied]
+}
+
+// orderTx is the storage of the order service. It runs under the lock the
+// orderRepo.Tx holds.
+type orderTx struct{ s *store }
+
+func (t *orderTx) LockOrder(ctx context.Context, id int) (db.Order, []repository.OrderLine, error) {
+	o, ok := t.s.orders[id]
+	if !ok {
+		return db.Order{}, nil, repository.NotFound("Order not found")
+	}
+	lines := make([]repository.OrderLine, 0, len(o.Lines))
+	for _, l := range o.Lines {
+		lines = append(lines, repository.OrderLine{
+			MenuItemID: l.MenuItemID,
+			Categories: t.s.categoryNames(t.s.menu[l.MenuItemID].CategoryID),
+			Quantity:   l.Quantity,
+			UnitPrice:  l.UnitPrice,
+		})
+	}
+	return orderView(o), lines, nil
+}
+
+func (t *orderTx) InsertOrder(ctx context.Context, o db.Order, lines []repository.OrderLine) (int, error) {
+	if err := t.s.checkOrder(o); err != nil {
+		return 0, err
+	}
+	t.s.lastOrderID++
+	o.ID = t.s.lastOrderID
+	o.CreatedAt = time.Now()
+	o.UpdatedAt = o.CreatedAt
+	o.SpecialInstructions = append(json.RawMessage(nil), o.SpecialInstructions...)
+	o.PromoCode, o.RedeemPoints, o.Items, o.AppliedPromotions = "", 0, nil, nil
+
+	stored := &order{Order: o}
+	for _, l := range lines {
+		stored.Lines = append(stored.Lines, orderLine{
+			MenuItemID: l.MenuItemID,
+			Quantity:   l.Quantity,
+			UnitPrice:  l.UnitPrice,
+		})
+	}
+	t.s.orders[o.ID] = stored
+	return o.ID, nil
+}
+
+func (t *orderTx) UpdateOrder(ctx context.Context, id int, o db.Order) error {
+	current, ok := t.s.orders[id]
+	if !ok {
+		return repository.NotFound("Order not found")
+	}
+	if err := t.s.checkOrder(o); err != nil {
+		return err
+	}
+	updated := *current
+	updated.CustomerID = o.CustomerID
+	updated.TotalAmount = o.TotalAmount
+	updated.Status = o.Status
+	updated.SpecialInstructions = append(json.RawMessage(nil), o.SpecialInstructions...)
+	updated.PaymentMethod = o.PaymentMethod
+	updated.UpdatedAt = time.Now()
+	t.s.orders[id] = &updated
+	return nil
+}
+
+func (t *orderTx) CustomerByName(ctx context.Context, name string) (int, error) {
+	return t.s.customerByName(name)
+}
+
+// SaleItem reads a menu item with its recipe and modifier groups. The store
+// keeps no bundles, so the item has no components.
+func (t *orderTx) SaleItem(ctx context.Context, menuItemID string) (repository.SaleItem, error) {
+	item, ok := t.s.menu[menuItemID]
+	if !ok {
+		return repository.SaleItem{}, fmt.Errorf("%w: %s", repository.ErrUnknownMenuItem, menuItemID)
+	}
+	recipe, err := t.s.recipe(item)
+	if err != nil {
+		return repository.SaleItem{}, err
+	}
+	groups, err := t.s.saleModifierGroups(item.ID)
+	if err != nil {
+		return repository.SaleItem{}, err
+	}
+	sale := repository.SaleItem{
+		ID:             item.ID,
+		Price:          item.Price,
+		Size:           item.Size,
+		Categories:     t.s.categoryNames(item.CategoryID),
+		Recipe:         recipe,
+		ModifierGroups: groups,
+	}
+	for _, c := range t.s.categoryPath(item.CategoryID) {
+		sale.CategoryIDs = append(sale.CategoryIDs, c.ID)
+	}
+	return sale, nil
+}
+
+func (t *orderTx) ActivePromotions(ctx context.Context, code string, at time.Time) ([]db.Promotion, error) {
+	var promos []db.Promotion
+	codeFound := false
+	for _, p := range t.s.sortedPromotions() {
+		switch {
+		case !p.IsActive,
+			p.ValidFrom != nil && p.ValidFrom.After(at),
+			p.ExpiresAt != nil && !p.ExpiresAt.After(at),
+			p.Code == "" && p.UsageLimit > 0 && p.TimesUsed >= p.UsageLimit,
+			p.Code != "" && (code == "" || !strings.EqualFold(p.Code, code)):
+			continue
+		}
+		if p.Code != "" {
+			if p.UsageLimit > 0 && p.TimesUsed >= p.UsageLimit {
+				return nil, repository.ErrPromoCodeUsedUp
+			}
+			codeFound = true
+		}
+		promos = append(promos, t.s.promotionView(p))
+	}
+	if code != "" && !codeFound {
+		return nil, repository.ErrInvalidPromoCode
+	}
+	return promos, nil
+}
+
+// UsePromotions stores the promotions applied to an order and counts their usage.
+func (t *orderTx) UsePromotions(ctx context.Context, orderID int, applied []db.AppliedPromotion) error {
+	for _, a := range applied {
+		p, ok := t.s.promotions[a.PromotionID]
+		if !ok || p.UsageLimit > 0 && p.TimesUsed >= p.UsageLimit {
+			return repository.ErrPromoCodeUsedUp
+		}
+		p.TimesUsed++
+		t.s.promotions[p.ID] = p
+		t.s.applied = append(t.s.applied, appliedPromotion{
+			OrderID:        orderID,
+			PromotionID:    a.PromotionID,
+			DiscountAmount: a.DiscountAmount,
+		})
+	}
+	return nil
+}
+
+func (t *orderTx) LoyaltyBalance(ctx context.Context, customerID int) (points, punches int, err error) {
+	if _, ok := t.s.customers[customerID]; !ok {
+		return 0, 0, repository.ErrUnknownCustomer
+	}
+	points, punches = t.s.loyaltyTotals(customerID)
+	return points, punches, nil
+}
+
+func (t *orderTx) OrderLoyalty(ctx context.Context, orderID int) ([]db.LoyaltyTransaction, error) {
+	var history []db.LoyaltyTransaction
+	for _, l := range t.s.loyalty {
+		if l.OrderID != nil && *l.OrderID == orderID {
+			history = append(history, l.LoyaltyTransaction)
+		}
+	}
+	return history, nil
+}
+
+// AddLoyalty records a loyalty transaction; a second 'earned' one for an
+// order is ignored.
+func (t *orderTx) AddLoyalty(ctx context.Context, customerID int, l db.LoyaltyTransaction) error {
+	if l.OrderID != nil && l.TransactionType == "earned" {
+		for _, e := range t.s.loyalty {
+			if e.OrderID != nil && *e.OrderID == *l.OrderID && e.TransactionType == "earned" {
+				return nil
+			}
+		}
+	}
+	t.s.lastLoyaltyID++
+	l.ID = t.s.lastLoyaltyID
+	l.CreatedAt = time.Now()
+	if l.OrderID != nil {
+		orderID := *l.OrderID
+		l.OrderID = &orderID
+	}
+	t.s.loyalty = append(t.s.loyalty, loyaltyEntry{CustomerID: customerID, LoyaltyTransaction: l})
+	return nil
+}
+
+// DeductStock takes the usage out of inventory. It fails with
+// ErrInsufficientStock when any ingredient would go negative.
+func (t *orderTx) DeductStock(ctx context.Context, usage map[string]float64) error {
+	ids := make([]string, 0, len(usage))
+	for id := range usage {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		item, ok := t.s.inventory[id]
+		if !ok || item.Stock < usage[id] {
+			return fmt.Errorf("%w: ingredient %s", repository.ErrInsufficientStock, id)
+		}
+		item.Stock -= usage[id]
+		item.LastUpdated = time.Now()
+		t.s.inventory[id] = item
+	}
+	return nil
+}
+
+// Savepoint runs fn and puts back what it changed when it fails.
+func (t *orderTx) Savepoint(ctx context.Context, fn func() error) (failed, err error) {
+	snap := t.s.snapshot()
+	if failed = fn(); failed != nil {
+		t.s.restore(snap)
+	}
+	return failed, nil
+}
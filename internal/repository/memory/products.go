@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// productByName finds a product by its exact name, which is unique.
+func (s *store) productByName(name string) (db.MenuProduct, bool) {
+	for _, p := range s.products {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return db.MenuProduct{}, false
+}
+
+// productRecipe checks the recipe of a product and returns it as stored.
+// Quantities may be given in any unit compatible with the ingredient's stock
+// unit; it fails with ErrInvalidUnit or ErrUnknownIngredient otherwise.
+func (s *store) productRecipe(ingredients []db.RecipeIngredient) ([]db.RecipeIngredient, error) {
+	recipe := make([]db.RecipeIngredient, 0, len(ingredients))
+	for _, ing := range ingredients {
+		unit, err := s.recipeUnit(ing.IngredientID, ing.Unit)
+		if err != nil {
+			return nil, err
+		}
+		recipe = append(recipe, db.RecipeIngredient{IngredientID: ing.IngredientID, Quantity: ing.Quantity, Unit: unit})
+	}
+	sort.Slice(recipe, func(i, j int) bool { return recipe[i].IngredientID < recipe[j].IngredientID })
+	return recipe, nil
+}
+
+type productRepo struct{ s *store }
+
+func (r *productRepo) Create(ctx context.Context, p db.MenuProduct) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := r.s.resolveCategory(&p.CategoryID, &p.Category); err != nil {
+		return 0, err
+	}
+	if _, ok := r.s.productByName(p.Name); ok {
+		return 0, repository.Conflict("Menu product %s already exists", p.Name)
+	}
+	recipe, err := r.s.productRecipe(p.Ingredients)
+	if err != nil {
+		return 0, err
+	}
+	r.s.lastProductID++
+	r.s.products[r.s.lastProductID] = db.MenuProduct{
+		ID:          r.s.lastProductID,
+		Name:        p.Name,
+		Description: p.Description,
+		CategoryID:  p.CategoryID,
+		Ingredients: recipe,
+	}
+	return r.s.lastProductID, nil
+}
+
+// Get returns a product with its recipe and size variants.
+func (r *productRepo) Get(ctx context.Context, id int) (db.MenuProduct, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	product, ok := r.s.products[id]
+	if !ok {
+		return db.MenuProduct{Variants: make([]db.MenuItem, 0)}, repository.NotFound("Menu product not found")
+	}
+	product.Category = r.s.categories[product.CategoryID].Name
+	if len(product.Ingredients) == 0 {
+		product.Ingredients = nil
+	} else {
+		product.Ingredients = append([]db.RecipeIngredient(nil), product.Ingredients...)
+	}
+	product.Variants = make([]db.MenuItem, 0)
+	for _, item := range r.s.menu {
+		if item.ProductID == id {
+			product.Variants = append(product.Variants, r.s.describeMenuItem(item))
+		}
+	}
+	sort.Slice(product.Variants, func(i, j int) bool {
+		return sizeRank(product.Variants[i].Size) < sizeRank(product.Variants[j].Size)
+	})
+	return product, nil
+}
+
+func (r *productRepo) Update(ctx context.Context, id int, p db.MenuProduct) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := r.s.resolveCategory(&p.CategoryID, &p.Category); err != nil {
+		return err
+	}
+	if other, ok := r.s.productByName(p.Name); ok && other.ID != id {
+		return repository.Conflict("Menu product %s already exists", p.Name)
+	}
+	if _, ok := r.s.products[id]; !ok {
+		return repository.NotFound("Menu product not found")
+	}
+	recipe, err := r.s.productRecipe(p.Ingredients)
+	if err != nil {
+		return err
+	}
+	r.s.products[id] = db.MenuProduct{
+		ID:          id,
+		Name:        p.Name,
+		Description: p.Description,
+		CategoryID:  p.CategoryID,
+		Ingredients: recipe,
+	}
+	return nil
+}
+
+func (r *productRepo) Delete(ctx context.Context, id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.products[id]; !ok {
+		return repository.NotFound("Menu product not found")
+	}
+	for _, item := range r.s.menu {
+		if item.ProductID == id {
+			return repository.Conflict("Menu product still has variants; delete them first")
+		}
+	}
+	delete(r.s.products, id)
+	return nil
+}
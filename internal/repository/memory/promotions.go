@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// checkPromotion enforces the keys of a promotion saved as id (0 for a new
+// one) and returns it as it is stored: the code trimmed and the daily window
+// as the TIME columns return it.
+func (s *store) checkPromotion(id int, p db.Promotion) (db.Promotion, error) {
+	if err := s.resolveCategory(&p.CategoryID, &p.Category); err != nil {
+		return p, err
+	}
+	if p.MenuItemID != "" {
+		if _, ok := s.menu[p.MenuItemID]; !ok {
+			return p, fmt.Errorf("%w: %s", repository.ErrUnknownMenuItem, p.MenuItemID)
+		}
+	}
+	p.Code = strings.TrimSpace(p.Code)
+	for _, other := range s.promotions {
+		if other.ID != id && p.Code != "" && other.Code == p.Code {
+			return p, repository.Conflict("A promotion with code %s already exists", p.Code)
+		}
+	}
+	p.StartTime, p.EndTime = timeColumn(p.StartTime), timeColumn(p.EndTime)
+	p.ID, p.Category = id, ""
+	return p, nil
+}
+
+// timeColumn formats a time of day the way a TIME column returns it.
+func timeColumn(clock string) string {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, clock); err == nil {
+			return t.Format("15:04:05")
+		}
+	}
+	return clock
+}
+
+// promotionView returns a stored promotion with its category name.
+func (s *store) promotionView(p db.Promotion) db.Promotion {
+	p.Category = s.categories[p.CategoryID].Name
+	return p
+}
+
+func (s *store) sortedPromotions() []db.Promotion {
+	promos := make([]db.Promotion, 0, len(s.promotions))
+	for _, p := range s.promotions {
+		promos = append(promos, p)
+	}
+	sort.Slice(promos, func(i, j int) bool { return promos[i].ID < promos[j].ID })
+	return promos
+}
+
+// deletePromotion removes a promotion and, like the foreign keys in the
+// database, its uses on orders.
+func (s *store) deletePromotion(id int) {
+	delete(s.promotions, id)
+	kept := s.applied[:0:0]
+	for _, a := range s.applied {
+		if a.PromotionID != id {
+			kept = append(kept, a)
+		}
+	}
+	s.applied = kept
+}
+
+type promotionRepo struct{ s *store }
+
+func (r *promotionRepo) Create(ctx context.Context, p db.Promotion) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, err := r.s.checkPromotion(0, p)
+	if err != nil {
+		return 0, err
+	}
+	r.s.lastPromotionID++
+	stored.ID = r.s.lastPromotionID
+	stored.TimesUsed = 0
+	r.s.promotions[stored.ID] = stored
+	return stored.ID, nil
+}
+
+func (r *promotionRepo) List(ctx context.Context) ([]db.Promotion, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var promos []db.Promotion
+	for _, p := range r.s.sortedPromotions() {
+		promos = append(promos, r.s.promotionView(p))
+	}
+	return promos, nil
+}
+
+func (r *promotionRepo) Get(ctx context.Context, id int) (db.Promotion, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	p, ok := r.s.promotions[id]
+	if !ok {
+		return db.Promotion{}, repository.NotFound("Promotion not found")
+	}
+	return r.s.promotionView(p), nil
+}
+
+func (r *promotionRepo) Update(ctx context.Context, id int, p db.Promotion) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	current, ok := r.s.promotions[id]
+	if !ok {
+		return repository.NotFound("Promotion not found")
+	}
+	stored, err := r.s.checkPromotion(id, p)
+	if err != nil {
+		return err
+	}
+	stored.TimesUsed = current.TimesUsed
+	r.s.promotions[id] = stored
+	return nil
+}
+
+func (r *promotionRepo) Delete(ctx context.Context, id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.promotions[id]; !ok {
+		return repository.NotFound("Promotion not found")
+	}
+	r.s.deletePromotion(id)
+	return nil
+}
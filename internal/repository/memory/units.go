@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// unit returns a unit of the registry. It fails with ErrInvalidUnit when the
+// unit does not exist.
+func (s *store) unit(code string) (db.Unit, error) {
+	u, ok := s.units[strings.ToLower(strings.TrimSpace(code))]
+	if !ok {
+		return u, fmt.Errorf("%w: unknown unit %q", repository.ErrInvalidUnit, code)
+	}
+	return u, nil
+}
+
+// convertQuantity converts a quantity between two units of the same dimension.
+func (s *store) convertQuantity(quantity float64, from, to string) (float64, error) {
+	f, err := s.unit(from)
+	if err != nil {
+		return 0, err
+	}
+	t, err := s.unit(to)
+	if err != nil {
+		return 0, err
+	}
+	if f.Dimension != t.Dimension {
+		return 0, fmt.Errorf("%w: cannot convert %s (%s) to %s (%s)", repository.ErrInvalidUnit, f.Code, f.Dimension, t.Code, t.Dimension)
+	}
+	return quantity * f.Factor / t.Factor, nil
+}
+
+// recipeUnit checks that a recipe may measure the ingredient in unit and
+// returns the unit to store, defaulting to the ingredient's stock unit.
+func (s *store) recipeUnit(ingredientID, unit string) (string, error) {
+	item, ok := s.inventory[ingredientID]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", repository.ErrUnknownIngredient, ingredientID)
+	}
+	if unit == "" {
+		return item.UnitType, nil
+	}
+	u, err := s.unit(unit)
+	if err != nil {
+		return "", err
+	}
+	if stock := s.units[item.UnitType]; u.Dimension != stock.Dimension {
+		return "", fmt.Errorf("%w: ingredient %s is stocked in %s and cannot be measured in %s",
+			repository.ErrInvalidUnit, ingredientID, item.UnitType, u.Code)
+	}
+	return u.Code, nil
+}
+
+// stockQuantity converts a quantity of an inventory item given in unit, which
+// is either a unit of the registry or the item's purchase unit, to its stock
+// unit. An empty unit is the stock unit.
+func (s *store) stockQuantity(inventoryID string, quantity float64, unit string) (float64, error) {
+	item, ok := s.inventory[inventoryID]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", repository.ErrUnknownIngredient, inventoryID)
+	}
+	switch p := item.PurchaseUnit; {
+	case unit == "":
+		return quantity, nil
+	case p != nil && strings.EqualFold(unit, p.Name):
+		return s.convertQuantity(quantity*p.Quantity, p.Unit, item.UnitType)
+	default:
+		return s.convertQuantity(quantity, unit, item.UnitType)
+	}
+}
+
+// validatePurchaseUnit checks that a purchase unit holds a positive quantity
+// of something compatible with the stock unit.
+func (s *store) validatePurchaseUnit(stockUnit string, p *db.PurchaseUnit) error {
+	if p == nil {
+		return nil
+	}
+	if p.Name == "" {
+		return repository.Invalid("purchase_unit name is required")
+	}
+	if p.Quantity <= 0 {
+		return repository.Invalid("purchase_unit quantity must be greater than 0")
+	}
+	if p.Unit == "" {
+		p.Unit = stockUnit
+	}
+	if _, err := s.convertQuantity(p.Quantity, p.Unit, stockUnit); err != nil {
+		return err
+	}
+	p.Unit = strings.ToLower(strings.TrimSpace(p.Unit))
+	return nil
+}
+
+// changeStockUnit refuses a stock unit of another dimension while recipes or
+// modifiers measure the inventory item in the current one. Both store their
+// unit, so they keep their meaning when the stock unit changes.
+func (s *store) changeStockUnit(inventoryID, unit string) error {
+	current := s.inventory[inventoryID].UnitType
+	if current == unit {
+		return nil
+	}
+	dimension := s.units[unit].Dimension
+
+	seen := make(map[string]bool)
+	var incompatible []string
+	use := func(ingredientID, unit string) {
+		if ingredientID == inventoryID && s.units[unit].Dimension != dimension && !seen[unit] {
+			seen[unit] = true
+			incompatible = append(incompatible, unit)
+		}
+	}
+	for _, p := range s.products {
+		for _, ing := range p.Ingredients {
+			use(ing.IngredientID, ing.Unit)
+		}
+	}
+	for _, g := range s.modifiers {
+		for _, m := range g.Modifiers {
+			for _, ing := range m.Ingredients {
+				use(ing.IngredientID, ing.Unit)
+			}
+		}
+	}
+	if len(incompatible) > 0 {
+		sort.Strings(incompatible)
+		return repository.Conflict("recipes measure ingredient %s in %s, which cannot be converted to %s",
+			inventoryID, strings.Join(incompatible, ", "), unit)
+	}
+	return nil
+}
+
+type unitRepo struct{ s *store }
+
+func (r *unitRepo) List(ctx context.Context) ([]db.Unit, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	units := make([]db.Unit, 0, len(r.s.units))
+	for _, u := range r.s.units {
+		units = append(units, u)
+	}
+	sort.Slice(units, func(i, j int) bool {
+		a, b := units[i], units[j]
+		if a.Dimension != b.Dimension {
+			return a.Dimension < b.Dimension
+		}
+		if a.Factor != b.Factor {
+			return a.Factor < b.Factor
+		}
+		return a.Code < b.Code
+	})
+	return units, nil
+}
+
+func (r *unitRepo) Create(ctx context.Context, u db.Unit) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.units[u.Code]; ok {
+		return repository.Conflict("Unit %s already exists", u.Code)
+	}
+	r.s.units[u.Code] = u
+	return nil
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// menuItemAllergens selects the derived allergens of the menu item aliased mi.
+const menuItemAllergens = "ARRAY(SELECT allergen FROM menu_item_allergens a WHERE a.menu_item_id = mi.id ORDER BY allergen)"
+
+// checkAllergens fails with ErrUnknownAllergen when any code is not in the registry.
+func checkAllergens(ctx context.Context, q queryer, codes []string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	rows, err := q.QueryContext(ctx, "SELECT code FROM allergens WHERE code = ANY($1)", pq.Array(codes))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	known := make(map[string]bool, len(codes))
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return err
+		}
+		known[code] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var unknown []string
+	for _, code := range codes {
+		if !known[code] {
+			unknown = append(unknown, code)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", repository.ErrUnknownAllergen, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// saveInventoryAllergens replaces the allergens an inventory item is tagged with.
+func saveInventoryAllergens(ctx context.Context, q queryer, inventoryID string, codes []string) error {
+	if err := checkAllergens(ctx, q, codes); err != nil {
+		return err
+	}
+	if _, err := q.ExecContext(ctx, "DELETE FROM inventory_allergens WHERE inventory_id = $1", inventoryID); err != nil {
+		return err
+	}
+	for _, code := range codes {
+		_, err := q.ExecContext(ctx,
+			"INSERT INTO inventory_allergens (inventory_id, allergen) VALUES ($1, $2)", inventoryID, code)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type allergenRepo struct{ db *sql.DB }
+
+func (r *allergenRepo) List(ctx context.Context) ([]db.Allergen, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT code, name FROM allergens ORDER BY code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	allergens := make([]db.Allergen, 0)
+	for rows.Next() {
+		var a db.Allergen
+		if err := rows.Scan(&a.Code, &a.Name); err != nil {
+			return nil, err
+		}
+		allergens = append(allergens, a)
+	}
+	return allergens, rows.Err()
+}
+
+func (r *allergenRepo) Create(ctx context.Context, a db.Allergen) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO allergens (code, name) VALUES ($1, $2)", a.Code, a.Name)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return repository.Conflict("Allergen %s already exists", a.Code)
+	}
+	return err
+}
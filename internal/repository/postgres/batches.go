@@ -0,0 +1,244 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// batchColumns lists the columns scanned by scanBatch, for inventory_batches
+// aliased b joined with inventory aliased i.
+const batchColumns = `b.id, b.inventory_id, i.name, b.quantity, b.received_quantity, i.unit_type,
+	b.unit_cost, to_char(b.expires_on, 'YYYY-MM-DD'), b.expires_on - CURRENT_DATE,
+	b.received_at, b.purchase_order_id`
+
+func scanBatch(row rowScanner) (db.InventoryBatch, error) {
+	var b db.InventoryBatch
+	var expiresOn sql.NullString
+	var daysLeft, purchaseOrderID sql.NullInt64
+	err := row.Scan(&b.ID, &b.InventoryID, &b.Name, &b.Quantity, &b.ReceivedQuantity, &b.Unit,
+		&b.UnitCost, &expiresOn, &daysLeft, &b.ReceivedAt, &purchaseOrderID)
+	b.ExpiresOn = expiresOn.String
+	if daysLeft.Valid {
+		days := int(daysLeft.Int64)
+		b.DaysLeft = &days
+	}
+	b.PurchaseOrderID = int(purchaseOrderID.Int64)
+	return b, err
+}
+
+// checkExpiry fails with ErrInvalidExpiry unless s is empty or a date.
+func checkExpiry(s string) error {
+	if s == "" {
+		return nil
+	}
+	if _, err := time.Parse("2006-01-02", s); err != nil {
+		return repository.ErrInvalidExpiry
+	}
+	return nil
+}
+
+// addBatch records received stock as a new batch when the item is batch
+// tracked; an empty expiry defaults to the item's shelf life. It does
+// nothing for other items.
+func addBatch(ctx context.Context, q queryer, inventoryID string, quantity, unitCost float64, expiresOn string, purchaseOrderID int) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO inventory_batches (inventory_id, quantity, received_quantity, unit_cost, expires_on, purchase_order_id)
+		SELECT id, $2, $2, $3, COALESCE(NULLIF($4, '')::date, CURRENT_DATE + shelf_life_days), $5
+		FROM inventory
+		WHERE id = $1 AND track_batches`,
+		inventoryID, quantity, unitCost, expiresOn, nullInt(purchaseOrderID))
+	return err
+}
+
+// consumeBatches takes quantity out of the batches of an item, the earliest
+// expiry first and the oldest receipt first among equal dates (FEFO, then
+// FIFO). Batches without an expiry date are used last.
+func consumeBatches(ctx context.Context, q queryer, inventoryID string, quantity float64) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, quantity FROM inventory_batches
+		WHERE inventory_id = $1 AND quantity > 0
+		ORDER BY expires_on NULLS LAST, received_at, id
+		FOR UPDATE`, inventoryID)
+	if err != nil {
+		return err
+	}
+	type batch struct {
+		id       int
+		quantity float64
+	}
+	var batches []batch
+	for rows.Next() {
+		var b batch
+		if err := rows.Scan(&b.id, &b.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		batches = append(batches, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, b := range batches {
+		if quantity <= 0 {
+			break
+		}
+		take := b.quantity
+		if take > quantity {
+			take = quantity
+		}
+		_, err := q.ExecContext(ctx,
+			"UPDATE inventory_batches SET quantity = GREATEST(quantity - $2, 0) WHERE id = $1", b.id, take)
+		if err != nil {
+			return err
+		}
+		quantity -= take
+	}
+	if quantity > 1e-6 {
+		return fmt.Errorf("%w: batches of ingredient %s are short by %g", repository.ErrInsufficientStock, inventoryID, quantity)
+	}
+	return nil
+}
+
+// syncBatches makes the batches of a tracked item add up to its stock after
+// the stock was set directly: a surplus becomes a batch at the current cost
+// and a shortfall is consumed from the batches.
+func syncBatches(ctx context.Context, q queryer, inventoryID string) error {
+	var tracked bool
+	var diff, price float64
+	err := q.QueryRowContext(ctx, `
+		SELECT i.track_batches, i.stock - COALESCE(SUM(b.quantity), 0), i.price
+		FROM inventory i
+		LEFT JOIN inventory_batches b ON b.inventory_id = i.id
+		WHERE i.id = $1
+		GROUP BY i.id`, inventoryID).Scan(&tracked, &diff, &price)
+	if err != nil || !tracked {
+		return err
+	}
+	switch {
+	case diff > 0:
+		return addBatch(ctx, q, inventoryID, diff, price, "", 0)
+	case diff < 0:
+		return consumeBatches(ctx, q, inventoryID, -diff)
+	}
+	return nil
+}
+
+// saveBatchTracking turns lot tracking of an item on or off. Stock on hand
+// when tracking starts becomes an opening batch; batches are dropped when it
+// stops.
+func saveBatchTracking(ctx context.Context, q queryer, inventoryID string, t *db.BatchTracking) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE inventory SET track_batches = $2, shelf_life_days = $3
+		WHERE id = $1`, inventoryID, t.Enabled, nullInt(t.ShelfLifeDays))
+	if err != nil {
+		return err
+	}
+	if !t.Enabled {
+		_, err := q.ExecContext(ctx, "DELETE FROM inventory_batches WHERE inventory_id = $1", inventoryID)
+		return err
+	}
+	return syncBatches(ctx, q, inventoryID)
+}
+
+// fetchBatches returns the remaining batches of an item, earliest expiry first.
+func fetchBatches(ctx context.Context, q queryer, inventoryID string) ([]db.InventoryBatch, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT `+batchColumns+`
+		FROM inventory_batches b
+		JOIN inventory i ON i.id = b.inventory_id
+		WHERE b.inventory_id = $1 AND b.quantity > 0
+		ORDER BY b.expires_on NULLS LAST, b.received_at, b.id`, inventoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []db.InventoryBatch
+	for rows.Next() {
+		b, err := scanBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
+
+// ExpiringBatches returns batches with stock left that expire within days
+// days, including those already expired, soonest first.
+func (r *inventoryRepo) ExpiringBatches(ctx context.Context, days int) ([]db.InventoryBatch, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+batchColumns+`
+		FROM inventory_batches b
+		JOIN inventory i ON i.id = b.inventory_id
+		WHERE b.quantity > 0 AND b.expires_on <= CURRENT_DATE + $1::int
+		ORDER BY b.expires_on, i.name, b.id`, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := make([]db.InventoryBatch, 0)
+	for rows.Next() {
+		b, err := scanBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
+
+// WriteOffBatch writes off quantity of a batch, or what is left of it when
+// quantity is 0, and records a 'written off' inventory transaction.
+func (r *inventoryRepo) WriteOffBatch(ctx context.Context, id int, quantity float64) (db.InventoryBatch, error) {
+	var b db.InventoryBatch
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var inventoryID string
+		var remaining float64
+		err := tx.QueryRowContext(ctx,
+			"SELECT inventory_id, quantity FROM inventory_batches WHERE id = $1 FOR UPDATE", id,
+		).Scan(&inventoryID, &remaining)
+		if err == sql.ErrNoRows {
+			return repository.NotFound("Batch not found")
+		} else if err != nil {
+			return err
+		}
+		if quantity == 0 {
+			quantity = remaining
+		}
+		if quantity == 0 || quantity > remaining {
+			return repository.Conflict("Batch has %g left", remaining)
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE inventory_batches SET quantity = quantity - $2 WHERE id = $1", id, quantity); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE inventory SET stock = GREATEST(stock - $2, 0), last_updated = NOW()
+			WHERE id = $1`, inventoryID, quantity); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO inventory_transactions (inventory_id, change_amount, transaction_type, batch_id)
+			VALUES ($1, $2, 'written off', $3)`, inventoryID, -quantity, id); err != nil {
+			return err
+		}
+
+		b, err = scanBatch(tx.QueryRowContext(ctx, `
+			SELECT `+batchColumns+`
+			FROM inventory_batches b
+			JOIN inventory i ON i.id = b.inventory_id
+			WHERE b.id = $1`, id))
+		return err
+	})
+	return b, err
+}
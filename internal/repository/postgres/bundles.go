@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// fetchBundleComponents returns the component slots of a bundle menu item.
+// Items that are not bundles have none.
+func fetchBundleComponents(ctx context.Context, q queryer, bundleID string) ([]db.BundleComponent, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT b.id, b.name, COALESCE(b.menu_item_id, ''), COALESCE(b.choice_category_id, 0),
+			COALESCE(c.name, ''), COALESCE(b.choice_size::text, ''), b.quantity
+		FROM bundle_components b
+		LEFT JOIN categories c ON c.id = b.choice_category_id
+		WHERE b.bundle_id = $1
+		ORDER BY b.id`, bundleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var components []db.BundleComponent
+	for rows.Next() {
+		var c db.BundleComponent
+		if err := rows.Scan(&c.ID, &c.Name, &c.MenuItemID, &c.ChoiceCategoryID, &c.ChoiceCategory, &c.ChoiceSize, &c.Quantity); err != nil {
+			return nil, err
+		}
+		components = append(components, c)
+	}
+	return components, rows.Err()
+}
+
+// saveBundleComponents replaces the components of a bundle. Bundles cannot be
+// nested, so fixed components must be plain menu items.
+func saveBundleComponents(ctx context.Context, tx *sql.Tx, bundleID string, components []db.BundleComponent) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM bundle_components WHERE bundle_id = $1", bundleID); err != nil {
+		return err
+	}
+	for _, c := range components {
+		if c.Quantity == 0 {
+			c.Quantity = 1
+		}
+		if err := resolveCategory(ctx, tx, &c.ChoiceCategoryID, &c.ChoiceCategory); errors.Is(err, repository.ErrUnknownCategory) {
+			return fmt.Errorf("%w: %v", repository.ErrInvalidBundle, err)
+		} else if err != nil {
+			return err
+		}
+		if c.MenuItemID != "" {
+			var nested bool
+			err := tx.QueryRowContext(ctx,
+				"SELECT EXISTS (SELECT 1 FROM bundle_components WHERE bundle_id = $1)", c.MenuItemID).Scan(&nested)
+			if err != nil {
+				return err
+			}
+			if nested {
+				return fmt.Errorf("%w: menu item %s is a bundle itself", repository.ErrInvalidBundle, c.MenuItemID)
+			}
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO bundle_components (bundle_id, name, menu_item_id, choice_category_id, choice_size, quantity)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			bundleID, c.Name, nullString(c.MenuItemID), nullInt(c.ChoiceCategoryID), nullString(c.ChoiceSize), c.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// choiceCandidates returns the menu items that may be chosen for a choice slot.
+func choiceCandidates(ctx context.Context, q queryer, c db.BundleComponent) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT mi.id
+		FROM menu_items mi
+		JOIN category_tree ct ON ct.id = mi.category_id
+		WHERE $1 = ANY(ct.ancestor_ids)
+		  AND ($2 = '' OR mi.size::text = $2)
+		  AND NOT EXISTS (SELECT 1 FROM bundle_components b WHERE b.bundle_id = mi.id)
+		ORDER BY mi.id`, c.ChoiceCategoryID, c.ChoiceSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// resolveCategory fills in whichever of id and name is missing. Clients may
+// refer to a category by id or by its (case-insensitive) name; names that do
+// not match an existing category are rejected rather than creating a new one.
+func resolveCategory(ctx context.Context, q queryer, id *int, name *string) error {
+	var err error
+	switch {
+	case *id != 0:
+		err = q.QueryRowContext(ctx, "SELECT name FROM categories WHERE id = $1", *id).Scan(name)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: %d", repository.ErrUnknownCategory, *id)
+		}
+	case *name != "":
+		err = q.QueryRowContext(ctx,
+			"SELECT id, name FROM categories WHERE LOWER(name) = LOWER($1)", strings.TrimSpace(*name),
+		).Scan(id, name)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: %q", repository.ErrUnknownCategory, *name)
+		}
+	}
+	return err
+}
+
+// fetchCategoryTree returns the category rootID with its subcategories, or the
+// whole tree when rootID is 0. Siblings are ordered by sort_order.
+func fetchCategoryTree(ctx context.Context, q queryer, rootID int) ([]db.Category, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, name, COALESCE(parent_id, 0), sort_order
+		FROM category_tree
+		WHERE $1 = 0 OR $1 = ANY(ancestor_ids)
+		ORDER BY sort_path`, rootID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	children := make(map[int][]db.Category)
+	var roots []db.Category
+	for rows.Next() {
+		var c db.Category
+		if err := rows.Scan(&c.ID, &c.Name, &c.ParentID, &c.SortOrder); err != nil {
+			return nil, err
+		}
+		if c.ID == rootID || (rootID == 0 && c.ParentID == 0) {
+			roots = append(roots, c)
+		} else {
+			children[c.ParentID] = append(children[c.ParentID], c)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var attach func(cs []db.Category)
+	attach = func(cs []db.Category) {
+		for i := range cs {
+			cs[i].Children = children[cs[i].ID]
+			attach(cs[i].Children)
+		}
+	}
+	attach(roots)
+	return roots, nil
+}
+
+// validateCategoryParent checks that parentID exists and is not the category
+// itself or one of its subcategories.
+func validateCategoryParent(ctx context.Context, q queryer, id, parentID int) error {
+	if parentID == 0 {
+		return nil
+	}
+	var loop bool
+	err := q.QueryRowContext(ctx,
+		"SELECT $1 = ANY(ancestor_ids) FROM category_tree WHERE id = $2", id, parentID).Scan(&loop)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: parent %d", repository.ErrUnknownCategory, parentID)
+	} else if err != nil {
+		return err
+	}
+	if loop {
+		return repository.Invalid("a category cannot be moved under itself or its subcategories")
+	}
+	return nil
+}
+
+type categoryRepo struct{ db *sql.DB }
+
+func (r *categoryRepo) Create(ctx context.Context, c db.Category) (int, error) {
+	if err := validateCategoryParent(ctx, r.db, 0, c.ParentID); err != nil {
+		return 0, err
+	}
+	var id int
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO categories (name, parent_id, sort_order)
+		VALUES ($1, $2, $3)
+		RETURNING id`, c.Name, nullInt(c.ParentID), c.SortOrder).Scan(&id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return 0, repository.Conflict("Category %s already exists", c.Name)
+	}
+	return id, err
+}
+
+// Tree returns the category tree in display order.
+func (r *categoryRepo) Tree(ctx context.Context) ([]db.Category, error) {
+	return fetchCategoryTree(ctx, r.db, 0)
+}
+
+// Get returns a category with its subcategories.
+func (r *categoryRepo) Get(ctx context.Context, id int) (db.Category, error) {
+	categories, err := fetchCategoryTree(ctx, r.db, id)
+	if err != nil {
+		return db.Category{}, err
+	}
+	if len(categories) == 0 {
+		return db.Category{}, repository.NotFound("Category not found")
+	}
+	return categories[0], nil
+}
+
+func (r *categoryRepo) Update(ctx context.Context, id int, c db.Category) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		// Serialize moves so two concurrent updates cannot create a cycle
+		if _, err := tx.ExecContext(ctx, "LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+			return err
+		}
+		if err := validateCategoryParent(ctx, tx, id, c.ParentID); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			UPDATE categories SET name = $2, parent_id = $3, sort_order = $4
+			WHERE id = $1`, id, c.Name, nullInt(c.ParentID), c.SortOrder)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return repository.Conflict("Category %s already exists", c.Name)
+		} else if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return repository.NotFound("Category not found")
+		}
+		return nil
+	})
+}
+
+func (r *categoryRepo) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return repository.Conflict("Category is still used by subcategories, menu items or promotions")
+	} else if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return repository.NotFound("Category not found")
+	}
+	return nil
+}
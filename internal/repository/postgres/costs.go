@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+
+	"frappuccino/internal/db"
+
+	"github.com/lib/pq"
+)
+
+// ingredientCost prices the usage at the current inventory unit costs.
+func ingredientCost(ctx context.Context, q queryer, usage map[string]float64) (float64, error) {
+	if len(usage) == 0 {
+		return 0, nil
+	}
+	ids := make([]string, 0, len(usage))
+	for id := range usage {
+		ids = append(ids, id)
+	}
+	rows, err := q.QueryContext(ctx, "SELECT id, price FROM inventory WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var cost float64
+	for rows.Next() {
+		var id string
+		var price float64
+		if err := rows.Scan(&id, &price); err != nil {
+			return 0, err
+		}
+		cost += usage[id] * price
+	}
+	return cost, rows.Err()
+}
+
+// recipeCost returns the current ingredient cost of a menu item's own recipe.
+func recipeCost(ctx context.Context, q queryer, menuItemID string) (float64, error) {
+	var cost float64
+	err := q.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(r.quantity * i.price), 0)
+		FROM menu_item_recipes r
+		JOIN inventory i ON i.id = r.ingredient_id
+		WHERE r.menu_item_id = $1`, menuItemID).Scan(&cost)
+	return cost, err
+}
+
+// menuItemCost returns the current ingredient cost of a menu item. Bundles
+// cost their components; a choice slot counts with its most expensive item,
+// in which case varies is true.
+func menuItemCost(ctx context.Context, q queryer, menuItemID string) (cost float64, varies bool, err error) {
+	components, err := fetchBundleComponents(ctx, q, menuItemID)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(components) == 0 {
+		cost, err = recipeCost(ctx, q, menuItemID)
+		return cost, false, err
+	}
+
+	for _, c := range components {
+		ids := []string{c.MenuItemID}
+		if c.MenuItemID == "" {
+			varies = true
+			if ids, err = choiceCandidates(ctx, q, c); err != nil {
+				return 0, false, err
+			}
+		}
+		var highest float64
+		for _, id := range ids {
+			itemCost, err := recipeCost(ctx, q, id)
+			if err != nil {
+				return 0, false, err
+			}
+			if itemCost > highest {
+				highest = itemCost
+			}
+		}
+		cost += highest * float64(c.Quantity)
+	}
+	return cost, varies, nil
+}
+
+// CurrentMargins lists every menu item with its current ingredient cost.
+func (r *reportRepo) CurrentMargins(ctx context.Context) ([]db.ItemMargin, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.id, p.name, mi.id, mi.name, mi.size, mi.price
+		FROM menu_items mi
+		JOIN menu_products p ON p.id = mi.product_id
+		ORDER BY p.name, p.id, mi.size`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]db.ItemMargin, 0)
+	for rows.Next() {
+		var m db.ItemMargin
+		if err := rows.Scan(&m.ProductID, &m.Product, &m.MenuItemID, &m.Name, &m.Size, &m.Price); err != nil {
+			return nil, err
+		}
+		items = append(items, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range items {
+		items[i].IngredientCost, items[i].CostVaries, err = menuItemCost(ctx, r.db, items[i].MenuItemID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// SoldMargins sums the revenue and the ingredient cost recorded at the time
+// of sale per menu item. Lines recorded before costing was introduced have
+// no unit_cost and are skipped.
+func (r *reportRepo) SoldMargins(ctx context.Context, startDate, endDate string) ([]db.SoldMargin, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT mi.id, mi.name, mi.size,
+			SUM(oi.quantity) AS units_sold,
+			SUM(oi.quantity * oi.price_at_order) AS revenue,
+			SUM(oi.quantity * oi.unit_cost) AS ingredient_cost
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		JOIN menu_items mi ON mi.id = oi.menu_item_id
+		WHERE oi.unit_cost IS NOT NULL
+		  AND o.created_at >= COALESCE(NULLIF($1, '')::timestamptz, '-infinity')
+		  AND o.created_at < COALESCE(NULLIF($2, '')::timestamptz, 'infinity')
+		GROUP BY mi.id, mi.name, mi.size
+		ORDER BY revenue DESC, mi.id`, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]db.SoldMargin, 0)
+	for rows.Next() {
+		var m db.SoldMargin
+		if err := rows.Scan(&m.MenuItemID, &m.Name, &m.Size, &m.UnitsSold, &m.Revenue, &m.IngredientCost); err != nil {
+			return nil, err
+		}
+		items = append(items, m)
+	}
+	return items, rows.Err()
+}
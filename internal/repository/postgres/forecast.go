@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"frappuccino/internal/db"
+)
+
+// DailyUsage returns today's date and the quantity of each ingredient used
+// per day over the history days before today, in stock units, from the
+// recipes of the items sold and the modifiers chosen. Index 0 is the oldest
+// day.
+func (r *reportRepo) DailyUsage(ctx context.Context, history int) (time.Time, map[string][]float64, error) {
+	var todayStr string
+	if err := r.db.QueryRowContext(ctx, "SELECT to_char(CURRENT_DATE, 'YYYY-MM-DD')").Scan(&todayStr); err != nil {
+		return time.Time{}, nil, err
+	}
+	today, err := time.Parse("2006-01-02", todayStr)
+	if err != nil {
+		return today, nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		WITH used AS (
+			SELECT o.created_at::date AS day, r.ingredient_id, s.quantity * r.quantity AS quantity
+			FROM order_item_sales s
+			JOIN orders o ON o.id = s.order_id
+			JOIN menu_item_recipes r ON r.menu_item_id = s.menu_item_id
+			WHERE o.created_at >= CURRENT_DATE - $1::int AND o.created_at < CURRENT_DATE
+			UNION ALL
+			SELECT o.created_at::date, mi.ingredient_id,
+				oi.quantity * stock_quantity(mi.ingredient_id, mi.quantity_delta, mi.unit)
+			FROM order_item_modifiers m
+			JOIN order_items oi ON oi.id = m.order_item_id
+			JOIN orders o ON o.id = oi.order_id
+			JOIN modifier_ingredients mi ON mi.modifier_id = m.modifier_id
+			WHERE o.created_at >= CURRENT_DATE - $1::int AND o.created_at < CURRENT_DATE
+		)
+		SELECT ingredient_id, day - (CURRENT_DATE - $1::int), SUM(quantity)
+		FROM used
+		GROUP BY ingredient_id, day`, history)
+	if err != nil {
+		return today, nil, err
+	}
+	defer rows.Close()
+
+	usage := make(map[string][]float64)
+	for rows.Next() {
+		var ingredientID string
+		var day int
+		var quantity float64
+		if err := rows.Scan(&ingredientID, &day, &quantity); err != nil {
+			return today, nil, err
+		}
+		if day < 0 || day >= history {
+			continue
+		}
+		if usage[ingredientID] == nil {
+			usage[ingredientID] = make([]float64, history)
+		}
+		// Modifiers that remove an ingredient may leave a day slightly negative
+		usage[ingredientID][day] += quantity
+	}
+	return today, usage, rows.Err()
+}
+
+func (r *reportRepo) StockLevels(ctx context.Context) ([]db.StockLevel, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, unit_type, stock FROM inventory")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var levels []db.StockLevel
+	for rows.Next() {
+		var l db.StockLevel
+		if err := rows.Scan(&l.InventoryID, &l.Name, &l.Unit, &l.Stock); err != nil {
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+	return levels, rows.Err()
+}
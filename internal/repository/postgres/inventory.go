@@ -0,0 +1,336 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// inventoryColumns lists the columns scanned by scanInventoryItem.
+const inventoryColumns = `id, name, stock, price, unit_type,
+	purchase_unit, purchase_quantity, purchase_quantity_unit,
+	supplier_id, (SELECT name FROM suppliers WHERE suppliers.id = inventory.supplier_id), reorder_level, par_level,
+	track_batches, shelf_life_days,
+	ARRAY(SELECT allergen FROM inventory_allergens WHERE inventory_id = inventory.id ORDER BY allergen),
+	calories, protein, carbohydrates, fat, last_updated`
+
+func scanInventoryItem(row rowScanner) (db.Inventory, error) {
+	item := db.Inventory{Nutrition: &db.Nutrition{}}
+	var allergens []string
+	var purchaseUnit, purchaseQuantityUnit sql.NullString
+	var purchaseQuantity sql.NullFloat64
+	var supplierID sql.NullInt64
+	var supplier sql.NullString
+	var reorderLevel, parLevel sql.NullFloat64
+	var trackBatches bool
+	var shelfLifeDays sql.NullInt64
+	err := row.Scan(
+		&item.ID,
+		&item.Name,
+		&item.Stock,
+		&item.Price,
+		&item.UnitType,
+		&purchaseUnit,
+		&purchaseQuantity,
+		&purchaseQuantityUnit,
+		&supplierID,
+		&supplier,
+		&reorderLevel,
+		&parLevel,
+		&trackBatches,
+		&shelfLifeDays,
+		pq.Array(&allergens),
+		&item.Nutrition.Calories,
+		&item.Nutrition.Protein,
+		&item.Nutrition.Carbohydrates,
+		&item.Nutrition.Fat,
+		&item.LastUpdated,
+	)
+	item.Allergens = allergens
+	if purchaseUnit.Valid {
+		item.PurchaseUnit = &db.PurchaseUnit{
+			Name:     purchaseUnit.String,
+			Quantity: purchaseQuantity.Float64,
+			Unit:     purchaseQuantityUnit.String,
+		}
+	}
+	if trackBatches {
+		item.BatchTracking = &db.BatchTracking{Enabled: true, ShelfLifeDays: int(shelfLifeDays.Int64)}
+	}
+	if supplierID.Valid || parLevel.Valid {
+		item.Reorder = &db.ReorderPolicy{
+			SupplierID: int(supplierID.Int64),
+			Supplier:   supplier.String,
+			Level:      reorderLevel.Float64,
+			ParLevel:   parLevel.Float64,
+		}
+	}
+	return item, err
+}
+
+// saveReorderPolicy sets the preferred supplier and reorder levels of an
+// inventory item.
+func saveReorderPolicy(ctx context.Context, q queryer, inventoryID string, p *db.ReorderPolicy) error {
+	var level, par interface{}
+	if p.ParLevel > 0 {
+		level, par = p.Level, p.ParLevel
+	}
+	_, err := q.ExecContext(ctx, `
+		UPDATE inventory SET supplier_id = $2, reorder_level = $3, par_level = $4
+		WHERE id = $1`, inventoryID, nullInt(p.SupplierID), level, par)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return fmt.Errorf("%w: %d", repository.ErrUnknownSupplier, p.SupplierID)
+	}
+	return err
+}
+
+// purchaseUnitArgs returns the purchase unit columns, NULL when there is none.
+func purchaseUnitArgs(p *db.PurchaseUnit) []interface{} {
+	if p == nil {
+		return []interface{}{nil, nil, nil}
+	}
+	return []interface{}{p.Name, p.Quantity, p.Unit}
+}
+
+// checkInventoryUnits normalizes the stock unit and checks it and the
+// purchase unit against the registry.
+func checkInventoryUnits(ctx context.Context, q queryer, item *db.Inventory) error {
+	unit, err := fetchUnit(ctx, q, item.UnitType)
+	if err != nil {
+		return err
+	}
+	item.UnitType = unit.Code
+	return validatePurchaseUnit(ctx, q, item.UnitType, item.PurchaseUnit)
+}
+
+type inventoryRepo struct{ db *sql.DB }
+
+// Create stores a new inventory item with its allergens, reorder policy and
+// batch tracking, and returns its id.
+func (r *inventoryRepo) Create(ctx context.Context, item db.Inventory) (string, error) {
+	var id string
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := checkInventoryUnits(ctx, tx, &item); err != nil {
+			return err
+		}
+		args := append([]interface{}{
+			item.ID,
+			item.Name,
+			item.Stock,
+			item.Price,
+			item.UnitType,
+			item.Nutrition.Calories,
+			item.Nutrition.Protein,
+			item.Nutrition.Carbohydrates,
+			item.Nutrition.Fat,
+		}, purchaseUnitArgs(item.PurchaseUnit)...)
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO inventory (id,name, stock, price, unit_type, calories, protein, carbohydrates, fat,
+				purchase_unit, purchase_quantity, purchase_quantity_unit, last_updated)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
+			RETURNING id`, args...).Scan(&id)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return repository.Conflict("Inventory item %s already exists", item.ID)
+		} else if err != nil {
+			return err
+		}
+		if err := saveInventoryAllergens(ctx, tx, id, item.Allergens); err != nil {
+			return err
+		}
+		if item.Reorder != nil {
+			if err := saveReorderPolicy(ctx, tx, id, item.Reorder); err != nil {
+				return err
+			}
+		}
+		if item.BatchTracking != nil {
+			return saveBatchTracking(ctx, tx, id, item.BatchTracking)
+		}
+		return nil
+	})
+	return id, err
+}
+
+func (r *inventoryRepo) List(ctx context.Context) ([]db.Inventory, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+inventoryColumns+" FROM inventory")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []db.Inventory
+	for rows.Next() {
+		item, err := scanInventoryItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Get returns an inventory item with its remaining batches.
+func (r *inventoryRepo) Get(ctx context.Context, id string) (db.Inventory, error) {
+	item, err := scanInventoryItem(r.db.QueryRowContext(ctx,
+		"SELECT "+inventoryColumns+" FROM inventory WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return item, repository.NotFound("Inventory item not found")
+	} else if err != nil {
+		return item, err
+	}
+	if item.BatchTracking != nil {
+		item.Batches, err = fetchBatches(ctx, r.db, id)
+	}
+	return item, err
+}
+
+// Update replaces an inventory item. Nutrition, allergens, the reorder policy
+// and batch tracking are only replaced when they are set. A change of stock
+// is recorded as an 'adjustment' transaction.
+func (r *inventoryRepo) Update(ctx context.Context, id string, item db.Inventory) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := checkInventoryUnits(ctx, tx, &item); err != nil {
+			return err
+		}
+		if err := changeStockUnit(ctx, tx, id, item.UnitType); err == sql.ErrNoRows {
+			return repository.NotFound("Inventory item not found")
+		} else if err != nil {
+			return err
+		}
+		adjustment, err := stockAdjustment(ctx, tx, id, item.Stock, item.UnitType)
+		if err != nil {
+			return err
+		}
+
+		args := append([]interface{}{
+			id,
+			item.Name,
+			item.Stock,
+			item.Price,
+			item.UnitType,
+		}, purchaseUnitArgs(item.PurchaseUnit)...)
+		_, err = tx.ExecContext(ctx, `
+			UPDATE inventory
+			SET name = $2, stock = $3, price = $4, unit_type = $5,
+				purchase_unit = $6, purchase_quantity = $7, purchase_quantity_unit = $8, last_updated = NOW()
+			WHERE id = $1`, args...)
+		if err != nil {
+			return err
+		}
+		if adjustment != 0 {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO inventory_transactions (inventory_id, change_amount, transaction_type)
+				VALUES ($1, $2, 'adjustment')`, id, adjustment)
+			if err != nil {
+				return err
+			}
+		}
+		if item.Nutrition != nil {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE inventory SET calories = $2, protein = $3, carbohydrates = $4, fat = $5
+				WHERE id = $1`,
+				id, item.Nutrition.Calories, item.Nutrition.Protein, item.Nutrition.Carbohydrates, item.Nutrition.Fat)
+			if err != nil {
+				return err
+			}
+		}
+		if item.Allergens != nil {
+			if err := saveInventoryAllergens(ctx, tx, id, item.Allergens); err != nil {
+				return err
+			}
+		}
+		if item.Reorder != nil {
+			if err := saveReorderPolicy(ctx, tx, id, item.Reorder); err != nil {
+				return err
+			}
+		}
+		// Batches follow the stock that was set
+		if item.BatchTracking != nil {
+			return saveBatchTracking(ctx, tx, id, item.BatchTracking)
+		}
+		return syncBatches(ctx, tx, id)
+	})
+}
+
+// stockAdjustment returns how much setting the stock of an item to stock,
+// in unit, changes it. The current stock is converted to unit first. Stock
+// left in a unit of another dimension cannot be compared with the new one,
+// so the update is refused until that stock is written off; an empty stock
+// makes the whole new stock the adjustment.
+func stockAdjustment(ctx context.Context, q queryer, id string, stock float64, unit string) (float64, error) {
+	var current float64
+	var currentUnit string
+	err := q.QueryRowContext(ctx,
+		"SELECT stock, unit_type FROM inventory WHERE id = $1", id).Scan(&current, &currentUnit)
+	if err != nil {
+		return 0, err
+	}
+	if currentUnit == unit || current == 0 {
+		return stock - current, nil
+	}
+	current, err = convertQuantity(ctx, q, current, currentUnit, unit)
+	if errors.Is(err, repository.ErrInvalidUnit) {
+		return 0, repository.Conflict("the stock of ingredient %s is measured in %s, which cannot be converted to %s; write it off first",
+			id, currentUnit, unit)
+	} else if err != nil {
+		return 0, err
+	}
+	return stock - current, nil
+}
+
+func (r *inventoryRepo) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM inventory WHERE id = $1", id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return repository.Conflict("Inventory item is still on purchase orders")
+	} else if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return repository.NotFound("Inventory item not found")
+	}
+	return nil
+}
+
+// Restock adds stock received for an inventory item, converting the amount
+// to the stock unit, and records the batch and an 'added' transaction.
+func (r *inventoryRepo) Restock(ctx context.Context, id string, req db.Restock) (db.RestockResult, error) {
+	result := db.RestockResult{ID: id}
+	if err := checkExpiry(req.ExpiresOn); err != nil {
+		return result, err
+	}
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		amount, err := stockQuantity(ctx, tx, id, req.Amount, req.Unit)
+		if errors.Is(err, repository.ErrUnknownIngredient) {
+			return repository.NotFound("Inventory item not found")
+		} else if err != nil {
+			return err
+		}
+		result.Added = amount
+
+		var price float64
+		err = tx.QueryRowContext(ctx, `
+			UPDATE inventory
+			SET stock = stock + $2, last_updated = NOW()
+			WHERE id = $1
+			RETURNING stock, unit_type, price`, id, amount).Scan(&result.Stock, &result.UnitType, &price)
+		if err != nil {
+			return err
+		}
+		if err := addBatch(ctx, tx, id, amount, price, req.ExpiresOn, 0); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO inventory_transactions (inventory_id, change_amount, transaction_type)
+			VALUES ($1, $2, 'added')`, id, amount)
+		return err
+	})
+	return result, err
+}
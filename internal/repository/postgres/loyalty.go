@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// loyaltyTotals returns the customer's point balance and collected punches.
+func loyaltyTotals(ctx context.Context, q queryer, customerID int) (points, punches int, err error) {
+	err = q.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(points), 0), COALESCE(SUM(punches), 0)
+		FROM loyalty_transactions
+		WHERE customer_id = $1`, customerID).Scan(&points, &punches)
+	return points, punches, err
+}
+
+// LoyaltyBalance locks the customer row so that concurrent orders cannot
+// spend the same points twice.
+func (t *orderTx) LoyaltyBalance(ctx context.Context, customerID int) (points, punches int, err error) {
+	var id int
+	err = t.tx.QueryRowContext(ctx, "SELECT id FROM customers WHERE id = $1 FOR UPDATE", customerID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, 0, repository.ErrUnknownCustomer
+	} else if err != nil {
+		return 0, 0, err
+	}
+	return loyaltyTotals(ctx, t.tx, customerID)
+}
+
+func (t *orderTx) OrderLoyalty(ctx context.Context, orderID int) ([]db.LoyaltyTransaction, error) {
+	rows, err := t.tx.QueryContext(ctx, `
+		SELECT id, transaction_type, points, punches, created_at
+		FROM loyalty_transactions
+		WHERE order_id = $1
+		ORDER BY id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []db.LoyaltyTransaction
+	for rows.Next() {
+		l := db.LoyaltyTransaction{OrderID: &orderID}
+		if err := rows.Scan(&l.ID, &l.TransactionType, &l.Points, &l.Punches, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, l)
+	}
+	return history, rows.Err()
+}
+
+// AddLoyalty writes a loyalty transaction. The unique index on earned
+// transactions makes a second one for the same order a no-op.
+func (t *orderTx) AddLoyalty(ctx context.Context, customerID int, l db.LoyaltyTransaction) error {
+	_, err := t.tx.ExecContext(ctx, `
+		INSERT INTO loyalty_transactions (customer_id, order_id, transaction_type, points, punches)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (order_id) WHERE transaction_type = 'earned' DO NOTHING`,
+		customerID, l.OrderID, l.TransactionType, l.Points, l.Punches)
+	return err
+}
+
+type customerRepo struct{ db *sql.DB }
+
+func (r *customerRepo) Create(ctx context.Context, c db.Customer) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO customers (name, email, preferences)
+		VALUES ($1, $2, $3)
+		RETURNING id`, c.Name, nullString(c.Email), c.Preferences).Scan(&id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return 0, repository.Conflict("A customer with email %s already exists", c.Email)
+	}
+	return id, err
+}
+
+func (r *customerRepo) Get(ctx context.Context, id int) (db.Customer, error) {
+	c := db.Customer{ID: id}
+	var preferences []byte
+	err := r.db.QueryRowContext(ctx,
+		"SELECT name, COALESCE(email, ''), preferences FROM customers WHERE id = $1", id,
+	).Scan(&c.Name, &c.Email, &preferences)
+	if err == sql.ErrNoRows {
+		return c, repository.NotFound("Customer not found")
+	}
+	c.Preferences = preferences
+	return c, err
+}
+
+func (r *customerRepo) Loyalty(ctx context.Context, customerID int) (db.LoyaltyAccount, error) {
+	account := db.LoyaltyAccount{CustomerID: customerID, History: make([]db.LoyaltyTransaction, 0)}
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM customers WHERE id = $1)", customerID).Scan(&exists)
+	if err != nil {
+		return account, err
+	}
+	if !exists {
+		return account, repository.NotFound("Customer not found")
+	}
+
+	account.Balance, account.Punches, err = loyaltyTotals(ctx, r.db, customerID)
+	if err != nil {
+		return account, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, order_id, transaction_type, points, punches, created_at
+		FROM loyalty_transactions
+		WHERE customer_id = $1
+		ORDER BY created_at DESC, id DESC`, customerID)
+	if err != nil {
+		return account, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t db.LoyaltyTransaction
+		var orderID sql.NullInt64
+		if err := rows.Scan(&t.ID, &orderID, &t.TransactionType, &t.Points, &t.Punches, &t.CreatedAt); err != nil {
+			return account, err
+		}
+		if orderID.Valid {
+			id := int(orderID.Int64)
+			t.OrderID = &id
+		}
+		account.History = append(account.History, t)
+	}
+	return account, rows.Err()
+}
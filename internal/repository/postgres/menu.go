@@ -0,0 +1,298 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+const (
+	menuItemColumns = "mi.id, mi.product_id, mi.name, mi.description, mi.price, " + menuItemAllergens + ", mi.category_id, c.name, mi.size, mi.recipe_scale"
+	menuItemFrom    = "menu_items mi JOIN categories c ON c.id = mi.category_id"
+)
+
+func scanMenuItem(row rowScanner) (db.MenuItem, error) {
+	var item db.MenuItem
+	var allergens []string
+	err := row.Scan(
+		&item.ID,
+		&item.ProductID,
+		&item.Name,
+		&item.Description,
+		&item.Price,
+		pq.Array(&allergens),
+		&item.CategoryID,
+		&item.Category,
+		&item.Size,
+		&item.RecipeScale,
+	)
+	item.Allergens = allergens
+	return item, err
+}
+
+// resolveMenuProduct fills the product of a menu item. Without a product_id the
+// product with the item's name is used, and created if it does not exist yet.
+// Name, description and category default to the product's.
+func resolveMenuProduct(ctx context.Context, q queryer, item *db.MenuItem) error {
+	if item.ProductID == 0 {
+		if item.Name == "" {
+			return repository.Invalid("name is required")
+		}
+		if item.CategoryID == 0 {
+			return repository.Invalid("category is required")
+		}
+		return q.QueryRowContext(ctx, `
+			INSERT INTO menu_products (name, description, category_id)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id`, item.Name, item.Description, item.CategoryID).Scan(&item.ProductID)
+	}
+
+	var name, category string
+	var description sql.NullString
+	var categoryID int
+	err := q.QueryRowContext(ctx, `
+		SELECT p.name, p.description, p.category_id, c.name
+		FROM menu_products p
+		JOIN categories c ON c.id = p.category_id
+		WHERE p.id = $1`, item.ProductID,
+	).Scan(&name, &description, &categoryID, &category)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", repository.ErrUnknownProduct, item.ProductID)
+	} else if err != nil {
+		return err
+	}
+	if item.Name == "" {
+		item.Name = name
+	}
+	if item.Description == "" {
+		item.Description = description.String
+	}
+	if item.CategoryID == 0 {
+		item.CategoryID, item.Category = categoryID, category
+	}
+	return nil
+}
+
+type menuRepo struct{ db *sql.DB }
+
+// List returns the menu as products with their size variants, in category
+// display order.
+func (r *menuRepo) List(ctx context.Context, f repository.MenuFilter) ([]db.MenuProduct, error) {
+	if err := checkAllergens(ctx, r.db, f.ExcludeAllergens); err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.id, p.name, COALESCE(p.description, ''), p.category_id, pc.name,
+			mi.id, mi.product_id, mi.name, mi.description, mi.price, `+menuItemAllergens+`,
+			mi.category_id, ct.name, mi.size, mi.recipe_scale
+		FROM menu_products p
+		JOIN categories pc ON pc.id = p.category_id
+		JOIN menu_items mi ON mi.product_id = p.id
+		JOIN category_tree ct ON ct.id = mi.category_id
+		WHERE ($1 = 0 OR $1 = ANY(ct.ancestor_ids))
+		  AND NOT EXISTS (
+			SELECT 1 FROM menu_item_allergens a
+			WHERE a.menu_item_id = mi.id AND a.allergen = ANY($2))
+		ORDER BY ct.sort_path, p.name, p.id, mi.size`, f.CategoryID, pq.Array(f.ExcludeAllergens))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]db.MenuProduct, 0)
+	for rows.Next() {
+		var product db.MenuProduct
+		var item db.MenuItem
+		var allergens []string
+		if err := rows.Scan(
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.CategoryID,
+			&product.Category,
+			&item.ID,
+			&item.ProductID,
+			&item.Name,
+			&item.Description,
+			&item.Price,
+			pq.Array(&allergens),
+			&item.CategoryID,
+			&item.Category,
+			&item.Size,
+			&item.RecipeScale,
+		); err != nil {
+			return nil, err
+		}
+		item.Allergens = allergens
+
+		if n := len(products); n == 0 || products[n-1].ID != product.ID {
+			product.Variants = make([]db.MenuItem, 0, 3)
+			products = append(products, product)
+		}
+		last := &products[len(products)-1]
+		last.Variants = append(last.Variants, item)
+	}
+	return products, rows.Err()
+}
+
+// Get returns a menu item with its modifier groups, bundle components and
+// nutrition.
+func (r *menuRepo) Get(ctx context.Context, id string) (db.MenuItem, error) {
+	query := "SELECT " + menuItemColumns + " FROM " + menuItemFrom + " WHERE mi.id = $1"
+	item, err := scanMenuItem(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return item, repository.NotFound("Menu item not found")
+	} else if err != nil {
+		return item, err
+	}
+
+	if item.ModifierGroups, err = fetchModifierGroups(ctx, r.db, item.ID); err != nil {
+		return item, err
+	}
+	if item.Components, err = fetchBundleComponents(ctx, r.db, item.ID); err != nil {
+		return item, err
+	}
+	nutrition, err := menuItemNutrition(ctx, r.db, item.ID)
+	if err != nil {
+		return item, err
+	}
+	item.Nutrition = &nutrition
+	return item, nil
+}
+
+// Create stores a menu item and, for bundles, its components. The product is
+// resolved as described at resolveMenuProduct.
+func (r *menuRepo) Create(ctx context.Context, item db.MenuItem) (string, error) {
+	var id string
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := resolveCategory(ctx, tx, &item.CategoryID, &item.Category); err != nil {
+			return err
+		}
+		if err := resolveMenuProduct(ctx, tx, &item); err != nil {
+			return err
+		}
+
+		// Allergens are derived from the recipe and not stored on the item
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO menu_items (id, product_id, name, description, price, category_id, size, recipe_scale)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id`,
+			item.ID,
+			item.ProductID,
+			item.Name,
+			item.Description,
+			item.Price,
+			item.CategoryID,
+			item.Size,
+			item.RecipeScale,
+		).Scan(&id)
+		if err != nil {
+			return menuItemError(err)
+		}
+
+		// Items with components are bundles
+		return saveBundleComponents(ctx, tx, id, item.Components)
+	})
+	return id, err
+}
+
+// Update replaces a menu item. product_id and recipe_scale keep their
+// current values when zero, and bundle components are replaced only when
+// they are not nil. A change of price is recorded in price_history.
+func (r *menuRepo) Update(ctx context.Context, id string, item db.MenuItem) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := resolveCategory(ctx, tx, &item.CategoryID, &item.Category); err != nil {
+			return err
+		}
+
+		var oldPrice, newPrice float64
+		err := tx.QueryRowContext(ctx, `
+			WITH prev AS (SELECT id, price FROM menu_items WHERE id = $1 FOR UPDATE)
+			UPDATE menu_items m
+			SET name = $2, description = $3, price = $4, category_id = $5, size = $6,
+				product_id = COALESCE(NULLIF($7, 0), m.product_id),
+				recipe_scale = COALESCE(NULLIF($8, 0), m.recipe_scale)
+			FROM prev
+			WHERE m.id = prev.id
+			RETURNING prev.price, m.price`,
+			id,
+			item.Name,
+			item.Description,
+			item.Price,
+			item.CategoryID,
+			item.Size,
+			item.ProductID,
+			item.RecipeScale,
+		).Scan(&oldPrice, &newPrice)
+		if err == sql.ErrNoRows {
+			return repository.NotFound("Menu item not found")
+		} else if err != nil {
+			return menuItemError(err)
+		}
+		if newPrice != oldPrice {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO price_history (menu_item_id, old_price, new_price)
+				VALUES ($1, $2, $3)`, id, oldPrice, newPrice)
+			if err != nil {
+				return err
+			}
+		}
+
+		if item.Components != nil {
+			return saveBundleComponents(ctx, tx, id, item.Components)
+		}
+		return nil
+	})
+}
+
+// Delete removes a menu item. A component of a bundle is refused, since the
+// bundle would keep its price without it.
+func (r *menuRepo) Delete(ctx context.Context, id string) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var bundle string
+		err := tx.QueryRowContext(ctx, `
+			SELECT mi.name FROM bundle_components b
+			JOIN menu_items mi ON mi.id = b.bundle_id
+			WHERE b.menu_item_id = $1
+			ORDER BY mi.name
+			LIMIT 1`, id).Scan(&bundle)
+		if err == nil {
+			return repository.Conflict("Menu item is a component of bundle %s", bundle)
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, "DELETE FROM menu_items WHERE id = $1", id)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Table == "bundle_components" {
+			return repository.Conflict("Menu item is a component of a bundle")
+		} else if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return repository.NotFound("Menu item not found")
+		}
+		return nil
+	})
+}
+
+// menuItemError explains the constraint violations a menu item write can run into.
+func menuItemError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return repository.Conflict("A menu item with this ID, or with this name and size, already exists")
+		case "23503":
+			return repository.ErrUnknownProduct
+		}
+	}
+	return err
+}
@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// fetchModifierGroups returns modifier groups with their modifiers. When
+// menuItemID is set only the groups attached to that menu item are returned.
+func fetchModifierGroups(ctx context.Context, q queryer, menuItemID string) ([]db.ModifierGroup, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT g.id, g.name, g.min_selections, g.max_selections,
+			COALESCE(array_agg(mg.menu_item_id ORDER BY mg.menu_item_id) FILTER (WHERE mg.menu_item_id IS NOT NULL), '{}')
+		FROM modifier_groups g
+		LEFT JOIN menu_item_modifier_groups mg ON mg.group_id = g.id
+		GROUP BY g.id
+		HAVING $1 = '' OR bool_or(mg.menu_item_id = $1)
+		ORDER BY g.id`, menuItemID)
+	if err != nil {
+		return nil, err
+	}
+	var groups []db.ModifierGroup
+	index := make(map[int]int)
+	var groupIDs []int64
+	for rows.Next() {
+		var g db.ModifierGroup
+		if err := rows.Scan(&g.ID, &g.Name, &g.MinSelections, &g.MaxSelections, pq.Array(&g.MenuItemIDs)); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if menuItemID != "" {
+			g.MenuItemIDs = nil
+		}
+		g.Modifiers = make([]db.Modifier, 0)
+		index[g.ID] = len(groups)
+		groups = append(groups, g)
+		groupIDs = append(groupIDs, int64(g.ID))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(groups) == 0 {
+		return groups, err
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT m.id, m.group_id, m.name, m.price_delta, mi.ingredient_id, mi.quantity_delta,
+			COALESCE(mi.unit, i.unit_type)
+		FROM modifiers m
+		LEFT JOIN modifier_ingredients mi ON mi.modifier_id = m.id
+		LEFT JOIN inventory i ON i.id = mi.ingredient_id
+		WHERE m.group_id = ANY($1)
+		ORDER BY m.id, mi.ingredient_id`, pq.Array(groupIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m db.Modifier
+		var groupID int
+		var ingredientID, unit sql.NullString
+		var quantityDelta sql.NullFloat64
+		if err := rows.Scan(&m.ID, &groupID, &m.Name, &m.PriceDelta, &ingredientID, &quantityDelta, &unit); err != nil {
+			return nil, err
+		}
+		g := &groups[index[groupID]]
+		if n := len(g.Modifiers); n == 0 || g.Modifiers[n-1].ID != m.ID {
+			g.Modifiers = append(g.Modifiers, m)
+		}
+		if ingredientID.Valid {
+			last := &g.Modifiers[len(g.Modifiers)-1]
+			last.Ingredients = append(last.Ingredients, db.ModifierIngredient{
+				IngredientID:  ingredientID.String,
+				QuantityDelta: quantityDelta.Float64,
+				Unit:          unit.String,
+			})
+		}
+	}
+	return groups, rows.Err()
+}
+
+// saveModifiers upserts the modifiers of a group: modifiers with an id are
+// updated, new ones are inserted and the ones left out are removed.
+func saveModifiers(ctx context.Context, tx *sql.Tx, groupID int, modifiers []db.Modifier) error {
+	keep := make([]int64, 0, len(modifiers))
+	for _, m := range modifiers {
+		if m.ID != 0 {
+			keep = append(keep, int64(m.ID))
+		}
+	}
+	_, err := tx.ExecContext(ctx,
+		"DELETE FROM modifiers WHERE group_id = $1 AND NOT (id = ANY($2))", groupID, pq.Array(keep))
+	if err != nil {
+		return err
+	}
+
+	for _, m := range modifiers {
+		id := m.ID
+		if id == 0 {
+			err = tx.QueryRowContext(ctx, `
+				INSERT INTO modifiers (group_id, name, price_delta)
+				VALUES ($1, $2, $3)
+				RETURNING id`, groupID, m.Name, m.PriceDelta).Scan(&id)
+		} else {
+			err = tx.QueryRowContext(ctx, `
+				UPDATE modifiers SET name = $3, price_delta = $4
+				WHERE id = $1 AND group_id = $2
+				RETURNING id`, id, groupID, m.Name, m.PriceDelta).Scan(&id)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w: modifier %d does not belong to this group", repository.ErrInvalidModifiers, m.ID)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM modifier_ingredients WHERE modifier_id = $1", id); err != nil {
+			return err
+		}
+		for _, ing := range m.Ingredients {
+			unit, err := recipeUnit(ctx, tx, ing.IngredientID, ing.Unit)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO modifier_ingredients (modifier_id, ingredient_id, quantity_delta, unit)
+				VALUES ($1, $2, $3, $4)`, id, ing.IngredientID, ing.QuantityDelta, unit)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// attachModifierGroup replaces the menu items a modifier group is offered on.
+func attachModifierGroup(ctx context.Context, tx *sql.Tx, groupID int, menuItemIDs []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM menu_item_modifier_groups WHERE group_id = $1", groupID); err != nil {
+		return err
+	}
+	for _, menuItemID := range menuItemIDs {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO menu_item_modifier_groups (menu_item_id, group_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`, menuItemID, groupID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type modifierRepo struct{ db *sql.DB }
+
+func (r *modifierRepo) Create(ctx context.Context, g db.ModifierGroup) (int, error) {
+	var id int
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO modifier_groups (name, min_selections, max_selections)
+			VALUES ($1, $2, $3)
+			RETURNING id`, g.Name, g.MinSelections, g.MaxSelections).Scan(&id)
+		if err != nil {
+			return err
+		}
+		if err := saveModifiers(ctx, tx, id, g.Modifiers); err != nil {
+			return err
+		}
+		return attachModifierGroup(ctx, tx, id, g.MenuItemIDs)
+	})
+	return id, err
+}
+
+// List returns every modifier group, or only the ones offered on menuItemID
+// when it is set.
+func (r *modifierRepo) List(ctx context.Context, menuItemID string) ([]db.ModifierGroup, error) {
+	return fetchModifierGroups(ctx, r.db, menuItemID)
+}
+
+func (r *modifierRepo) Update(ctx context.Context, id int, g db.ModifierGroup) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE modifier_groups SET name = $2, min_selections = $3, max_selections = $4
+			WHERE id = $1`, id, g.Name, g.MinSelections, g.MaxSelections)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return repository.NotFound("Modifier group not found")
+		}
+		if err := saveModifiers(ctx, tx, id, g.Modifiers); err != nil {
+			return err
+		}
+		return attachModifierGroup(ctx, tx, id, g.MenuItemIDs)
+	})
+}
+
+func (r *modifierRepo) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM modifier_groups WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return repository.NotFound("Modifier group not found")
+	}
+	return nil
+}
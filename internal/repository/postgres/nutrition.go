@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"math"
+
+	"frappuccino/internal/db"
+
+	"github.com/lib/pq"
+)
+
+// addNutrition adds n multiplied by factor to total.
+func addNutrition(total *db.Nutrition, n db.Nutrition, factor float64) {
+	total.Calories += n.Calories * factor
+	total.Protein += n.Protein * factor
+	total.Carbohydrates += n.Carbohydrates * factor
+	total.Fat += n.Fat * factor
+	total.UpTo = total.UpTo || n.UpTo
+}
+
+func roundNutrition(n db.Nutrition) db.Nutrition {
+	round := func(v float64) float64 { return math.Round(v*10) / 10 }
+	n.Calories = math.Round(n.Calories)
+	n.Protein = round(n.Protein)
+	n.Carbohydrates = round(n.Carbohydrates)
+	n.Fat = round(n.Fat)
+	return n
+}
+
+// recipeNutrition returns the nutrition of a menu item's own recipe.
+func recipeNutrition(ctx context.Context, q queryer, menuItemID string) (db.Nutrition, error) {
+	var n db.Nutrition
+	err := q.QueryRowContext(ctx, `
+		SELECT calories, protein, carbohydrates, fat
+		FROM menu_item_nutrition
+		WHERE menu_item_id = $1`, menuItemID,
+	).Scan(&n.Calories, &n.Protein, &n.Carbohydrates, &n.Fat)
+	if err == sql.ErrNoRows {
+		return n, nil
+	}
+	return n, err
+}
+
+// menuItemNutrition computes the nutrition of a menu item from its recipe. For
+// bundles it adds up the components; a choice slot counts with the richest
+// item that may be chosen, and the result is marked UpTo.
+func menuItemNutrition(ctx context.Context, q queryer, menuItemID string) (db.Nutrition, error) {
+	components, err := fetchBundleComponents(ctx, q, menuItemID)
+	if err != nil {
+		return db.Nutrition{}, err
+	}
+	if len(components) == 0 {
+		n, err := recipeNutrition(ctx, q, menuItemID)
+		return roundNutrition(n), err
+	}
+
+	var total db.Nutrition
+	for _, c := range components {
+		if c.MenuItemID != "" {
+			n, err := recipeNutrition(ctx, q, c.MenuItemID)
+			if err != nil {
+				return total, err
+			}
+			addNutrition(&total, n, float64(c.Quantity))
+			continue
+		}
+
+		candidates, err := choiceCandidates(ctx, q, c)
+		if err != nil {
+			return total, err
+		}
+		var richest db.Nutrition
+		for _, id := range candidates {
+			n, err := recipeNutrition(ctx, q, id)
+			if err != nil {
+				return total, err
+			}
+			if n.Calories > richest.Calories {
+				richest = n
+			}
+		}
+		addNutrition(&total, richest, float64(c.Quantity))
+		total.UpTo = true
+	}
+	return roundNutrition(total), nil
+}
+
+// Labels returns every menu item with its category path, allergens and
+// nutrition, in menu order.
+func (r *menuRepo) Labels(ctx context.Context) ([]db.MenuLabel, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT array_to_string(ct.ancestor_names, ' > '), mi.id, mi.name, mi.size, mi.price, `+menuItemAllergens+`
+		FROM menu_items mi
+		JOIN category_tree ct ON ct.id = mi.category_id
+		ORDER BY ct.sort_path, mi.name, mi.size`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var labels []db.MenuLabel
+	for rows.Next() {
+		var l db.MenuLabel
+		if err := rows.Scan(&l.Category, &l.ID, &l.Name, &l.Size, &l.Price, pq.Array(&l.Allergens)); err != nil {
+			return nil, err
+		}
+		labels = append(labels, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range labels {
+		labels[i].Nutrition, err = menuItemNutrition(ctx, r.db, labels[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return labels, nil
+}
@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+const orderColumns = "id, customer_id, total_amount, status, special_instructions, payment_method, discount_amount, created_at, updated_at"
+
+func scanOrder(row rowScanner) (db.Order, error) {
+	var o db.Order
+	var instructions []byte
+	err := row.Scan(&o.ID, &o.CustomerID, &o.TotalAmount, &o.Status, &instructions, &o.PaymentMethod,
+		&o.DiscountAmount, &o.CreatedAt, &o.UpdatedAt)
+	o.SpecialInstructions = instructions
+	return o, err
+}
+
+type orderRepo struct{ db *sql.DB }
+
+func (r *orderRepo) List(ctx context.Context) ([]db.Order, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+orderColumns+" FROM orders")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []db.Order
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+func (r *orderRepo) Get(ctx context.Context, id int) (db.Order, error) {
+	o, err := scanOrder(r.db.QueryRowContext(ctx, "SELECT "+orderColumns+" FROM orders WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return o, repository.NotFound("Order not found")
+	} else if err != nil {
+		return o, err
+	}
+	o.AppliedPromotions, err = getAppliedPromotions(ctx, r.db, o.ID)
+	return o, err
+}
+
+// Tx runs fn in a database transaction that is committed when fn returns nil.
+func (r *orderRepo) Tx(ctx context.Context, fn func(repository.OrderTx) error) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return fn(&orderTx{tx: tx})
+	})
+}
+
+// orderTx is the storage of the order service inside a transaction.
+type orderTx struct{ tx *sql.Tx }
+
+func (t *orderTx) LockOrder(ctx context.Context, id int) (db.Order, []repository.OrderLine, error) {
+	o, err := scanOrder(t.tx.QueryRowContext(ctx, "SELECT "+orderColumns+" FROM orders WHERE id = $1 FOR UPDATE", id))
+	if err == sql.ErrNoRows {
+		return o, nil, repository.NotFound("Order not found")
+	} else if err != nil {
+		return o, nil, err
+	}
+
+	rows, err := t.tx.QueryContext(ctx, `
+		SELECT oi.menu_item_id, oi.quantity::int, oi.price_at_order, ct.ancestor_names
+		FROM order_items oi
+		JOIN menu_items mi ON mi.id = oi.menu_item_id
+		JOIN category_tree ct ON ct.id = mi.category_id
+		WHERE oi.order_id = $1
+		ORDER BY oi.id`, id)
+	if err != nil {
+		return o, nil, err
+	}
+	defer rows.Close()
+
+	var lines []repository.OrderLine
+	for rows.Next() {
+		var l repository.OrderLine
+		if err := rows.Scan(&l.MenuItemID, &l.Quantity, &l.UnitPrice, pq.Array(&l.Categories)); err != nil {
+			return o, nil, err
+		}
+		lines = append(lines, l)
+	}
+	return o, lines, rows.Err()
+}
+
+// InsertOrder stores the order with its lines, the modifiers selected on
+// them and, for bundles, the components sold with their share of the revenue.
+func (t *orderTx) InsertOrder(ctx context.Context, o db.Order, lines []repository.OrderLine) (int, error) {
+	var id int
+	err := t.tx.QueryRowContext(ctx, `
+		INSERT INTO orders (customer_id, total_amount, status, special_instructions, payment_method, discount_amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		o.CustomerID, o.TotalAmount, o.Status, o.SpecialInstructions, o.PaymentMethod, o.DiscountAmount,
+	).Scan(&id)
+	if err != nil {
+		return 0, orderError(err)
+	}
+
+	for _, l := range lines {
+		unitCost, err := ingredientCost(ctx, t.tx, l.Usage)
+		if err != nil {
+			return 0, err
+		}
+		var orderItemID int
+		err = t.tx.QueryRowContext(ctx, `
+			INSERT INTO order_items (order_id, menu_item_id, quantity, price_at_order, unit_cost)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id`, id, l.MenuItemID, l.Quantity, l.UnitPrice, unitCost).Scan(&orderItemID)
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range l.Modifiers {
+			_, err := t.tx.ExecContext(ctx, `
+				INSERT INTO order_item_modifiers (order_item_id, modifier_id, name, price_delta)
+				VALUES ($1, $2, $3, $4)`, orderItemID, m.ID, m.Name, m.PriceDelta)
+			if err != nil {
+				return 0, err
+			}
+		}
+		for _, p := range l.Parts {
+			_, err := t.tx.ExecContext(ctx, `
+				INSERT INTO order_item_components (order_item_id, menu_item_id, quantity, allocated_revenue)
+				VALUES ($1, $2, $3, $4)`, orderItemID, p.MenuItemID, p.Quantity, p.Revenue)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return id, nil
+}
+
+func (t *orderTx) UpdateOrder(ctx context.Context, id int, o db.Order) error {
+	var previous string
+	err := t.tx.QueryRowContext(ctx, `
+		WITH prev AS (SELECT id, status FROM orders WHERE id = $1 FOR UPDATE)
+		UPDATE orders
+		SET customer_id = $2, total_amount = $3, status = $4,
+		    special_instructions = $5, payment_method = $6, updated_at = NOW()
+		FROM prev
+		WHERE orders.id = prev.id
+		RETURNING prev.status`,
+		id, o.CustomerID, o.TotalAmount, o.Status, o.SpecialInstructions, o.PaymentMethod,
+	).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return repository.NotFound("Order not found")
+	} else if err != nil {
+		return orderError(err)
+	}
+	if previous == o.Status {
+		return nil
+	}
+	return recordStatusChange(ctx, t.tx, id, previous, o.Status)
+}
+
+func (t *orderTx) CustomerByName(ctx context.Context, name string) (int, error) {
+	var id int
+	err := t.tx.QueryRowContext(ctx, "SELECT id FROM customers WHERE name = $1 ORDER BY id LIMIT 1", name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w: %q", repository.ErrUnknownCustomer, name)
+	}
+	return id, err
+}
+
+func (t *orderTx) Savepoint(ctx context.Context, fn func() error) (failed, err error) {
+	if _, err := t.tx.ExecContext(ctx, "SAVEPOINT order_savepoint"); err != nil {
+		return nil, err
+	}
+	if failed = fn(); failed != nil {
+		_, err = t.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT order_savepoint")
+		return failed, err
+	}
+	_, err = t.tx.ExecContext(ctx, "RELEASE SAVEPOINT order_savepoint")
+	return nil, err
+}
+
+// recordStatusChange adds a status change of an order to its history.
+func recordStatusChange(ctx context.Context, q queryer, orderID int, previous, status string) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO order_status_history (order_id, previous_status, new_status)
+		VALUES ($1, $2, $3)`, orderID, previous, status)
+	return err
+}
+
+// orderError translates the foreign key violation of an order for a
+// customer that does not exist.
+func orderError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return repository.ErrUnknownCustomer
+	}
+	return err
+}
+
+func (r *orderRepo) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM orders WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.NotFound("Order not found")
+	}
+	return nil
+}
+
+// getAppliedPromotions returns the promotions recorded on an order.
+func getAppliedPromotions(ctx context.Context, q queryer, orderID int) ([]db.AppliedPromotion, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT p.id, p.name, COALESCE(p.code, ''), op.discount_amount
+		FROM order_promotions op
+		JOIN promotions p ON p.id = op.promotion_id
+		WHERE op.order_id = $1
+		ORDER BY op.id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []db.AppliedPromotion
+	for rows.Next() {
+		var a db.AppliedPromotion
+		if err := rows.Scan(&a.PromotionID, &a.Name, &a.Code, &a.DiscountAmount); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,43 @@
+// Package postgres implements the repositories on PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"frappuccino/internal/repository"
+)
+
+// New returns the repositories backed by dbc.
+func New(dbc *sql.DB) *repository.Repositories {
+	return &repository.Repositories{
+		Orders:         &orderRepo{db: dbc},
+		Menu:           &menuRepo{db: dbc},
+		Inventory:      &inventoryRepo{db: dbc},
+		Customers:      &customerRepo{db: dbc},
+		Categories:     &categoryRepo{db: dbc},
+		Products:       &productRepo{db: dbc},
+		Modifiers:      &modifierRepo{db: dbc},
+		Promotions:     &promotionRepo{db: dbc},
+		Allergens:      &allergenRepo{db: dbc},
+		Units:          &unitRepo{db: dbc},
+		Suppliers:      &supplierRepo{db: dbc},
+		PurchaseOrders: &purchaseOrderRepo{db: dbc},
+		StockTakes:     &stockTakeRepo{db: dbc},
+		Reports:        &reportRepo{db: dbc},
+	}
+}
+
+// inTx runs fn in a transaction that is committed when fn succeeds.
+func inTx(ctx context.Context, dbc *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := dbc.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// queryer is satisfied by both *sql.DB and *sql.Tx, so the helpers can run
+// inside the transaction of the caller.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// SaleItem reads a menu item with its recipe, the modifier groups attached
+// to it and, for bundles, its components.
+func (t *orderTx) SaleItem(ctx context.Context, menuItemID string) (repository.SaleItem, error) {
+	item := repository.SaleItem{ID: menuItemID, Recipe: make(map[string]float64)}
+	var categoryIDs []int64
+	err := t.tx.QueryRowContext(ctx, `
+		SELECT mi.price, mi.size, ct.ancestor_names, ct.ancestor_ids
+		FROM menu_items mi
+		JOIN category_tree ct ON ct.id = mi.category_id
+		WHERE mi.id = $1`, menuItemID,
+	).Scan(&item.Price, &item.Size, pq.Array(&item.Categories), pq.Array(&categoryIDs))
+	if err == sql.ErrNoRows {
+		return item, fmt.Errorf("%w: %s", repository.ErrUnknownMenuItem, menuItemID)
+	} else if err != nil {
+		return item, err
+	}
+	for _, id := range categoryIDs {
+		item.CategoryIDs = append(item.CategoryIDs, int(id))
+	}
+
+	rows, err := t.tx.QueryContext(ctx, `
+		SELECT ingredient_id, quantity
+		FROM menu_item_recipes
+		WHERE menu_item_id = $1`, menuItemID)
+	if err != nil {
+		return item, err
+	}
+	for rows.Next() {
+		var id string
+		var quantity float64
+		if err := rows.Scan(&id, &quantity); err != nil {
+			rows.Close()
+			return item, err
+		}
+		item.Recipe[id] = quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return item, err
+	}
+
+	if item.ModifierGroups, err = saleModifierGroups(ctx, t.tx, menuItemID); err != nil {
+		return item, err
+	}
+	item.Components, err = fetchBundleComponents(ctx, t.tx, menuItemID)
+	return item, err
+}
+
+// saleModifierGroups returns the modifier groups attached to a menu item
+// with the ingredient deltas of their modifiers in stock units.
+func saleModifierGroups(ctx context.Context, q queryer, menuItemID string) ([]db.ModifierGroup, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT g.id, g.name, g.min_selections, g.max_selections, m.id, m.name, m.price_delta,
+			mi.ingredient_id, COALESCE(stock_quantity(mi.ingredient_id, mi.quantity_delta, mi.unit), 0)
+		FROM modifier_groups g
+		JOIN menu_item_modifier_groups mg ON mg.group_id = g.id
+		LEFT JOIN modifiers m ON m.group_id = g.id
+		LEFT JOIN modifier_ingredients mi ON mi.modifier_id = m.id
+		WHERE mg.menu_item_id = $1
+		ORDER BY g.id, m.id, mi.ingredient_id`, menuItemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []db.ModifierGroup
+	for rows.Next() {
+		var g db.ModifierGroup
+		var modifierID sql.NullInt64
+		var name, ingredientID sql.NullString
+		var priceDelta, quantityDelta sql.NullFloat64
+		if err := rows.Scan(&g.ID, &g.Name, &g.MinSelections, &g.MaxSelections,
+			&modifierID, &name, &priceDelta, &ingredientID, &quantityDelta); err != nil {
+			return nil, err
+		}
+		if n := len(groups); n == 0 || groups[n-1].ID != g.ID {
+			groups = append(groups, g)
+		}
+		if !modifierID.Valid {
+			continue
+		}
+		group := &groups[len(groups)-1]
+		if n := len(group.Modifiers); n == 0 || group.Modifiers[n-1].ID != int(modifierID.Int64) {
+			group.Modifiers = append(group.Modifiers, db.Modifier{
+				ID:         int(modifierID.Int64),
+				Name:       name.String,
+				PriceDelta: priceDelta.Float64,
+			})
+		}
+		if ingredientID.Valid {
+			m := &group.Modifiers[len(group.Modifiers)-1]
+			m.Ingredients = append(m.Ingredients, db.ModifierIngredient{
+				IngredientID:  ingredientID.String,
+				QuantityDelta: quantityDelta.Float64,
+			})
+		}
+	}
+	return groups, rows.Err()
+}
+
+// ActivePromotions returns the promotions that may apply at the given moment:
+// every automatic promotion that is not used up plus the one matching code,
+// if a code was given. The code is looked up whatever its usage, so that a
+// used up code is told apart from an unknown one.
+func (t *orderTx) ActivePromotions(ctx context.Context, code string, at time.Time) ([]db.Promotion, error) {
+	query := "SELECT " + promotionColumns + ` FROM promotions
+		WHERE is_active
+		  AND (valid_from IS NULL OR valid_from <= $1)
+		  AND (expires_at IS NULL OR expires_at > $1)
+		  AND (code IS NULL OR ($2 <> '' AND LOWER(code) = LOWER($2)))
+		  AND (usage_limit IS NULL OR times_used < usage_limit OR code IS NOT NULL)
+		ORDER BY id`
+	rows, err := t.tx.QueryContext(ctx, query, at, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promos []db.Promotion
+	codeFound := false
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		if p.Code != "" {
+			if p.UsageLimit > 0 && p.TimesUsed >= p.UsageLimit {
+				return nil, repository.ErrPromoCodeUsedUp
+			}
+			codeFound = true
+		}
+		promos = append(promos, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if code != "" && !codeFound {
+		return nil, repository.ErrInvalidPromoCode
+	}
+	return promos, nil
+}
+
+// UsePromotions stores the promotions applied to an order and counts their usage.
+func (t *orderTx) UsePromotions(ctx context.Context, orderID int, applied []db.AppliedPromotion) error {
+	for _, a := range applied {
+		result, err := t.tx.ExecContext(ctx, `
+			UPDATE promotions SET times_used = times_used + 1
+			WHERE id = $1 AND (usage_limit IS NULL OR times_used < usage_limit)`, a.PromotionID)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return repository.ErrPromoCodeUsedUp
+		}
+		_, err = t.tx.ExecContext(ctx, `
+			INSERT INTO order_promotions (order_id, promotion_id, discount_amount)
+			VALUES ($1, $2, $3)`, orderID, a.PromotionID, a.DiscountAmount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// saveProductRecipe replaces the recipe shared by the variants of a product.
+// Quantities may be given in any unit compatible with the ingredient's stock
+// unit; it fails with ErrInvalidUnit or ErrUnknownIngredient otherwise.
+func saveProductRecipe(ctx context.Context, tx *sql.Tx, productID int, ingredients []db.RecipeIngredient) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM product_ingredients WHERE product_id = $1", productID); err != nil {
+		return err
+	}
+	for _, ing := range ingredients {
+		unit, err := recipeUnit(ctx, tx, ing.IngredientID, ing.Unit)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO product_ingredients (product_id, ingredient_id, quantity, unit)
+			VALUES ($1, $2, $3, $4)`, productID, ing.IngredientID, ing.Quantity, unit)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type productRepo struct{ db *sql.DB }
+
+func (r *productRepo) Create(ctx context.Context, p db.MenuProduct) (int, error) {
+	var id int
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := resolveCategory(ctx, tx, &p.CategoryID, &p.Category); err != nil {
+			return err
+		}
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO menu_products (name, description, category_id)
+			VALUES ($1, $2, $3)
+			RETURNING id`, p.Name, p.Description, p.CategoryID).Scan(&id)
+		if err != nil {
+			return productError(err, p.Name)
+		}
+		return saveProductRecipe(ctx, tx, id, p.Ingredients)
+	})
+	return id, err
+}
+
+// Get returns a product with its recipe and size variants.
+func (r *productRepo) Get(ctx context.Context, id int) (db.MenuProduct, error) {
+	product := db.MenuProduct{Variants: make([]db.MenuItem, 0)}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT p.id, p.name, COALESCE(p.description, ''), p.category_id, c.name
+		FROM menu_products p
+		JOIN categories c ON c.id = p.category_id
+		WHERE p.id = $1`, id,
+	).Scan(&product.ID, &product.Name, &product.Description, &product.CategoryID, &product.Category)
+	if err == sql.ErrNoRows {
+		return product, repository.NotFound("Menu product not found")
+	} else if err != nil {
+		return product, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT pi.ingredient_id, pi.quantity, COALESCE(pi.unit, i.unit_type)
+		FROM product_ingredients pi
+		JOIN inventory i ON i.id = pi.ingredient_id
+		WHERE pi.product_id = $1 ORDER BY pi.ingredient_id`, id)
+	if err != nil {
+		return product, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ing db.RecipeIngredient
+		if err := rows.Scan(&ing.IngredientID, &ing.Quantity, &ing.Unit); err != nil {
+			return product, err
+		}
+		product.Ingredients = append(product.Ingredients, ing)
+	}
+	if err := rows.Err(); err != nil {
+		return product, err
+	}
+
+	variants, err := r.db.QueryContext(ctx,
+		"SELECT "+menuItemColumns+" FROM "+menuItemFrom+" WHERE mi.product_id = $1 ORDER BY mi.size", id)
+	if err != nil {
+		return product, err
+	}
+	defer variants.Close()
+	for variants.Next() {
+		item, err := scanMenuItem(variants)
+		if err != nil {
+			return product, err
+		}
+		product.Variants = append(product.Variants, item)
+	}
+	return product, variants.Err()
+}
+
+func (r *productRepo) Update(ctx context.Context, id int, p db.MenuProduct) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := resolveCategory(ctx, tx, &p.CategoryID, &p.Category); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `
+			UPDATE menu_products SET name = $2, description = $3, category_id = $4
+			WHERE id = $1`, id, p.Name, p.Description, p.CategoryID)
+		if err != nil {
+			return productError(err, p.Name)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return repository.NotFound("Menu product not found")
+		}
+		return saveProductRecipe(ctx, tx, id, p.Ingredients)
+	})
+}
+
+func (r *productRepo) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM menu_products WHERE id = $1", id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return repository.Conflict("Menu product still has variants; delete them first")
+	} else if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return repository.NotFound("Menu product not found")
+	}
+	return nil
+}
+
+func productError(err error, name string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return repository.Conflict("Menu product %s already exists", name)
+	}
+	return err
+}
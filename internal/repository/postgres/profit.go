@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+
+	"frappuccino/internal/db"
+)
+
+// periodFormats maps the supported report periods to the layout of their labels.
+var periodFormats = map[string]string{
+	"day":   "YYYY-MM-DD",
+	"month": "YYYY-MM",
+}
+
+// TradingTotals sums sales, cost of goods sold and write-offs per period.
+// Orders count on the day they were placed, which is when their stock is
+// deducted; COGS uses the ingredient cost recorded on each order line and
+// write-offs are valued at the current inventory unit cost.
+func (r *reportRepo) TradingTotals(ctx context.Context, period, startDate, endDate string) ([]db.PeriodTotals, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH entries AS (
+			SELECT date_trunc($1, o.created_at) AS period,
+				1 AS orders,
+				COALESCE(SUM(oi.quantity), 0) AS units_sold,
+				COALESCE(SUM(oi.quantity * oi.price_at_order), 0) AS sales,
+				o.discount_amount AS discounts,
+				o.total_amount AS revenue,
+				COALESCE(SUM(oi.quantity * oi.unit_cost), 0) AS cogs,
+				0 AS write_offs
+			FROM orders o
+			LEFT JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.created_at >= COALESCE(NULLIF($3, '')::timestamptz, '-infinity')
+			  AND o.created_at < COALESCE(NULLIF($4, '')::timestamptz, 'infinity')
+			GROUP BY o.id
+			UNION ALL
+			SELECT date_trunc($1, t.changed_at), 0, 0, 0, 0, 0, 0, -t.change_amount * i.price
+			FROM inventory_transactions t
+			JOIN inventory i ON i.id = t.inventory_id
+			WHERE t.transaction_type = 'written off'
+			  AND t.changed_at >= COALESCE(NULLIF($3, '')::timestamptz, '-infinity')
+			  AND t.changed_at < COALESCE(NULLIF($4, '')::timestamptz, 'infinity')
+		)
+		SELECT to_char(period, $2), SUM(orders), SUM(units_sold), SUM(sales), SUM(discounts),
+			SUM(revenue), SUM(cogs), SUM(write_offs)
+		FROM entries
+		GROUP BY period
+		ORDER BY period`, period, periodFormats[period], startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []db.PeriodTotals
+	for rows.Next() {
+		var t db.PeriodTotals
+		if err := rows.Scan(&t.Period, &t.Orders, &t.UnitsSold, &t.Sales, &t.Discounts,
+			&t.Revenue, &t.COGS, &t.WriteOffs); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+const promotionColumns = `id, name, discount_type, value, code, usage_limit, times_used,
+	category_id, (SELECT name FROM categories WHERE categories.id = promotions.category_id),
+	menu_item_id, buy_quantity, get_quantity, start_time, end_time,
+	valid_from, expires_at, is_active`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPromotion(row rowScanner) (db.Promotion, error) {
+	var (
+		p                                    db.Promotion
+		code, category, menuItemID           sql.NullString
+		categoryID                           sql.NullInt64
+		startTime, endTime                   sql.NullString
+		usageLimit, buyQuantity, getQuantity sql.NullInt64
+		validFrom, expiresAt                 sql.NullTime
+	)
+	err := row.Scan(
+		&p.ID,
+		&p.Name,
+		&p.DiscountType,
+		&p.Value,
+		&code,
+		&usageLimit,
+		&p.TimesUsed,
+		&categoryID,
+		&category,
+		&menuItemID,
+		&buyQuantity,
+		&getQuantity,
+		&startTime,
+		&endTime,
+		&validFrom,
+		&expiresAt,
+		&p.IsActive,
+	)
+	if err != nil {
+		return p, err
+	}
+	p.Code = code.String
+	p.UsageLimit = int(usageLimit.Int64)
+	p.CategoryID = int(categoryID.Int64)
+	p.Category = category.String
+	p.MenuItemID = menuItemID.String
+	p.BuyQuantity = int(buyQuantity.Int64)
+	p.GetQuantity = int(getQuantity.Int64)
+	p.StartTime = startTime.String
+	p.EndTime = endTime.String
+	if validFrom.Valid {
+		p.ValidFrom = &validFrom.Time
+	}
+	if expiresAt.Valid {
+		p.ExpiresAt = &expiresAt.Time
+	}
+	return p, nil
+}
+
+// promotionArgs returns the column values of p in the order of promotionColumns,
+// without id, times_used and the category name.
+func promotionArgs(p db.Promotion) []interface{} {
+	return []interface{}{
+		p.Name,
+		p.DiscountType,
+		p.Value,
+		nullString(strings.TrimSpace(p.Code)),
+		nullInt(p.UsageLimit),
+		nullInt(p.CategoryID),
+		nullString(p.MenuItemID),
+		nullInt(p.BuyQuantity),
+		nullInt(p.GetQuantity),
+		nullString(p.StartTime),
+		nullString(p.EndTime),
+		p.ValidFrom,
+		p.ExpiresAt,
+		p.IsActive,
+	}
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullInt(n int) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
+
+type promotionRepo struct{ db *sql.DB }
+
+func (r *promotionRepo) Create(ctx context.Context, p db.Promotion) (int, error) {
+	if err := resolveCategory(ctx, r.db, &p.CategoryID, &p.Category); err != nil {
+		return 0, err
+	}
+	var id int
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO promotions (name, discount_type, value, code, usage_limit, category_id, menu_item_id,
+			buy_quantity, get_quantity, start_time, end_time, valid_from, expires_at, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id`, promotionArgs(p)...).Scan(&id)
+	return id, err
+}
+
+func (r *promotionRepo) List(ctx context.Context) ([]db.Promotion, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+promotionColumns+" FROM promotions ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promos []db.Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promos = append(promos, p)
+	}
+	return promos, rows.Err()
+}
+
+func (r *promotionRepo) Get(ctx context.Context, id int) (db.Promotion, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+promotionColumns+" FROM promotions WHERE id = $1", id)
+	p, err := scanPromotion(row)
+	if err == sql.ErrNoRows {
+		return p, repository.NotFound("Promotion not found")
+	}
+	return p, err
+}
+
+func (r *promotionRepo) Update(ctx context.Context, id int, p db.Promotion) error {
+	if err := resolveCategory(ctx, r.db, &p.CategoryID, &p.Category); err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE promotions
+		SET name = $1, discount_type = $2, value = $3, code = $4, usage_limit = $5, category_id = $6,
+			menu_item_id = $7, buy_quantity = $8, get_quantity = $9, start_time = $10, end_time = $11,
+			valid_from = $12, expires_at = $13, is_active = $14
+		WHERE id = $15`, append(promotionArgs(p), id)...)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return repository.NotFound("Promotion not found")
+	}
+	return nil
+}
+
+func (r *promotionRepo) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM promotions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return repository.NotFound("Promotion not found")
+	}
+	return nil
+}
@@ -0,0 +1,447 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+const (
+	purchaseOrderColumns = `po.id, po.supplier_id, s.name, po.status, po.notes,
+		po.created_at, po.updated_at, po.sent_at, po.received_at`
+	purchaseOrderFrom = "purchase_orders po JOIN suppliers s ON s.id = po.supplier_id"
+)
+
+func scanPurchaseOrder(row rowScanner) (db.PurchaseOrder, error) {
+	po := db.PurchaseOrder{Items: make([]db.PurchaseOrderItem, 0)}
+	var notes sql.NullString
+	var sentAt, receivedAt sql.NullTime
+	err := row.Scan(&po.ID, &po.SupplierID, &po.Supplier, &po.Status, &notes,
+		&po.CreatedAt, &po.UpdatedAt, &sentAt, &receivedAt)
+	po.Notes = notes.String
+	if sentAt.Valid {
+		po.SentAt = &sentAt.Time
+	}
+	if receivedAt.Valid {
+		po.ReceivedAt = &receivedAt.Time
+	}
+	return po, err
+}
+
+// fetchPurchaseOrderItems fills in the items and totals of the purchase orders.
+func fetchPurchaseOrderItems(ctx context.Context, q queryer, orders []db.PurchaseOrder) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	index := make(map[int]int, len(orders))
+	ids := make([]int64, 0, len(orders))
+	for i, po := range orders {
+		index[po.ID] = i
+		ids = append(ids, int64(po.ID))
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT poi.purchase_order_id, poi.inventory_id, i.name, poi.quantity, i.unit_type,
+			poi.unit_cost, poi.received_quantity
+		FROM purchase_order_items poi
+		JOIN inventory i ON i.id = poi.inventory_id
+		WHERE poi.purchase_order_id = ANY($1)
+		ORDER BY poi.id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var poID int
+		var item db.PurchaseOrderItem
+		if err := rows.Scan(&poID, &item.InventoryID, &item.Name, &item.Quantity, &item.Unit,
+			&item.UnitCost, &item.ReceivedQuantity); err != nil {
+			return err
+		}
+		po := &orders[index[poID]]
+		po.Items = append(po.Items, item)
+		po.Total += item.Quantity * item.UnitCost
+	}
+	for i := range orders {
+		orders[i].Total = roundMoney(orders[i].Total)
+	}
+	return rows.Err()
+}
+
+// fetchPurchaseOrder returns a purchase order with its items.
+func fetchPurchaseOrder(ctx context.Context, q queryer, id int) (db.PurchaseOrder, error) {
+	po, err := scanPurchaseOrder(q.QueryRowContext(ctx,
+		"SELECT "+purchaseOrderColumns+" FROM "+purchaseOrderFrom+" WHERE po.id = $1", id))
+	if err != nil {
+		return po, err
+	}
+	orders := []db.PurchaseOrder{po}
+	err = fetchPurchaseOrderItems(ctx, q, orders)
+	return orders[0], err
+}
+
+// lockPurchaseOrder locks a purchase order for the rest of the transaction
+// and returns its status.
+func lockPurchaseOrder(ctx context.Context, tx *sql.Tx, id int) (string, error) {
+	var status string
+	err := tx.QueryRowContext(ctx, "SELECT status FROM purchase_orders WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	return status, err
+}
+
+// savePurchaseOrderItems replaces the items of a purchase order. Quantities
+// and costs are converted to the stock unit of each item; a missing cost is
+// the current inventory cost.
+func savePurchaseOrderItems(ctx context.Context, tx *sql.Tx, poID int, items []db.PurchaseOrderItem) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM purchase_order_items WHERE purchase_order_id = $1", poID); err != nil {
+		return err
+	}
+	for _, item := range items {
+		quantity, err := stockQuantity(ctx, tx, item.InventoryID, item.Quantity, item.Unit)
+		if err != nil {
+			return err
+		}
+		cost := item.UnitCost * item.Quantity / quantity
+		if item.UnitCost == 0 {
+			err := tx.QueryRowContext(ctx, "SELECT price FROM inventory WHERE id = $1", item.InventoryID).Scan(&cost)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO purchase_order_items (purchase_order_id, inventory_id, quantity, unit_cost)
+			VALUES ($1, $2, $3, $4)`, poID, item.InventoryID, quantity, cost)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// receiveStock books stock delivered against a purchase order. The inventory
+// cost becomes the weighted average of the stock on hand and the delivery,
+// and batch-tracked items get a new batch.
+func receiveStock(ctx context.Context, q queryer, poID int, inventoryID string, quantity, unitCost float64, expiresOn string) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE inventory SET
+			price = CASE WHEN stock + $2 > 0 THEN (stock * price + $2 * $3) / (stock + $2) ELSE $3 END,
+			stock = stock + $2,
+			last_updated = NOW()
+		WHERE id = $1`, inventoryID, quantity, unitCost)
+	if err != nil {
+		return err
+	}
+	if err := addBatch(ctx, q, inventoryID, quantity, unitCost, expiresOn, poID); err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO inventory_transactions (inventory_id, change_amount, transaction_type, purchase_order_id)
+		VALUES ($1, $2, 'added', $3)`, inventoryID, quantity, poID)
+	return err
+}
+
+// purchaseOrderError maps errors returned while saving a purchase order.
+func purchaseOrderError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return repository.ErrUnknownSupplier
+	}
+	return err
+}
+
+// lockDraft locks a purchase order and checks that it is still a draft.
+func lockDraft(ctx context.Context, tx *sql.Tx, id int, action string) error {
+	status, err := lockPurchaseOrder(ctx, tx, id)
+	if err == sql.ErrNoRows {
+		return repository.NotFound("Purchase order not found")
+	} else if err != nil {
+		return err
+	}
+	if status != "draft" {
+		return repository.Conflict("Only draft purchase orders can be %s", action)
+	}
+	return nil
+}
+
+type purchaseOrderRepo struct{ db *sql.DB }
+
+func (r *purchaseOrderRepo) Create(ctx context.Context, po db.PurchaseOrder) (int, error) {
+	var id int
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO purchase_orders (supplier_id, notes)
+			VALUES ($1, $2)
+			RETURNING id`, po.SupplierID, nullString(po.Notes)).Scan(&id)
+		if err != nil {
+			return purchaseOrderError(err)
+		}
+		return savePurchaseOrderItems(ctx, tx, id, po.Items)
+	})
+	return id, err
+}
+
+// List returns purchase orders, newest first. An empty status and a zero
+// supplierID match every order.
+func (r *purchaseOrderRepo) List(ctx context.Context, status string, supplierID int) ([]db.PurchaseOrder, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+purchaseOrderColumns+` FROM `+purchaseOrderFrom+`
+		WHERE ($1 = '' OR po.status::text = $1)
+		  AND ($2 = 0 OR po.supplier_id = $2)
+		ORDER BY po.created_at DESC, po.id DESC`, status, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]db.PurchaseOrder, 0)
+	for rows.Next() {
+		po, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, po)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	return orders, fetchPurchaseOrderItems(ctx, r.db, orders)
+}
+
+func (r *purchaseOrderRepo) Get(ctx context.Context, id int) (db.PurchaseOrder, error) {
+	po, err := fetchPurchaseOrder(ctx, r.db, id)
+	if err == sql.ErrNoRows {
+		return po, repository.NotFound("Purchase order not found")
+	}
+	return po, err
+}
+
+// Update replaces the supplier, notes and items of a draft.
+func (r *purchaseOrderRepo) Update(ctx context.Context, id int, po db.PurchaseOrder) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockDraft(ctx, tx, id, "changed"); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE purchase_orders SET supplier_id = $2, notes = $3, updated_at = NOW()
+			WHERE id = $1`, id, po.SupplierID, nullString(po.Notes))
+		if err != nil {
+			return purchaseOrderError(err)
+		}
+		return savePurchaseOrderItems(ctx, tx, id, po.Items)
+	})
+}
+
+// Delete deletes a draft purchase order.
+func (r *purchaseOrderRepo) Delete(ctx context.Context, id int) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockDraft(ctx, tx, id, "deleted"); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM purchase_orders WHERE id = $1", id)
+		return err
+	})
+}
+
+// Send marks a draft as sent to the supplier and returns its new status.
+func (r *purchaseOrderRepo) Send(ctx context.Context, id int) (string, error) {
+	var status string
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE purchase_orders SET status = 'sent', sent_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'draft'
+		RETURNING status`, id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", repository.NotFound("Purchase order not found or already sent")
+	}
+	return status, err
+}
+
+// Receive books a delivery against a sent purchase order and returns the
+// updated order. With no items, everything still outstanding is received.
+func (r *purchaseOrderRepo) Receive(ctx context.Context, id int, items []db.PurchaseOrderItem) (db.PurchaseOrder, error) {
+	var po db.PurchaseOrder
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := receivePurchaseOrder(ctx, tx, id, items)
+		if err == sql.ErrNoRows {
+			return repository.NotFound("Purchase order not found")
+		} else if err != nil {
+			return err
+		}
+		po, err = fetchPurchaseOrder(ctx, tx, id)
+		return err
+	})
+	return po, err
+}
+
+// receivePurchaseOrder books a delivery against a sent purchase order. With
+// no items, everything still outstanding is received at the ordered cost.
+// It returns the new status of the order.
+func receivePurchaseOrder(ctx context.Context, tx *sql.Tx, id int, received []db.PurchaseOrderItem) (string, error) {
+	status, err := lockPurchaseOrder(ctx, tx, id)
+	if err != nil {
+		return "", err
+	}
+	if status != "sent" && status != "partially_received" {
+		return "", fmt.Errorf("%w: purchase order is %s", repository.ErrPurchaseOrderStatus, status)
+	}
+
+	type line struct {
+		outstanding, unitCost float64
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT inventory_id, quantity - received_quantity, unit_cost
+		FROM purchase_order_items
+		WHERE purchase_order_id = $1
+		ORDER BY inventory_id
+		FOR UPDATE`, id)
+	if err != nil {
+		return "", err
+	}
+	lines := make(map[string]line)
+	var ids []string
+	for rows.Next() {
+		var inventoryID string
+		var l line
+		if err := rows.Scan(&inventoryID, &l.outstanding, &l.unitCost); err != nil {
+			rows.Close()
+			return "", err
+		}
+		lines[inventoryID] = l
+		ids = append(ids, inventoryID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if len(received) == 0 {
+		for _, inventoryID := range ids {
+			if l := lines[inventoryID]; l.outstanding > 0 {
+				received = append(received, db.PurchaseOrderItem{
+					InventoryID: inventoryID, Quantity: l.outstanding, UnitCost: l.unitCost,
+				})
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range received {
+		l, ok := lines[item.InventoryID]
+		if !ok {
+			return "", fmt.Errorf("%w: item %s is not on this purchase order", repository.ErrInvalidReceipt, item.InventoryID)
+		}
+		if seen[item.InventoryID] {
+			return "", fmt.Errorf("%w: item %s is listed twice", repository.ErrInvalidReceipt, item.InventoryID)
+		}
+		seen[item.InventoryID] = true
+		if item.Quantity <= 0 || item.UnitCost < 0 {
+			return "", fmt.Errorf("%w: quantity must be greater than 0 and unit_cost cannot be negative for item %s",
+				repository.ErrInvalidReceipt, item.InventoryID)
+		}
+		if err := checkExpiry(item.ExpiresOn); err != nil {
+			return "", fmt.Errorf("%w: %v for item %s", repository.ErrInvalidReceipt, err, item.InventoryID)
+		}
+
+		quantity, err := stockQuantity(ctx, tx, item.InventoryID, item.Quantity, item.Unit)
+		if err != nil {
+			return "", err
+		}
+		if quantity > l.outstanding+1e-6 {
+			return "", fmt.Errorf("%w: only %g of item %s is outstanding", repository.ErrInvalidReceipt, l.outstanding, item.InventoryID)
+		}
+		cost := l.unitCost
+		if item.UnitCost > 0 {
+			cost = item.UnitCost * item.Quantity / quantity
+		}
+
+		if err := receiveStock(ctx, tx, id, item.InventoryID, quantity, cost, item.ExpiresOn); err != nil {
+			return "", err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE purchase_order_items SET received_quantity = LEAST(quantity, received_quantity + $3)
+			WHERE purchase_order_id = $1 AND inventory_id = $2`, id, item.InventoryID, quantity)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE purchase_orders po SET
+			status = CASE WHEN complete THEN 'received' ELSE 'partially_received' END::purchase_order_status,
+			received_at = CASE WHEN complete THEN NOW() END,
+			updated_at = NOW()
+		FROM (
+			SELECT bool_and(received_quantity >= quantity) AS complete
+			FROM purchase_order_items WHERE purchase_order_id = $1
+		) r
+		WHERE po.id = $1
+		RETURNING po.status`, id).Scan(&status)
+	return status, err
+}
+
+// Suggested proposes what to order from each supplier: items whose stock plus
+// what is already on order (drafts included) is at or below their reorder
+// level are topped up to their par level, rounded up to whole purchase units.
+func (r *purchaseOrderRepo) Suggested(ctx context.Context) ([]db.SuggestedOrder, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT i.id, i.name, i.stock, COALESCE(o.on_order, 0), i.reorder_level, i.par_level,
+			i.unit_type, i.price, COALESCE(i.supplier_id, 0), COALESCE(s.name, ''),
+			COALESCE(s.lead_time_days, 0),
+			i.purchase_unit, stock_quantity(i.id, i.purchase_quantity, i.purchase_quantity_unit)
+		FROM inventory i
+		LEFT JOIN suppliers s ON s.id = i.supplier_id
+		LEFT JOIN (
+			SELECT poi.inventory_id, SUM(poi.quantity - poi.received_quantity) AS on_order
+			FROM purchase_order_items poi
+			JOIN purchase_orders po ON po.id = poi.purchase_order_id
+			WHERE po.status <> 'received'
+			GROUP BY poi.inventory_id
+		) o ON o.inventory_id = i.id
+		WHERE i.reorder_level IS NOT NULL
+		  AND i.stock + COALESCE(o.on_order, 0) <= i.reorder_level
+		ORDER BY s.name NULLS LAST, i.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]db.SuggestedOrder, 0)
+	for rows.Next() {
+		var item db.SuggestedItem
+		var supplierID, leadTime int
+		var supplier string
+		var purchaseUnit sql.NullString
+		var packSize sql.NullFloat64
+		if err := rows.Scan(&item.InventoryID, &item.Name, &item.Stock, &item.OnOrder,
+			&item.ReorderLevel, &item.ParLevel, &item.Unit, &item.UnitCost,
+			&supplierID, &supplier, &leadTime, &purchaseUnit, &packSize); err != nil {
+			return nil, err
+		}
+
+		item.Quantity = item.ParLevel - item.Stock - item.OnOrder
+		if purchaseUnit.Valid && packSize.Float64 > 0 {
+			item.PurchaseUnit = purchaseUnit.String
+			item.Packs = math.Ceil(item.Quantity/packSize.Float64 - 1e-9)
+			item.Quantity = item.Packs * packSize.Float64
+		}
+
+		if n := len(orders); n == 0 || orders[n-1].SupplierID != supplierID {
+			orders = append(orders, db.SuggestedOrder{
+				SupplierID:   supplierID,
+				Supplier:     supplier,
+				LeadTimeDays: leadTime,
+			})
+		}
+		o := &orders[len(orders)-1]
+		o.Items = append(o.Items, item)
+		o.EstimatedTotal = roundMoney(o.EstimatedTotal + item.Quantity*item.UnitCost)
+	}
+	return orders, rows.Err()
+}
@@ -0,0 +1,248 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+type reportRepo struct{ db *sql.DB }
+
+func (r *reportRepo) TotalSales(ctx context.Context) (float64, error) {
+	var sum sql.NullFloat64
+	err := r.db.QueryRowContext(ctx, "SELECT SUM(total_amount) FROM orders").Scan(&sum)
+	return sum.Float64, err
+}
+
+func (r *reportRepo) PopularItems(ctx context.Context) ([]db.PopularItem, error) {
+	// order_item_sales attributes bundle lines to the items they contain
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT mi.id, mi.name, COALESCE(mi.description, ''),
+			SUM(s.quantity) AS total_quantity,
+			SUM(s.revenue) AS revenue,
+			COALESCE(SUM(s.revenue) FILTER (WHERE s.bundle_id IS NOT NULL), 0) AS bundle_revenue
+		FROM order_item_sales s
+		JOIN menu_items mi ON s.menu_item_id = mi.id
+		GROUP BY mi.id, mi.name, mi.description
+		ORDER BY total_quantity DESC, mi.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []db.PopularItem
+	for rows.Next() {
+		var item db.PopularItem
+		if err := rows.Scan(&item.ID, &item.Name, &item.Description,
+			&item.TotalQuantity, &item.Revenue, &item.BundleRevenue); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *reportRepo) OrderedItemCounts(ctx context.Context, startDate, endDate string) ([]db.OrderedItemCount, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT order_items.menu_item_id, SUM(order_items.quantity) AS total_quantity, orders.created_at
+		FROM order_items
+		JOIN orders ON order_items.order_id = orders.id
+		WHERE orders.created_at BETWEEN $1 AND $2
+		GROUP BY order_items.menu_item_id, orders.created_at`, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []db.OrderedItemCount
+	for rows.Next() {
+		var item db.OrderedItemCount
+		if err := rows.Scan(&item.ID, &item.Total, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *reportRepo) Search(ctx context.Context, q repository.SearchQuery) (db.SearchResults, error) {
+	var results db.SearchResults
+	priced := q.MinPrice > 0 || q.MaxPrice > 0
+	args := []interface{}{q.Text}
+	if priced {
+		args = append(args, q.MinPrice, q.MaxPrice)
+	}
+
+	if q.Menu {
+		query := `
+			SELECT id, name, description, price,
+				(CASE WHEN name ILIKE '%' || $1 || '%' THEN 1 ELSE 0 END) +
+				(CASE WHEN description ILIKE '%' || $1 || '%' THEN 1 ELSE 0 END) AS relevance
+			FROM menu_items
+			WHERE (name ILIKE '%' || $1 || '%' OR description ILIKE '%' || $1 || '%')`
+		if priced {
+			query += " AND price BETWEEN $2 AND $3"
+		}
+		query += " ORDER BY relevance DESC, name ASC LIMIT 10"
+
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return results, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var m db.MenuItemMatch
+			if err := rows.Scan(&m.ID, &m.Name, &m.Description, &m.Price, &m.Relevance); err != nil {
+				return results, err
+			}
+			results.MenuItems = append(results.MenuItems, m)
+		}
+		if err := rows.Err(); err != nil {
+			return results, err
+		}
+	}
+
+	if q.Orders {
+		query := `
+			SELECT o.id, c.name, array_agg(mi.name) AS items, o.total_amount,
+				(CASE WHEN c.name ILIKE '%' || $1 || '%' THEN 1 ELSE 0 END) AS relevance
+			FROM orders o
+			JOIN customers c ON o.customer_id = c.id
+			JOIN order_items oi ON o.id = oi.order_id
+			JOIN menu_items mi ON oi.menu_item_id = mi.id
+			WHERE c.name ILIKE '%' || $1 || '%'`
+		if priced {
+			query += " AND o.total_amount BETWEEN $2 AND $3"
+		}
+		query += " GROUP BY o.id, c.name, o.total_amount ORDER BY relevance DESC, o.id ASC LIMIT 10"
+
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return results, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var m db.OrderMatch
+			if err := rows.Scan(&m.ID, &m.CustomerName, pq.Array(&m.Items), &m.Total, &m.Relevance); err != nil {
+				return results, err
+			}
+			results.Orders = append(results.Orders, m)
+		}
+		if err := rows.Err(); err != nil {
+			return results, err
+		}
+	}
+
+	results.TotalMatches = len(results.MenuItems) + len(results.Orders)
+	return results, nil
+}
+
+func (r *reportRepo) OrderCountsByDay(ctx context.Context, month int) (map[int]int, error) {
+	return r.orderCounts(ctx, `
+		SELECT EXTRACT(DAY FROM created_at)::int AS day, COUNT(*)
+		FROM orders
+		WHERE EXTRACT(MONTH FROM created_at) = $1
+		GROUP BY day`, month)
+}
+
+func (r *reportRepo) OrderCountsByMonth(ctx context.Context, year int) (map[int]int, error) {
+	return r.orderCounts(ctx, `
+		SELECT EXTRACT(MONTH FROM created_at)::int AS month, COUNT(*)
+		FROM orders
+		WHERE EXTRACT(YEAR FROM created_at) = $1
+		GROUP BY month`, year)
+}
+
+func (r *reportRepo) orderCounts(ctx context.Context, query string, arg int) (map[int]int, error) {
+	rows, err := r.db.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int)
+	for rows.Next() {
+		var period, count int
+		if err := rows.Scan(&period, &count); err != nil {
+			return nil, err
+		}
+		counts[period] = count
+	}
+	return counts, rows.Err()
+}
+
+func (r *reportRepo) Leftovers(ctx context.Context, sortBy string, limit, offset int) ([]db.Leftover, int, error) {
+	query := "SELECT name, stock, price FROM inventory"
+	switch sortBy {
+	case "price":
+		query += " ORDER BY price DESC"
+	case "quantity":
+		query += " ORDER BY stock DESC"
+	default:
+		query += " ORDER BY name"
+	}
+	query += fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var items []db.Leftover
+	for rows.Next() {
+		var item db.Leftover
+		if err := rows.Scan(&item.Name, &item.Quantity, &item.Price); err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM inventory").Scan(&total)
+	return items, total, err
+}
+
+func (r *reportRepo) DiscountUsage(ctx context.Context, startDate, endDate string) ([]db.PromotionUsage, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.id, p.name, COALESCE(p.code, ''), p.discount_type,
+			COUNT(op.id) AS times_applied,
+			COALESCE(SUM(op.discount_amount), 0) AS total_discount,
+			COALESCE(SUM(o.total_amount), 0) AS discounted_revenue,
+			p.usage_limit
+		FROM promotions p
+		LEFT JOIN order_promotions op ON op.promotion_id = p.id
+			AND op.applied_at >= COALESCE(NULLIF($1, '')::timestamptz, '-infinity')
+			AND op.applied_at < COALESCE(NULLIF($2, '')::timestamptz, 'infinity')
+		LEFT JOIN orders o ON o.id = op.order_id
+		GROUP BY p.id, p.name, p.code, p.discount_type, p.usage_limit
+		ORDER BY total_discount DESC, p.id`, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := make([]db.PromotionUsage, 0)
+	for rows.Next() {
+		var u db.PromotionUsage
+		var usageLimit sql.NullInt64
+		if err := rows.Scan(&u.PromotionID, &u.Name, &u.Code, &u.DiscountType,
+			&u.TimesApplied, &u.TotalDiscount, &u.DiscountedRevenue, &usageLimit); err != nil {
+			return nil, err
+		}
+		if usageLimit.Valid {
+			limit := int(usageLimit.Int64)
+			u.UsageLimit = &limit
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"frappuccino/internal/repository"
+)
+
+// DeductStock takes the usage out of inventory and records a sale transaction
+// for every ingredient; batch-tracked ingredients are taken from their
+// batches. It fails with ErrInsufficientStock when any ingredient
+// would go negative.
+func (t *orderTx) DeductStock(ctx context.Context, usage map[string]float64) error {
+	// Lock rows in a stable order so concurrent orders cannot deadlock.
+	ids := make([]string, 0, len(usage))
+	for id := range usage {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		needed := usage[id]
+		var tracked bool
+		err := t.tx.QueryRowContext(ctx, `
+			UPDATE inventory SET stock = stock - $1, last_updated = NOW()
+			WHERE id = $2 AND stock >= $1
+			RETURNING track_batches`, needed, id).Scan(&tracked)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: ingredient %s", repository.ErrInsufficientStock, id)
+		} else if err != nil {
+			return err
+		}
+		if tracked {
+			if err := consumeBatches(ctx, t.tx, id, needed); err != nil {
+				return err
+			}
+		}
+
+		_, err = t.tx.ExecContext(ctx, `
+			INSERT INTO inventory_transactions (inventory_id, change_amount, transaction_type)
+			VALUES ($1, $2, 'sale')`, id, -needed)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// lockStockTake locks a stock take for the rest of the transaction and
+// returns its status.
+func lockStockTake(ctx context.Context, tx *sql.Tx, id int) (string, error) {
+	var status string
+	err := tx.QueryRowContext(ctx, "SELECT status FROM stock_takes WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	return status, err
+}
+
+// fetchStockTake returns a stock take with its variance lines and totals
+// valued at cost. Open stock takes compare against current stock and cost;
+// committed ones use the values kept at commit.
+func fetchStockTake(ctx context.Context, q queryer, id int) (db.StockTake, error) {
+	var st db.StockTake
+	var notes sql.NullString
+	var committedAt sql.NullTime
+	err := q.QueryRowContext(ctx,
+		"SELECT id, status, notes, opened_at, committed_at FROM stock_takes WHERE id = $1", id,
+	).Scan(&st.ID, &st.Status, &notes, &st.OpenedAt, &committedAt)
+	if err != nil {
+		return st, err
+	}
+	st.Notes = notes.String
+	if committedAt.Valid {
+		st.CommittedAt = &committedAt.Time
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT c.inventory_id, i.name, i.unit_type, COALESCE(c.system_quantity, i.stock),
+			c.counted_quantity, COALESCE(c.unit_cost, i.price)
+		FROM stock_take_counts c
+		JOIN inventory i ON i.id = c.inventory_id
+		WHERE c.stock_take_id = $1
+		ORDER BY i.name, c.inventory_id`, id)
+	if err != nil {
+		return st, err
+	}
+	defer rows.Close()
+
+	st.Lines = make([]db.StockTakeLine, 0)
+	for rows.Next() {
+		var l db.StockTakeLine
+		if err := rows.Scan(&l.InventoryID, &l.Name, &l.Unit, &l.SystemQuantity,
+			&l.CountedQuantity, &l.UnitCost); err != nil {
+			return st, err
+		}
+		l.Variance = math.Round((l.CountedQuantity-l.SystemQuantity)*100) / 100
+		l.VarianceValue = roundMoney(l.Variance * l.UnitCost)
+		if l.VarianceValue < 0 {
+			st.ShortageValue -= l.VarianceValue
+		} else {
+			st.SurplusValue += l.VarianceValue
+		}
+		st.Lines = append(st.Lines, l)
+	}
+	st.ItemsCounted = len(st.Lines)
+	st.ShortageValue = roundMoney(st.ShortageValue)
+	st.SurplusValue = roundMoney(st.SurplusValue)
+	st.NetVariance = roundMoney(st.SurplusValue - st.ShortageValue)
+	return st, rows.Err()
+}
+
+// commitStockTake sets the stock of every counted item to its count, keeps
+// the system quantity and cost for the variance report and posts
+// 'adjustment' transactions for the differences.
+func commitStockTake(ctx context.Context, tx *sql.Tx, id int) error {
+	// Lock counted items in a stable order so concurrent sales cannot deadlock
+	rows, err := tx.QueryContext(ctx, `
+		SELECT i.id, i.stock, i.price, c.counted_quantity
+		FROM stock_take_counts c
+		JOIN inventory i ON i.id = c.inventory_id
+		WHERE c.stock_take_id = $1
+		ORDER BY i.id
+		FOR UPDATE OF i`, id)
+	if err != nil {
+		return err
+	}
+	type count struct {
+		inventoryID            string
+		stock, price, quantity float64
+	}
+	var counts []count
+	for rows.Next() {
+		var c count
+		if err := rows.Scan(&c.inventoryID, &c.stock, &c.price, &c.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		counts = append(counts, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range counts {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE stock_take_counts SET system_quantity = $3, unit_cost = $4
+			WHERE stock_take_id = $1 AND inventory_id = $2`, id, c.inventoryID, c.stock, c.price)
+		if err != nil {
+			return err
+		}
+		if c.quantity == c.stock {
+			continue
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE inventory SET stock = $2, last_updated = NOW()
+			WHERE id = $1`, c.inventoryID, c.quantity)
+		if err != nil {
+			return err
+		}
+		if err := syncBatches(ctx, tx, c.inventoryID); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO inventory_transactions (inventory_id, change_amount, transaction_type, stock_take_id)
+			VALUES ($1, $2, 'adjustment', $3)`, c.inventoryID, c.quantity-c.stock, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE stock_takes SET status = 'committed', committed_at = NOW() WHERE id = $1", id)
+	return err
+}
+
+// lockOpenStockTake locks a stock take and checks that it is still open.
+func lockOpenStockTake(ctx context.Context, tx *sql.Tx, id int, committed string) error {
+	status, err := lockStockTake(ctx, tx, id)
+	if err == sql.ErrNoRows {
+		return repository.NotFound("Stock take not found")
+	} else if err != nil {
+		return err
+	}
+	if status != "open" {
+		return repository.Conflict("%s", committed)
+	}
+	return nil
+}
+
+type stockTakeRepo struct{ db *sql.DB }
+
+// Open starts a count. Only one stock take can be open at a time.
+func (r *stockTakeRepo) Open(ctx context.Context, notes string) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO stock_takes (notes) VALUES ($1) RETURNING id", nullString(notes)).Scan(&id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return 0, repository.Conflict("Another stock take is already open")
+	}
+	return id, err
+}
+
+// List returns stock takes, newest first, without their lines.
+func (r *stockTakeRepo) List(ctx context.Context) ([]db.StockTake, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id FROM stock_takes ORDER BY opened_at DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stockTakes := make([]db.StockTake, 0, len(ids))
+	for _, id := range ids {
+		st, err := fetchStockTake(ctx, r.db, id)
+		if err != nil {
+			return nil, err
+		}
+		st.Lines = nil
+		stockTakes = append(stockTakes, st)
+	}
+	return stockTakes, nil
+}
+
+// Get returns a stock take with its variances: a preview while it is open
+// and the variance report once committed.
+func (r *stockTakeRepo) Get(ctx context.Context, id int) (db.StockTake, error) {
+	st, err := fetchStockTake(ctx, r.db, id)
+	if err == sql.ErrNoRows {
+		return st, repository.NotFound("Stock take not found")
+	}
+	return st, err
+}
+
+// SubmitCounts records counted quantities on an open stock take. Counting an
+// item again replaces its earlier count.
+func (r *stockTakeRepo) SubmitCounts(ctx context.Context, id int, counts []db.StockCount) (db.StockTake, error) {
+	var st db.StockTake
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockOpenStockTake(ctx, tx, id, "Stock take is already committed"); err != nil {
+			return err
+		}
+		for _, c := range counts {
+			quantity, err := stockQuantity(ctx, tx, c.InventoryID, c.Quantity, c.Unit)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO stock_take_counts (stock_take_id, inventory_id, counted_quantity)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (stock_take_id, inventory_id)
+				DO UPDATE SET counted_quantity = EXCLUDED.counted_quantity, counted_at = NOW()`,
+				id, c.InventoryID, quantity)
+			if err != nil {
+				return err
+			}
+		}
+		var err error
+		st, err = fetchStockTake(ctx, tx, id)
+		return err
+	})
+	return st, err
+}
+
+// Commit applies the counts of an open stock take to inventory and returns
+// the variance report.
+func (r *stockTakeRepo) Commit(ctx context.Context, id int) (db.StockTake, error) {
+	var st db.StockTake
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockOpenStockTake(ctx, tx, id, "Stock take is already committed"); err != nil {
+			return err
+		}
+		if err := commitStockTake(ctx, tx, id); err != nil {
+			return err
+		}
+		var err error
+		st, err = fetchStockTake(ctx, tx, id)
+		return err
+	})
+	return st, err
+}
+
+// Delete discards an open stock take and its counts.
+func (r *stockTakeRepo) Delete(ctx context.Context, id int) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockOpenStockTake(ctx, tx, id, "Committed stock takes cannot be deleted"); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM stock_takes WHERE id = $1", id)
+		return err
+	})
+}
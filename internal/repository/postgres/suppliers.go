@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+const supplierColumns = "id, name, contact_name, email, phone, lead_time_days, created_at"
+
+func scanSupplier(row rowScanner) (db.Supplier, error) {
+	var s db.Supplier
+	var contactName, email, phone sql.NullString
+	err := row.Scan(&s.ID, &s.Name, &contactName, &email, &phone, &s.LeadTimeDays, &s.CreatedAt)
+	s.ContactName = contactName.String
+	s.Email = email.String
+	s.Phone = phone.String
+	return s, err
+}
+
+type supplierRepo struct{ db *sql.DB }
+
+func (r *supplierRepo) Create(ctx context.Context, s db.Supplier) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO suppliers (name, contact_name, email, phone, lead_time_days)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		s.Name, nullString(s.ContactName), nullString(s.Email), nullString(s.Phone), s.LeadTimeDays,
+	).Scan(&id)
+	return id, supplierError(err, s.Name)
+}
+
+func (r *supplierRepo) List(ctx context.Context) ([]db.Supplier, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+supplierColumns+" FROM suppliers ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := make([]db.Supplier, 0)
+	for rows.Next() {
+		s, err := scanSupplier(rows)
+		if err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, s)
+	}
+	return suppliers, rows.Err()
+}
+
+func (r *supplierRepo) Get(ctx context.Context, id int) (db.Supplier, error) {
+	s, err := scanSupplier(r.db.QueryRowContext(ctx,
+		"SELECT "+supplierColumns+" FROM suppliers WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return s, repository.NotFound("Supplier not found")
+	}
+	return s, err
+}
+
+func (r *supplierRepo) Update(ctx context.Context, id int, s db.Supplier) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE suppliers SET name = $2, contact_name = $3, email = $4, phone = $5, lead_time_days = $6
+		WHERE id = $1`,
+		id, s.Name, nullString(s.ContactName), nullString(s.Email), nullString(s.Phone), s.LeadTimeDays)
+	if err != nil {
+		return supplierError(err, s.Name)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return repository.NotFound("Supplier not found")
+	}
+	return nil
+}
+
+func (r *supplierRepo) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM suppliers WHERE id = $1", id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return repository.Conflict("Supplier still has purchase orders")
+	} else if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return repository.NotFound("Supplier not found")
+	}
+	return nil
+}
+
+func supplierError(err error, name string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return repository.Conflict("Supplier %s already exists", name)
+	}
+	return err
+}
@@ -0,0 +1,281 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// fetchUnit returns a unit of the registry. It fails with ErrInvalidUnit when
+// the unit does not exist.
+func fetchUnit(ctx context.Context, q queryer, code string) (db.Unit, error) {
+	u := db.Unit{Code: strings.ToLower(strings.TrimSpace(code))}
+	err := q.QueryRowContext(ctx,
+		"SELECT name, dimension, factor FROM units WHERE code = $1", u.Code,
+	).Scan(&u.Name, &u.Dimension, &u.Factor)
+	if err == sql.ErrNoRows {
+		return u, fmt.Errorf("%w: unknown unit %q", repository.ErrInvalidUnit, code)
+	}
+	return u, err
+}
+
+// convertQuantity converts a quantity between two units of the same dimension.
+func convertQuantity(ctx context.Context, q queryer, quantity float64, from, to string) (float64, error) {
+	f, err := fetchUnit(ctx, q, from)
+	if err != nil {
+		return 0, err
+	}
+	t, err := fetchUnit(ctx, q, to)
+	if err != nil {
+		return 0, err
+	}
+	if f.Dimension != t.Dimension {
+		return 0, fmt.Errorf("%w: cannot convert %s (%s) to %s (%s)", repository.ErrInvalidUnit, f.Code, f.Dimension, t.Code, t.Dimension)
+	}
+	return quantity * f.Factor / t.Factor, nil
+}
+
+// recipeUnit checks that a recipe may measure the ingredient in unit and
+// returns the unit to store, defaulting to the ingredient's stock unit.
+func recipeUnit(ctx context.Context, q queryer, ingredientID, unit string) (string, error) {
+	var stockUnit, dimension string
+	err := q.QueryRowContext(ctx, `
+		SELECT u.code, u.dimension
+		FROM inventory i
+		JOIN units u ON u.code = i.unit_type
+		WHERE i.id = $1`, ingredientID).Scan(&stockUnit, &dimension)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("%w: %s", repository.ErrUnknownIngredient, ingredientID)
+	} else if err != nil {
+		return "", err
+	}
+	if unit == "" {
+		return stockUnit, nil
+	}
+
+	u, err := fetchUnit(ctx, q, unit)
+	if err != nil {
+		return "", err
+	}
+	if u.Dimension != dimension {
+		return "", fmt.Errorf("%w: ingredient %s is stocked in %s and cannot be measured in %s",
+			repository.ErrInvalidUnit, ingredientID, stockUnit, u.Code)
+	}
+	return u.Code, nil
+}
+
+// stockQuantity converts a quantity of an inventory item given in unit, which
+// is either a unit of the registry or the item's purchase unit, to its stock
+// unit. An empty unit is the stock unit.
+func stockQuantity(ctx context.Context, q queryer, inventoryID string, quantity float64, unit string) (float64, error) {
+	var stockUnit string
+	var purchaseUnit, purchaseQuantityUnit sql.NullString
+	var purchaseQuantity sql.NullFloat64
+	err := q.QueryRowContext(ctx, `
+		SELECT unit_type, purchase_unit, purchase_quantity, purchase_quantity_unit
+		FROM inventory WHERE id = $1`, inventoryID,
+	).Scan(&stockUnit, &purchaseUnit, &purchaseQuantity, &purchaseQuantityUnit)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w: %s", repository.ErrUnknownIngredient, inventoryID)
+	} else if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case unit == "":
+		return quantity, nil
+	case purchaseUnit.Valid && strings.EqualFold(unit, purchaseUnit.String):
+		return convertQuantity(ctx, q, quantity*purchaseQuantity.Float64, purchaseQuantityUnit.String, stockUnit)
+	default:
+		return convertQuantity(ctx, q, quantity, unit, stockUnit)
+	}
+}
+
+// validatePurchaseUnit checks that a purchase unit holds a positive quantity
+// of something compatible with the stock unit.
+func validatePurchaseUnit(ctx context.Context, q queryer, stockUnit string, p *db.PurchaseUnit) error {
+	if p == nil {
+		return nil
+	}
+	if p.Name == "" {
+		return repository.Invalid("purchase_unit name is required")
+	}
+	if p.Quantity <= 0 {
+		return repository.Invalid("purchase_unit quantity must be greater than 0")
+	}
+	if p.Unit == "" {
+		p.Unit = stockUnit
+	}
+	if _, err := convertQuantity(ctx, q, p.Quantity, p.Unit, stockUnit); err != nil {
+		return err
+	}
+	p.Unit = strings.ToLower(strings.TrimSpace(p.Unit))
+	return nil
+}
+
+// changeStockUnit prepares an inventory item for a new stock unit. Recipes
+// that relied on the old stock unit get it written out so they keep their
+// meaning, and a unit of another dimension is refused while recipes use the
+// item. What else is kept in the stock unit is converted as described at
+// convertStockRecords. It returns sql.ErrNoRows when the item does not exist.
+func changeStockUnit(ctx context.Context, q queryer, inventoryID, unit string) error {
+	var current string
+	err := q.QueryRowContext(ctx,
+		"SELECT unit_type FROM inventory WHERE id = $1 FOR UPDATE", inventoryID).Scan(&current)
+	if err != nil {
+		return err
+	}
+	if current == unit {
+		return nil
+	}
+
+	var incompatible []string
+	rows, err := q.QueryContext(ctx, `
+		SELECT DISTINCT r.unit
+		FROM (
+			SELECT COALESCE(unit, $3) AS unit FROM product_ingredients WHERE ingredient_id = $1
+			UNION ALL
+			SELECT COALESCE(unit, $3) FROM menu_item_ingredients WHERE ingredient_id = $1
+			UNION ALL
+			SELECT COALESCE(unit, $3) FROM modifier_ingredients WHERE ingredient_id = $1
+		) r
+		JOIN units u ON u.code = r.unit
+		WHERE u.dimension <> (SELECT dimension FROM units WHERE code = $2)
+		ORDER BY r.unit`, inventoryID, unit, current)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			return err
+		}
+		incompatible = append(incompatible, u)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(incompatible) > 0 {
+		return repository.Conflict("recipes measure ingredient %s in %s, which cannot be converted to %s",
+			inventoryID, strings.Join(incompatible, ", "), unit)
+	}
+
+	for _, table := range []string{"product_ingredients", "menu_item_ingredients", "modifier_ingredients"} {
+		_, err := q.ExecContext(ctx,
+			"UPDATE "+table+" SET unit = $2 WHERE ingredient_id = $1 AND unit IS NULL", inventoryID, current)
+		if err != nil {
+			return err
+		}
+	}
+	return convertStockRecords(ctx, q, inventoryID, current, unit)
+}
+
+// stockRecords are the records other than the stock itself that are kept in
+// the stock unit of an item: what they are called, their table and the
+// column naming the item, the quantity columns, the column of a cost per
+// stock unit, and the rows that still matter for a unit of another
+// dimension.
+var stockRecords = []struct {
+	name, table, key string
+	quantities       []string
+	cost, inUse      string
+}{
+	{"batches", "inventory_batches", "inventory_id",
+		[]string{"quantity", "received_quantity"}, "unit_cost",
+		"quantity > 0"},
+	{"open purchase orders", "purchase_order_items", "inventory_id",
+		[]string{"quantity", "received_quantity"}, "unit_cost",
+		"purchase_order_id IN (SELECT id FROM purchase_orders WHERE status <> 'received')"},
+	{"open stock take counts", "stock_take_counts", "inventory_id",
+		[]string{"counted_quantity", "system_quantity"}, "unit_cost",
+		"stock_take_id IN (SELECT id FROM stock_takes WHERE status = 'open')"},
+	{"reorder levels", "inventory", "id",
+		[]string{"reorder_level", "par_level"}, "",
+		"reorder_level IS NOT NULL"},
+	{"stock history", "inventory_transactions", "inventory_id",
+		[]string{"change_amount"}, "",
+		"FALSE"},
+}
+
+// convertStockRecords rewrites the stock records of an item from the stock
+// unit from to the unit to, so that receiving an open purchase order or
+// syncing the batches with the stock afterwards adds like to like. A unit of
+// another dimension is refused while any record still matters; the ones
+// that do not, such as used up batches and the history, are left as they
+// were.
+func convertStockRecords(ctx context.Context, q queryer, inventoryID, from, to string) error {
+	factor, err := convertQuantity(ctx, q, 1, from, to)
+	if errors.Is(err, repository.ErrInvalidUnit) {
+		for _, r := range stockRecords {
+			var inUse bool
+			err := q.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+r.table+
+				" WHERE "+r.key+" = $1 AND "+r.inUse+")", inventoryID).Scan(&inUse)
+			if err != nil {
+				return err
+			}
+			if inUse {
+				return repository.Conflict("%s of ingredient %s are kept in %s, which cannot be converted to %s",
+					r.name, inventoryID, from, to)
+			}
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, r := range stockRecords {
+		var set []string
+		for _, c := range r.quantities {
+			set = append(set, c+" = "+c+" * $2")
+		}
+		if r.cost != "" {
+			set = append(set, r.cost+" = "+r.cost+" / $2")
+		}
+		_, err := q.ExecContext(ctx, "UPDATE "+r.table+" SET "+strings.Join(set, ", ")+
+			" WHERE "+r.key+" = $1", inventoryID, factor)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type unitRepo struct{ db *sql.DB }
+
+func (r *unitRepo) List(ctx context.Context) ([]db.Unit, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT code, name, dimension, factor FROM units ORDER BY dimension, factor, code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := make([]db.Unit, 0)
+	for rows.Next() {
+		var u db.Unit
+		if err := rows.Scan(&u.Code, &u.Name, &u.Dimension, &u.Factor); err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+	return units, rows.Err()
+}
+
+func (r *unitRepo) Create(ctx context.Context, u db.Unit) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO units (code, name, dimension, factor) VALUES ($1, $2, $3, $4)",
+		u.Code, u.Name, u.Dimension, u.Factor)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return repository.Conflict("Unit %s already exists", u.Code)
+	}
+	return err
+}
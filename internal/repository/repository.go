@@ -0,0 +1,295 @@
+// Package repository defines how the service layer stores and loads data.
+// The interfaces are implemented on PostgreSQL by package postgres and in
+// memory by package memory; errors the caller should report to the client
+// are *Error values.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"frappuccino/internal/db"
+)
+
+// Repositories bundles one implementation of every repository.
+type Repositories struct {
+	Orders         OrderRepository
+	Menu           MenuRepository
+	Inventory      InventoryRepository
+	Customers      CustomerRepository
+	Categories     CategoryRepository
+	Products       ProductRepository
+	Modifiers      ModifierRepository
+	Promotions     PromotionRepository
+	Allergens      AllergenRepository
+	Units          UnitRepository
+	Suppliers      SupplierRepository
+	PurchaseOrders PurchaseOrderRepository
+	StockTakes     StockTakeRepository
+	Reports        ReportRepository
+}
+
+type OrderRepository interface {
+	List(ctx context.Context) ([]db.Order, error)
+	Get(ctx context.Context, id int) (db.Order, error)
+	Delete(ctx context.Context, id int) error
+	// Tx runs fn in a transaction. What fn changes through the OrderTx is
+	// kept only when fn returns nil.
+	Tx(ctx context.Context, fn func(OrderTx) error) error
+}
+
+// OrderTx is the storage the order service works with while it places,
+// updates and closes orders, within one transaction.
+type OrderTx interface {
+	OrderStore
+	Catalog
+	PromotionLedger
+	LoyaltyLedger
+	StockLedger
+	// Savepoint runs fn so that its failure undoes only what fn changed:
+	// failed is the error of fn, and the transaction goes on. err reports a
+	// failure of the savepoint itself.
+	Savepoint(ctx context.Context, fn func() error) (failed, err error)
+}
+
+// OrderStore reads and writes orders and finds their customers.
+type OrderStore interface {
+	// LockOrder returns an order with its lines and keeps it from changing
+	// until the transaction ends.
+	LockOrder(ctx context.Context, id int) (db.Order, []OrderLine, error)
+	// InsertOrder stores a new order with its lines, recording the current
+	// ingredient cost of each line, and returns its id.
+	InsertOrder(ctx context.Context, o db.Order, lines []OrderLine) (int, error)
+	// UpdateOrder replaces the columns of an order and records a change of
+	// status in its history.
+	UpdateOrder(ctx context.Context, id int, o db.Order) error
+	// CustomerByName returns the id of the first customer with the name. It
+	// fails with ErrUnknownCustomer when there is none.
+	CustomerByName(ctx context.Context, name string) (int, error)
+}
+
+// Catalog describes menu items as they are sold.
+type Catalog interface {
+	// SaleItem fails with ErrUnknownMenuItem when the menu item does not exist.
+	SaleItem(ctx context.Context, menuItemID string) (SaleItem, error)
+}
+
+// PromotionLedger finds the promotions an order can get and counts their use.
+type PromotionLedger interface {
+	// ActivePromotions returns the promotions valid at the moment that are
+	// applied automatically, and the one with code if it is set. It fails
+	// with ErrInvalidPromoCode when no promotion has the code and with
+	// ErrPromoCodeUsedUp when the one that has it reached its usage limit.
+	ActivePromotions(ctx context.Context, code string, at time.Time) ([]db.Promotion, error)
+	// UsePromotions records the promotions applied to an order and counts
+	// their use. It fails with ErrPromoCodeUsedUp when a promotion has
+	// reached its usage limit.
+	UsePromotions(ctx context.Context, orderID int, applied []db.AppliedPromotion) error
+}
+
+// LoyaltyLedger holds the loyalty transactions of customers.
+type LoyaltyLedger interface {
+	// LoyaltyBalance returns a customer's points and punches and keeps
+	// other transactions from spending them until this one ends. It fails
+	// with ErrUnknownCustomer when the customer does not exist.
+	LoyaltyBalance(ctx context.Context, customerID int) (points, punches int, err error)
+	// OrderLoyalty returns the loyalty transactions recorded for an order.
+	OrderLoyalty(ctx context.Context, orderID int) ([]db.LoyaltyTransaction, error)
+	// AddLoyalty records a loyalty transaction of a customer. An order earns
+	// once: another 'earned' transaction for it is ignored.
+	AddLoyalty(ctx context.Context, customerID int, t db.LoyaltyTransaction) error
+}
+
+// StockLedger takes what is sold out of inventory.
+type StockLedger interface {
+	// DeductStock takes the quantities, in stock units, out of inventory as
+	// a sale. It fails with ErrInsufficientStock when any ingredient would
+	// go negative.
+	DeductStock(ctx context.Context, usage map[string]float64) error
+}
+
+// SaleItem is a menu item as it is sold: its price, where it sits in the
+// menu and what it takes from stock.
+type SaleItem struct {
+	ID          string
+	Price       float64
+	Size        string
+	Categories  []string // names of the item's category and its parents
+	CategoryIDs []int    // ids of the same categories
+	// Recipe is what one unit takes from stock, in stock units.
+	Recipe map[string]float64
+	// ModifierGroups are the groups attached to the item, with the
+	// ingredient deltas of their modifiers in stock units.
+	ModifierGroups []db.ModifierGroup
+	// Components are the slots of a bundle; other items have none.
+	Components []db.BundleComponent
+}
+
+// OrderLine is a line of an order. Categories holds the names of the menu
+// item's category and its parents. UnitPrice includes the price deltas of
+// the selected Modifiers. Usage is what one unit takes from stock, in stock
+// units, and is only set on lines being sold. Parts is set for bundles.
+type OrderLine struct {
+	MenuItemID string
+	Categories []string
+	Quantity   int
+	UnitPrice  float64
+	Modifiers  []db.Modifier
+	Parts      []BundlePart
+	Usage      map[string]float64
+}
+
+// BundlePart is a menu item sold as part of a bundle line, with the number
+// sold over the whole line and its share of the line's revenue.
+type BundlePart struct {
+	MenuItemID string
+	Quantity   int
+	Revenue    float64
+}
+
+// BulkOrder is one order of a bulk request. The customer is found by name
+// and must exist.
+type BulkOrder struct {
+	CustomerName string
+	Items        []db.OrderItem
+}
+
+// BulkResult is the outcome of one BulkOrder.
+type BulkResult struct {
+	OrderID  int
+	Total    float64
+	Discount float64
+	Usage    map[string]float64 // stock taken per ingredient
+	Err      error
+}
+
+type MenuRepository interface {
+	List(ctx context.Context, f MenuFilter) ([]db.MenuProduct, error)
+	Get(ctx context.Context, id string) (db.MenuItem, error)
+	Create(ctx context.Context, item db.MenuItem) (string, error)
+	// Update replaces a menu item; bundle components are only replaced when
+	// Components is not nil.
+	Update(ctx context.Context, id string, item db.MenuItem) error
+	Delete(ctx context.Context, id string) error
+	Labels(ctx context.Context) ([]db.MenuLabel, error)
+}
+
+// MenuFilter narrows the menu to a category and its subcategories and drops
+// items containing any of the allergens.
+type MenuFilter struct {
+	CategoryID       int
+	ExcludeAllergens []string
+}
+
+type InventoryRepository interface {
+	List(ctx context.Context) ([]db.Inventory, error)
+	Get(ctx context.Context, id string) (db.Inventory, error)
+	Create(ctx context.Context, item db.Inventory) (string, error)
+	Update(ctx context.Context, id string, item db.Inventory) error
+	Delete(ctx context.Context, id string) error
+	Restock(ctx context.Context, id string, r db.Restock) (db.RestockResult, error)
+	ExpiringBatches(ctx context.Context, days int) ([]db.InventoryBatch, error)
+	WriteOffBatch(ctx context.Context, id int, quantity float64) (db.InventoryBatch, error)
+}
+
+type CustomerRepository interface {
+	Create(ctx context.Context, c db.Customer) (int, error)
+	Get(ctx context.Context, id int) (db.Customer, error)
+	// Loyalty returns the points and punch balances of a customer with the
+	// history of loyalty transactions, newest first.
+	Loyalty(ctx context.Context, customerID int) (db.LoyaltyAccount, error)
+}
+
+type CategoryRepository interface {
+	Tree(ctx context.Context) ([]db.Category, error)
+	Get(ctx context.Context, id int) (db.Category, error)
+	Create(ctx context.Context, c db.Category) (int, error)
+	Update(ctx context.Context, id int, c db.Category) error
+	Delete(ctx context.Context, id int) error
+}
+
+type ProductRepository interface {
+	Get(ctx context.Context, id int) (db.MenuProduct, error)
+	Create(ctx context.Context, p db.MenuProduct) (int, error)
+	Update(ctx context.Context, id int, p db.MenuProduct) error
+	Delete(ctx context.Context, id int) error
+}
+
+type ModifierRepository interface {
+	List(ctx context.Context, menuItemID string) ([]db.ModifierGroup, error)
+	Create(ctx context.Context, g db.ModifierGroup) (int, error)
+	Update(ctx context.Context, id int, g db.ModifierGroup) error
+	Delete(ctx context.Context, id int) error
+}
+
+type PromotionRepository interface {
+	List(ctx context.Context) ([]db.Promotion, error)
+	Get(ctx context.Context, id int) (db.Promotion, error)
+	Create(ctx context.Context, p db.Promotion) (int, error)
+	Update(ctx context.Context, id int, p db.Promotion) error
+	Delete(ctx context.Context, id int) error
+}
+
+type AllergenRepository interface {
+	List(ctx context.Context) ([]db.Allergen, error)
+	Create(ctx context.Context, a db.Allergen) error
+}
+
+type UnitRepository interface {
+	List(ctx context.Context) ([]db.Unit, error)
+	Create(ctx context.Context, u db.Unit) error
+}
+
+type SupplierRepository interface {
+	List(ctx context.Context) ([]db.Supplier, error)
+	Get(ctx context.Context, id int) (db.Supplier, error)
+	Create(ctx context.Context, s db.Supplier) (int, error)
+	Update(ctx context.Context, id int, s db.Supplier) error
+	Delete(ctx context.Context, id int) error
+}
+
+type PurchaseOrderRepository interface {
+	List(ctx context.Context, status string, supplierID int) ([]db.PurchaseOrder, error)
+	Get(ctx context.Context, id int) (db.PurchaseOrder, error)
+	Create(ctx context.Context, po db.PurchaseOrder) (int, error)
+	Update(ctx context.Context, id int, po db.PurchaseOrder) error
+	Delete(ctx context.Context, id int) error
+	Send(ctx context.Context, id int) (string, error)
+	Receive(ctx context.Context, id int, items []db.PurchaseOrderItem) (db.PurchaseOrder, error)
+	Suggested(ctx context.Context) ([]db.SuggestedOrder, error)
+}
+
+type StockTakeRepository interface {
+	List(ctx context.Context) ([]db.StockTake, error)
+	Get(ctx context.Context, id int) (db.StockTake, error)
+	Open(ctx context.Context, notes string) (int, error)
+	SubmitCounts(ctx context.Context, id int, counts []db.StockCount) (db.StockTake, error)
+	Commit(ctx context.Context, id int) (db.StockTake, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type ReportRepository interface {
+	TotalSales(ctx context.Context) (float64, error)
+	PopularItems(ctx context.Context) ([]db.PopularItem, error)
+	OrderedItemCounts(ctx context.Context, startDate, endDate string) ([]db.OrderedItemCount, error)
+	Search(ctx context.Context, q SearchQuery) (db.SearchResults, error)
+	OrderCountsByDay(ctx context.Context, month int) (map[int]int, error)
+	OrderCountsByMonth(ctx context.Context, year int) (map[int]int, error)
+	Leftovers(ctx context.Context, sortBy string, limit, offset int) ([]db.Leftover, int, error)
+	DiscountUsage(ctx context.Context, startDate, endDate string) ([]db.PromotionUsage, error)
+	CurrentMargins(ctx context.Context) ([]db.ItemMargin, error)
+	SoldMargins(ctx context.Context, startDate, endDate string) ([]db.SoldMargin, error)
+	TradingTotals(ctx context.Context, period, startDate, endDate string) ([]db.PeriodTotals, error)
+	// DailyUsage returns today's date and, per ingredient, the quantity used
+	// on each of the last history days, oldest first.
+	DailyUsage(ctx context.Context, history int) (time.Time, map[string][]float64, error)
+	StockLevels(ctx context.Context) ([]db.StockLevel, error)
+}
+
+// SearchQuery is a full-text search over the menu and orders. A zero price
+// range matches any price.
+type SearchQuery struct {
+	Text               string
+	MinPrice, MaxPrice float64
+	Menu, Orders       bool
+}
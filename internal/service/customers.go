@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+type Customers struct {
+	repo repository.CustomerRepository
+}
+
+func NewCustomers(repo repository.CustomerRepository) *Customers {
+	return &Customers{repo: repo}
+}
+
+// Create adds a customer. Emails are unique; a customer may have none.
+func (s *Customers) Create(ctx context.Context, c db.Customer) (int, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Email = strings.TrimSpace(c.Email)
+	if err := validate.Struct(c).Err(); err != nil {
+		return 0, err
+	}
+	return s.repo.Create(ctx, c)
+}
+
+func (s *Customers) Get(ctx context.Context, id int) (db.Customer, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// Loyalty returns a customer's loyalty account with the value of the points
+// balance and the coffees left until the next free one.
+func (s *Customers) Loyalty(ctx context.Context, customerID int) (db.LoyaltyAccount, error) {
+	account, err := s.repo.Loyalty(ctx, customerID)
+	if err != nil {
+		return account, err
+	}
+	account.BalanceValue = roundMoney(float64(account.Balance) * db.PointValue)
+	if account.Punches < db.PunchCardSize-1 {
+		account.PunchesToReward = db.PunchCardSize - 1 - account.Punches
+	}
+	return account, nil
+}
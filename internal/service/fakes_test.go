@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// fakeOrders is an OrderRepository and OrderTx over plain maps. A failed
+// transaction or savepoint puts the orders, stock and loyalty ledger back.
+type fakeOrders struct {
+	items     map[string]repository.SaleItem
+	customers map[int]string
+	promos    []db.Promotion
+	stock     map[string]float64
+	orders    map[int]fakeOrder
+	loyalty   map[int][]db.LoyaltyTransaction // by customer
+	used      map[int]int                     // promotion uses by id
+	lastID    int
+}
+
+type fakeOrder struct {
+	db.Order
+	Lines []repository.OrderLine
+}
+
+func newFakeOrders() *fakeOrders {
+	return &fakeOrders{
+		items:     make(map[string]repository.SaleItem),
+		customers: map[int]string{1: "John Smith", 2: "Emily Johnson"},
+		stock:     make(map[string]float64),
+		orders:    make(map[int]fakeOrder),
+		loyalty:   make(map[int][]db.LoyaltyTransaction),
+		used:      make(map[int]int),
+	}
+}
+
+type fakeState struct {
+	stock   map[string]float64
+	orders  map[int]fakeOrder
+	loyalty map[int][]db.LoyaltyTransaction
+	used    map[int]int
+}
+
+func (f *fakeOrders) save() fakeState {
+	s := fakeState{
+		stock:   make(map[string]float64),
+		orders:  make(map[int]fakeOrder),
+		loyalty: make(map[int][]db.LoyaltyTransaction),
+		used:    make(map[int]int),
+	}
+	for k, v := range f.stock {
+		s.stock[k] = v
+	}
+	for k, v := range f.orders {
+		s.orders[k] = v
+	}
+	for k, v := range f.loyalty {
+		s.loyalty[k] = append([]db.LoyaltyTransaction(nil), v...)
+	}
+	for k, v := range f.used {
+		s.used[k] = v
+	}
+	return s
+}
+
+func (f *fakeOrders) restore(s fakeState) {
+	f.stock, f.orders, f.loyalty, f.used = s.stock, s.orders, s.loyalty, s.used
+}
+
+func (f *fakeOrders) List(ctx context.Context) ([]db.Order, error) {
+	var orders []db.Order
+	for _, o := range f.orders {
+		orders = append(orders, o.Order)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders, nil
+}
+
+func (f *fakeOrders) Get(ctx context.Context, id int) (db.Order, error) {
+	o, ok := f.orders[id]
+	if !ok {
+		return db.Order{}, repository.NotFound("Order not found")
+	}
+	return o.Order, nil
+}
+
+func (f *fakeOrders) Delete(ctx context.Context, id int) error {
+	if _, ok := f.orders[id]; !ok {
+		return repository.NotFound("Order not found")
+	}
+	delete(f.orders, id)
+	return nil
+}
+
+func (f *fakeOrders) Tx(ctx context.Context, fn func(repository.OrderTx) error) error {
+	s := f.save()
+	if err := fn(f); err != nil {
+		f.restore(s)
+		return err
+	}
+	return nil
+}
+
+func (f *fakeOrders) Savepoint(ctx context.Context, fn func() error) (failed, err error) {
+	s := f.save()
+	if failed = fn(); failed != nil {
+		f.restore(s)
+	}
+	return failed, nil
+}
+
+func (f *fakeOrders) LockOrder(ctx context.Context, id int) (db.Order, []repository.OrderLine, error) {
+	o, ok := f.orders[id]
+	if !ok {
+		return db.Order{}, nil, repository.NotFound("Order not found")
+	}
+	return o.Order, o.Lines, nil
+}
+
+func (f *fakeOrders) InsertOrder(ctx context.Context, o db.Order, lines []repository.OrderLine) (int, error) {
+	if _, ok := f.customers[o.CustomerID]; !ok {
+		return 0, repository.ErrUnknownCustomer
+	}
+	f.lastID++
+	o.ID = f.lastID
+	f.orders[o.ID] = fakeOrder{Order: o, Lines: lines}
+	return o.ID, nil
+}
+
+func (f *fakeOrders) UpdateOrder(ctx context.Context, id int, o db.Order) error {
+	stored, ok := f.orders[id]
+	if !ok {
+		return repository.NotFound("Order not found")
+	}
+	o.ID = id
+	stored.Order = o
+	f.orders[id] = stored
+	return nil
+}
+
+func (f *fakeOrders) CustomerByName(ctx context.Context, name string) (int, error) {
+	for id, n := range f.customers {
+		if n == name {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", repository.ErrUnknownCustomer, name)
+}
+
+func (f *fakeOrders) SaleItem(ctx context.Context, menuItemID string) (repository.SaleItem, error) {
+	item, ok := f.items[menuItemID]
+	if !ok {
+		return item, fmt.Errorf("%w: %s", repository.ErrUnknownMenuItem, menuItemID)
+	}
+	item.ID = menuItemID
+	return item, nil
+}
+
+func (f *fakeOrders) ActivePromotions(ctx context.Context, code string, at time.Time) ([]db.Promotion, error) {
+	var promos []db.Promotion
+	found := false
+	for _, p := range f.promos {
+		if p.Code == "" || p.Code == code {
+			promos = append(promos, p)
+			found = found || p.Code != ""
+		}
+	}
+	if code != "" && !found {
+		return nil, repository.ErrInvalidPromoCode
+	}
+	return promos, nil
+}
+
+func (f *fakeOrders) UsePromotions(ctx context.Context, orderID int, applied []db.AppliedPromotion) error {
+	for _, a := range applied {
+		for _, p := range f.promos {
+			if p.ID == a.PromotionID && p.UsageLimit > 0 && f.used[p.ID] >= p.UsageLimit {
+				return repository.ErrPromoCodeUsedUp
+			}
+		}
+		f.used[a.PromotionID]++
+	}
+	return nil
+}
+
+func (f *fakeOrders) LoyaltyBalance(ctx context.Context, customerID int) (points, punches int, err error) {
+	if _, ok := f.customers[customerID]; !ok {
+		return 0, 0, repository.ErrUnknownCustomer
+	}
+	for _, t := range f.loyalty[customerID] {
+		points += t.Points
+		punches += t.Punches
+	}
+	return points, punches, nil
+}
+
+func (f *fakeOrders) OrderLoyalty(ctx context.Context, orderID int) ([]db.LoyaltyTransaction, error) {
+	var history []db.LoyaltyTransaction
+	for _, ts := range f.loyalty {
+		for _, t := range ts {
+			if t.OrderID != nil && *t.OrderID == orderID {
+				history = append(history, t)
+			}
+		}
+	}
+	return history, nil
+}
+
+func (f *fakeOrders) AddLoyalty(ctx context.Context, customerID int, t db.LoyaltyTransaction) error {
+	f.loyalty[customerID] = append(f.loyalty[customerID], t)
+	return nil
+}
+
+func (f *fakeOrders) DeductStock(ctx context.Context, usage map[string]float64) error {
+	for id, quantity := range usage {
+		if f.stock[id] < quantity {
+			return fmt.Errorf("%w: ingredient %s", repository.ErrInsufficientStock, id)
+		}
+		f.stock[id] -= quantity
+	}
+	return nil
+}
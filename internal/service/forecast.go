@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+type Forecasts struct {
+	reports repository.ReportRepository
+}
+
+func NewForecasts(reports repository.ReportRepository) *Forecasts {
+	return &Forecasts{reports: reports}
+}
+
+// Demand projects the consumption of every ingredient over the next days
+// from the last history days of sales, and compares it with the stock on
+// hand. It returns the first day of the forecast and the ingredients, those
+// that run out soonest first.
+func (s *Forecasts) Demand(ctx context.Context, days, history int) (time.Time, []db.IngredientForecast, error) {
+	today, usage, err := s.reports.DailyUsage(ctx, history)
+	if err != nil {
+		return today, nil, err
+	}
+	levels, err := s.reports.StockLevels(ctx)
+	if err != nil {
+		return today, nil, err
+	}
+
+	forecasts := make([]db.IngredientForecast, 0, len(levels))
+	for _, l := range levels {
+		f := db.IngredientForecast{InventoryID: l.InventoryID, Name: l.Name, Unit: l.Unit, Stock: l.Stock}
+		series := usage[f.InventoryID]
+		if series == nil {
+			series = make([]float64, history)
+		}
+
+		var total, recent float64
+		for i, v := range series {
+			total += v
+			if i >= history-7 {
+				recent += v
+			}
+		}
+		f.AverageDaily = math.Round(math.Max(total, 0)/float64(history)*100) / 100
+		f.MovingAverage = math.Round(math.Max(recent, 0)/7*100) / 100
+
+		f.Forecast = forecastUsage(series, today, days)
+		for _, d := range f.Forecast {
+			f.ForecastTotal += d.Quantity
+		}
+		f.DaysOfCover, f.StockoutDate = daysOfCover(f.Stock, f.Forecast)
+		f.Short = f.Stock < f.ForecastTotal
+		for i := range f.Forecast {
+			f.Forecast[i].Quantity = math.Round(f.Forecast[i].Quantity*100) / 100
+		}
+		f.ForecastTotal = math.Round(f.ForecastTotal*100) / 100
+		forecasts = append(forecasts, f)
+	}
+
+	sort.SliceStable(forecasts, func(i, j int) bool {
+		a, b := forecasts[i].DaysOfCover, forecasts[j].DaysOfCover
+		switch {
+		case a != nil && b != nil && *a != *b:
+			return *a < *b
+		case (a == nil) != (b == nil):
+			return a != nil
+		}
+		return forecasts[i].Name < forecasts[j].Name
+	})
+	return today, forecasts, nil
+}
+
+// forecastUsage projects daily usage from a history ending yesterday. Each
+// weekday gets a seasonal factor, its average usage relative to the overall
+// average; the level is the 7-day moving average with those factors taken
+// out, and day i of the forecast is the level times its weekday's factor.
+func forecastUsage(series []float64, today time.Time, days int) []db.DailyForecast {
+	history := len(series)
+	start := today.AddDate(0, 0, -history)
+
+	var total float64
+	var weekdayTotal, weekdayCount [7]float64
+	for i, v := range series {
+		v = math.Max(v, 0)
+		wd := start.AddDate(0, 0, i).Weekday()
+		total += v
+		weekdayTotal[wd] += v
+		weekdayCount[wd]++
+	}
+
+	var factor [7]float64
+	mean := total / float64(history)
+	for wd := range factor {
+		factor[wd] = 1
+		if mean > 0 && weekdayCount[wd] > 0 {
+			factor[wd] = weekdayTotal[wd] / weekdayCount[wd] / mean
+		}
+	}
+
+	var recent, recentFactors float64
+	for i := max(history-7, 0); i < history; i++ {
+		recent += math.Max(series[i], 0)
+		recentFactors += factor[start.AddDate(0, 0, i).Weekday()]
+	}
+	var level float64
+	if recentFactors > 0 {
+		level = recent / recentFactors
+	}
+
+	forecast := make([]db.DailyForecast, days)
+	for i := range forecast {
+		day := today.AddDate(0, 0, i)
+		forecast[i] = db.DailyForecast{
+			Date:     day.Format("2006-01-02"),
+			Quantity: level * factor[day.Weekday()],
+		}
+	}
+	return forecast
+}
+
+// daysOfCover returns how many days the stock lasts at the forecast usage,
+// with the day it runs out when that falls within the forecast. Beyond the
+// forecast the average forecast usage is assumed.
+func daysOfCover(stock float64, forecast []db.DailyForecast) (*float64, string) {
+	var total float64
+	for _, f := range forecast {
+		total += f.Quantity
+	}
+	if total <= 0 {
+		return nil, ""
+	}
+
+	left := stock
+	for i, f := range forecast {
+		if f.Quantity > 0 && left < f.Quantity {
+			cover := math.Round((float64(i)+left/f.Quantity)*10) / 10
+			return &cover, f.Date
+		}
+		left -= f.Quantity
+	}
+	cover := float64(len(forecast)) + left/(total/float64(len(forecast)))
+	cover = math.Round(cover*10) / 10
+	return &cover, ""
+}
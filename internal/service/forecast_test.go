@@ -0,0 +1,102 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"frappuccino/internal/db"
+)
+
+// quantities returns the forecast quantities rounded to 1e-9.
+func quantities(forecast []db.DailyForecast) []float64 {
+	q := make([]float64, len(forecast))
+	for i, f := range forecast {
+		q[i] = math.Round(f.Quantity*1e9) / 1e9
+	}
+	return q
+}
+
+func TestForecastUsage(t *testing.T) {
+	today := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC) // a Monday
+
+	// Two weeks of 10 a day with 20 on Mondays: Mondays weigh 1.75, the
+	// other days 0.875 of the mean.
+	weekly := make([]float64, 14)
+	for i := range weekly {
+		weekly[i] = 10
+	}
+	weekly[0], weekly[7] = 20, 20
+
+	tests := []struct {
+		name   string
+		series []float64
+		days   int
+		want   []float64
+	}{
+		{"empty series", nil, 3, []float64{0, 0, 0}},
+		{"zero usage", make([]float64, 14), 3, []float64{0, 0, 0}},
+		// Friday to Sunday used 1, 2 and 3: the level is 2 and weekdays
+		// without history keep a factor of 1.
+		{"shorter than a week", []float64{1, 2, 3}, 7, []float64{2, 2, 2, 2, 1, 2, 3}},
+		{"weekday factor", weekly, 8, []float64{20, 10, 10, 10, 10, 10, 10, 20}},
+		// A correction on Monday does not make the Monday forecast negative
+		{"negative usage counts as none", []float64{-5, 0, 0, 0, 0, 0, 7}, 7, []float64{0, 0, 0, 0, 0, 0, 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast := forecastUsage(tt.series, today, tt.days)
+			got := quantities(forecast)
+			if len(got) != len(tt.want) {
+				t.Fatalf("forecast = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("forecast = %v, want %v", got, tt.want)
+				}
+			}
+			if forecast[0].Date != "2026-10-19" {
+				t.Errorf("forecast starts on %s, want today", forecast[0].Date)
+			}
+		})
+	}
+}
+
+func TestDaysOfCover(t *testing.T) {
+	forecast := func(quantities ...float64) []db.DailyForecast {
+		f := make([]db.DailyForecast, len(quantities))
+		for i, q := range quantities {
+			f[i] = db.DailyForecast{Date: time.Date(2026, 10, 19+i, 0, 0, 0, 0, time.UTC).Format("2006-01-02"), Quantity: q}
+		}
+		return f
+	}
+
+	tests := []struct {
+		name     string
+		stock    float64
+		forecast []db.DailyForecast
+		cover    float64 // -1 for none
+		stockout string
+	}{
+		{"no forecast", 10, nil, -1, ""},
+		{"no usage", 10, forecast(0, 0, 0), -1, ""},
+		{"runs out within the forecast", 25, forecast(10, 10, 10), 2.5, "2026-10-21"},
+		{"out of stock", 0, forecast(10, 10, 10), 0, "2026-10-19"},
+		{"skips days without usage", 5, forecast(0, 10, 10), 1.5, "2026-10-20"},
+		{"lasts beyond the forecast", 45, forecast(10, 10, 10), 4.5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cover, stockout := daysOfCover(tt.stock, tt.forecast)
+			switch {
+			case tt.cover < 0 && cover != nil:
+				t.Errorf("cover = %v, want none", *cover)
+			case tt.cover >= 0 && (cover == nil || *cover != tt.cover):
+				t.Errorf("cover = %v, want %v", cover, tt.cover)
+			}
+			if stockout != tt.stockout {
+				t.Errorf("stockout = %q, want %q", stockout, tt.stockout)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+type Inventory struct {
+	repo repository.InventoryRepository
+}
+
+func NewInventory(repo repository.InventoryRepository) *Inventory {
+	return &Inventory{repo: repo}
+}
+
+func (s *Inventory) List(ctx context.Context) ([]db.Inventory, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Inventory) Get(ctx context.Context, id string) (db.Inventory, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *Inventory) Create(ctx context.Context, item db.Inventory) (string, error) {
+	errs := validateInventoryItem(item)
+	if item.ID == "" {
+		errs.Add("id", "is required")
+	}
+	if err := errs.Err(); err != nil {
+		return "", err
+	}
+	if item.Nutrition == nil {
+		item.Nutrition = &db.Nutrition{}
+	}
+	item.Allergens = NormalizeAllergens(item.Allergens)
+	return s.repo.Create(ctx, item)
+}
+
+// Update replaces an inventory item. Nutrition, allergens, the reorder
+// policy and batch tracking keep their current values when omitted.
+func (s *Inventory) Update(ctx context.Context, id string, item db.Inventory) error {
+	if err := validateInventoryItem(item).Err(); err != nil {
+		return err
+	}
+	if item.Allergens != nil {
+		item.Allergens = NormalizeAllergens(item.Allergens)
+	}
+	return s.repo.Update(ctx, id, item)
+}
+
+func (s *Inventory) Delete(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Restock adds stock received for an item. The amount may be given in any
+// compatible unit or in the item's purchase unit.
+func (s *Inventory) Restock(ctx context.Context, id string, r db.Restock) (db.RestockResult, error) {
+	if err := validate.Struct(r).Err(); err != nil {
+		return db.RestockResult{}, err
+	}
+	return s.repo.Restock(ctx, id, r)
+}
+
+// ExpiringBatches returns the batches expiring within days days and the
+// value of the stock left in them.
+func (s *Inventory) ExpiringBatches(ctx context.Context, days int) ([]db.InventoryBatch, float64, error) {
+	if days < 0 {
+		return nil, 0, repository.Invalid("days must be a non-negative integer")
+	}
+	batches, err := s.repo.ExpiringBatches(ctx, days)
+	if err != nil {
+		return nil, 0, err
+	}
+	var value float64
+	for _, b := range batches {
+		value += b.Quantity * b.UnitCost
+	}
+	return batches, roundMoney(value), nil
+}
+
+// WriteOffBatch writes off quantity of a batch, or all of it when quantity
+// is 0.
+func (s *Inventory) WriteOffBatch(ctx context.Context, id int, quantity float64) (db.InventoryBatch, error) {
+	if quantity < 0 {
+		return db.InventoryBatch{}, repository.Invalid("quantity cannot be negative")
+	}
+	return s.repo.WriteOffBatch(ctx, id, quantity)
+}
+
+// validateInventoryItem checks an item against its tags and the rules of
+// its reorder policy.
+func validateInventoryItem(item db.Inventory) validate.Errors {
+	errs := validate.Struct(item)
+	if p := item.Reorder; p != nil {
+		// A zero par level turns reordering off
+		if p.ParLevel == 0 && p.Level > 0 {
+			errs.Add("reorder.par_level", "is required with a level")
+		} else if p.ParLevel < p.Level {
+			errs.Add("reorder.par_level", "cannot be below the level")
+		}
+	}
+	return errs
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// redeemLoyalty applies the punch-card reward and the requested points to an
+// order that is about to be placed by a customer with the given points and
+// punches. maxDiscount is the amount still payable after promotions.
+func redeemLoyalty(points, punches, redeemPoints int, lines []repository.OrderLine, maxDiscount float64) (db.LoyaltyRedemption, error) {
+	var redemption db.LoyaltyRedemption
+	if redeemPoints < 0 {
+		return redemption, repository.ErrInvalidRedeemAmount
+	}
+
+	// Every full card of paid coffees makes the cheapest coffee on the order free.
+	var coffees []float64
+	for _, l := range lines {
+		if inCategory(l, db.PunchCardCategory) {
+			for i := 0; i < l.Quantity; i++ {
+				coffees = append(coffees, l.UnitPrice)
+			}
+		}
+	}
+	sort.Float64s(coffees)
+	remaining := maxDiscount
+	for _, price := range coffees {
+		if punches < db.PunchCardSize-1 || remaining <= 0 {
+			break
+		}
+		discount := math.Min(price, remaining)
+		punches -= db.PunchCardSize - 1
+		remaining = roundMoney(remaining - discount)
+		redemption.FreeCoffees++
+		redemption.RewardDiscount = roundMoney(redemption.RewardDiscount + discount)
+	}
+
+	if redeemPoints > 0 {
+		if redeemPoints > points {
+			return redemption, fmt.Errorf("%w: balance is %d", repository.ErrInsufficientPoints, points)
+		}
+		discount := math.Min(float64(redeemPoints)*db.PointValue, remaining)
+		redemption.PointsDiscount = roundMoney(discount)
+		redemption.PointsRedeemed = int(math.Ceil(redemption.PointsDiscount/db.PointValue - 1e-9))
+	}
+	return redemption, nil
+}
+
+// redemptionTransactions returns the ledger entries for a redemption made on
+// an order.
+func redemptionTransactions(orderID int, redemption db.LoyaltyRedemption) []db.LoyaltyTransaction {
+	var entries []db.LoyaltyTransaction
+	if redemption.FreeCoffees > 0 {
+		entries = append(entries, db.LoyaltyTransaction{
+			OrderID:         &orderID,
+			TransactionType: "reward",
+			Punches:         -redemption.FreeCoffees * (db.PunchCardSize - 1),
+		})
+	}
+	if redemption.PointsRedeemed > 0 {
+		entries = append(entries, db.LoyaltyTransaction{
+			OrderID:         &orderID,
+			TransactionType: "redeemed",
+			Points:          -redemption.PointsRedeemed,
+		})
+	}
+	return entries
+}
+
+// earnedLoyalty returns the points and punches a closed order earns. Coffees
+// that were given away by the punch card, rewardPunches worth of them, do
+// not earn new punches.
+func earnedLoyalty(total float64, lines []repository.OrderLine, rewardPunches int) (points, punches int) {
+	var coffees int
+	for _, l := range lines {
+		if inCategory(l, db.PunchCardCategory) {
+			coffees += l.Quantity
+		}
+	}
+	points = int(math.Floor(total * db.PointsPerCurrencyUnit))
+	punches = coffees - rewardPunches/(db.PunchCardSize-1)
+	if punches < 0 {
+		punches = 0
+	}
+	return points, punches
+}
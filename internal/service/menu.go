@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+type Menu struct {
+	repo repository.MenuRepository
+}
+
+func NewMenu(repo repository.MenuRepository) *Menu {
+	return &Menu{repo: repo}
+}
+
+// List returns the menu grouped by product. Allergen codes are matched
+// case-insensitively.
+func (s *Menu) List(ctx context.Context, f repository.MenuFilter) ([]db.MenuProduct, error) {
+	if f.ExcludeAllergens != nil {
+		f.ExcludeAllergens = NormalizeAllergens(f.ExcludeAllergens)
+	}
+	return s.repo.List(ctx, f)
+}
+
+func (s *Menu) Get(ctx context.Context, id string) (db.MenuItem, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// Create adds a menu item, either a new product or a size variant of
+// product_id. A missing recipe_scale means the product recipe as it is.
+func (s *Menu) Create(ctx context.Context, item db.MenuItem) (string, error) {
+	errs := validate.Struct(item)
+	if item.ProductID == 0 && item.Name == "" {
+		errs.Add("name", "is required")
+	}
+	validateBundleComponents(&errs, item.ID, item.Components)
+	if err := errs.Err(); err != nil {
+		return "", err
+	}
+	if item.RecipeScale == 0 {
+		item.RecipeScale = 1
+	}
+	return s.repo.Create(ctx, item)
+}
+
+func (s *Menu) Update(ctx context.Context, id string, item db.MenuItem) error {
+	errs := validate.Struct(item)
+	if item.Name == "" {
+		errs.Add("name", "is required")
+	}
+	if item.CategoryID == 0 && item.Category == "" {
+		errs.Add("category_id", "is required")
+	}
+	validateBundleComponents(&errs, id, item.Components)
+	if err := errs.Err(); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, id, item)
+}
+
+func (s *Menu) Delete(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Labels returns the allergen and nutrition labels of the whole menu.
+func (s *Menu) Labels(ctx context.Context) ([]db.MenuLabel, error) {
+	return s.repo.Labels(ctx)
+}
+
+// validateBundleComponents checks what the tags of bundle components cannot:
+// a component is either a fixed item or a choice. That the items and
+// categories they refer to exist is left to the repository.
+func validateBundleComponents(errs *validate.Errors, bundleID string, components []db.BundleComponent) {
+	for i, c := range components {
+		field := fmt.Sprintf("components[%d]", i)
+		isChoice := c.ChoiceCategoryID != 0 || c.ChoiceCategory != ""
+		switch {
+		case (c.MenuItemID == "") == !isChoice:
+			errs.Add(field, "needs either menu_item_id or choice_category")
+		case c.MenuItemID != "" && c.MenuItemID == bundleID:
+			errs.Add(field+".menu_item_id", "cannot be the bundle itself")
+		case c.ChoiceSize != "" && !isChoice:
+			errs.Add(field+".choice_size", "is only allowed for choice components")
+		}
+	}
+}
+
+// NormalizeAllergens lowercases and trims allergen codes, drops empty and
+// duplicate ones and sorts them.
+func NormalizeAllergens(codes []string) []string {
+	seen := make(map[string]bool, len(codes))
+	normalized := make([]string, 0, len(codes))
+	for _, code := range codes {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		normalized = append(normalized, code)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
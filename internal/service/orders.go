@@ -0,0 +1,305 @@
+// Package service holds the business rules of the API: it validates requests
+// and applies defaults before handing them to the repositories, and prices
+// orders, with their promotions, loyalty and stock usage, and forecasts
+// ingredient demand on top of the storage the repositories offer.
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+	"frappuccino/internal/validate"
+)
+
+type Orders struct {
+	repo repository.OrderRepository
+	loc  *time.Location // time zone of the daily promotion windows
+}
+
+// NewOrders returns the order service. Promotion time windows such as a
+// 15:00-17:00 happy hour are read as wall-clock times in loc.
+func NewOrders(repo repository.OrderRepository, loc *time.Location) *Orders {
+	return &Orders{repo: repo, loc: loc}
+}
+
+// now returns the current time in the time zone of the shop.
+func (s *Orders) now() time.Time {
+	return time.Now().In(s.loc)
+}
+
+func (s *Orders) List(ctx context.Context) ([]db.Order, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Orders) Get(ctx context.Context, id int) (db.Order, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// Create places an order. Orders with items are priced from the menu and go
+// through promotions and loyalty redemptions; orders without items are
+// stored with the total_amount given and take no promo code or points.
+func (s *Orders) Create(ctx context.Context, o db.Order) (db.OrderReceipt, error) {
+	errs := validate.Struct(o)
+	if len(o.Items) == 0 {
+		if o.TotalAmount == 0 {
+			errs.Add("total_amount", "must be greater than 0")
+		}
+		// Without items there is nothing to price, so nothing to discount
+		if o.PromoCode != "" {
+			errs.Add("promo_code", "needs an order with items")
+		}
+		if o.RedeemPoints > 0 {
+			errs.Add("redeem_points", "needs an order with items")
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return db.OrderReceipt{}, err
+	}
+	if o.Status == "" {
+		o.Status = "open"
+	}
+
+	var receipt db.OrderReceipt
+	err := s.repo.Tx(ctx, func(tx repository.OrderTx) error {
+		var err error
+		if len(o.Items) > 0 {
+			receipt, err = place(ctx, tx, o, s.now())
+			return err
+		}
+		receipt.OrderID, err = tx.InsertOrder(ctx, o, nil)
+		receipt.TotalAmount = o.TotalAmount
+		return err
+	})
+	return receipt, err
+}
+
+// place prices the order lines from the menu, applies promotions and loyalty
+// redemptions and stores the order with its lines and the applied
+// promotions. The ingredients used are taken out of stock.
+func place(ctx context.Context, tx repository.OrderTx, o db.Order, now time.Time) (db.OrderReceipt, error) {
+	var receipt db.OrderReceipt
+	lines, err := saleLines(ctx, tx, o.Items)
+	if err != nil {
+		return receipt, err
+	}
+	promos, err := tx.ActivePromotions(ctx, o.PromoCode, now)
+	if err != nil {
+		return receipt, err
+	}
+	applied, discount := applyPromotions(lines, promos, now)
+	subtotal := linesSubtotal(lines)
+
+	points, punches, err := tx.LoyaltyBalance(ctx, o.CustomerID)
+	if err != nil {
+		return receipt, err
+	}
+	redemption, err := redeemLoyalty(points, punches, o.RedeemPoints, lines, roundMoney(subtotal-discount))
+	if err != nil {
+		return receipt, err
+	}
+	discount = roundMoney(discount + redemption.Discount())
+	o.TotalAmount = roundMoney(subtotal - discount)
+	o.DiscountAmount = discount
+
+	orderID, err := tx.InsertOrder(ctx, o, lines)
+	if err != nil {
+		return receipt, err
+	}
+	if err := tx.DeductStock(ctx, ingredientUsage(lines)); err != nil {
+		return receipt, err
+	}
+	if err := tx.UsePromotions(ctx, orderID, applied); err != nil {
+		return receipt, err
+	}
+	for _, t := range redemptionTransactions(orderID, redemption) {
+		if err := tx.AddLoyalty(ctx, o.CustomerID, t); err != nil {
+			return receipt, err
+		}
+	}
+
+	return db.OrderReceipt{
+		OrderID:           orderID,
+		TotalAmount:       o.TotalAmount,
+		DiscountAmount:    discount,
+		AppliedPromotions: applied,
+		Loyalty:           redemption,
+	}, nil
+}
+
+// Update replaces an order and records a change of status in its history. A
+// total of 0 is kept, e.g. for an order paid in full with discounts. Closing
+// an open order this way earns loyalty like Close does; a closed order cannot
+// be reopened.
+func (s *Orders) Update(ctx context.Context, id int, o db.Order) error {
+	if err := validate.Struct(o).Err(); err != nil {
+		return err
+	}
+	if o.Status == "" {
+		o.Status = "open"
+	}
+	return s.repo.Tx(ctx, func(tx repository.OrderTx) error {
+		previous, lines, err := tx.LockOrder(ctx, id)
+		if err != nil {
+			return err
+		}
+		if previous.Status == "closed" && o.Status != "closed" {
+			return errReopen
+		}
+		if err := tx.UpdateOrder(ctx, id, o); err != nil {
+			return err
+		}
+		if previous.Status == "open" && o.Status == "closed" {
+			return earnLoyalty(ctx, tx, id, o.CustomerID, o.TotalAmount, lines)
+		}
+		return nil
+	})
+}
+
+// errReopen refuses to reopen a closed order, which has earned its loyalty.
+var errReopen = repository.Conflict("Closed orders cannot be reopened")
+
+func (s *Orders) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Close closes an open order; closed orders earn loyalty points and
+// punch-card stamps.
+func (s *Orders) Close(ctx context.Context, id int) error {
+	return s.repo.Tx(ctx, func(tx repository.OrderTx) error {
+		o, lines, err := tx.LockOrder(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) || err == nil && o.Status != "open" {
+			return repository.NotFound("Order not found or already closed")
+		} else if err != nil {
+			return err
+		}
+		o.Status = "closed"
+		if err := tx.UpdateOrder(ctx, id, o); err != nil {
+			return err
+		}
+		return earnLoyalty(ctx, tx, id, o.CustomerID, o.TotalAmount, lines)
+	})
+}
+
+// earnLoyalty credits points and punches for a closed order. An order earns
+// once: closing it again credits nothing.
+func earnLoyalty(ctx context.Context, tx repository.OrderTx, orderID, customerID int, total float64, lines []repository.OrderLine) error {
+	history, err := tx.OrderLoyalty(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	var rewardPunches int
+	for _, t := range history {
+		switch t.TransactionType {
+		case "earned":
+			return nil
+		case "reward":
+			rewardPunches -= t.Punches
+		}
+	}
+
+	points, punches := earnedLoyalty(total, lines, rewardPunches)
+	if points == 0 && punches == 0 {
+		return nil
+	}
+	return tx.AddLoyalty(ctx, customerID, db.LoyaltyTransaction{
+		OrderID:         &orderID,
+		TransactionType: "earned",
+		Points:          points,
+		Punches:         punches,
+	})
+}
+
+// PlaceBulk places the orders one by one, each under its own savepoint so a
+// rejected order does not undo the ones accepted before it. Automatic
+// promotions apply; a rejected order has Err set in its result.
+func (s *Orders) PlaceBulk(ctx context.Context, orders []repository.BulkOrder) ([]repository.BulkResult, error) {
+	results := make([]repository.BulkResult, 0, len(orders))
+	err := s.repo.Tx(ctx, func(tx repository.OrderTx) error {
+		now := s.now()
+		promos, err := tx.ActivePromotions(ctx, "", now)
+		if err != nil {
+			return err
+		}
+		for _, order := range orders {
+			var result repository.BulkResult
+			failed, err := tx.Savepoint(ctx, func() error {
+				var err error
+				result, err = placeBulkOrder(ctx, tx, order, promos, now)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			if failed != nil {
+				result = repository.BulkResult{Err: failed}
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	return results, err
+}
+
+// placeBulkOrder places a single order of a bulk request for an existing
+// customer: it prices the items, applies automatic promotions and deducts
+// stock.
+func placeBulkOrder(ctx context.Context, tx repository.OrderTx, order repository.BulkOrder, promos []db.Promotion, now time.Time) (repository.BulkResult, error) {
+	var result repository.BulkResult
+	if len(order.Items) == 0 {
+		return result, repository.ErrNoItems
+	}
+	lines, err := saleLines(ctx, tx, order.Items)
+	if err != nil {
+		return result, err
+	}
+	applied, discount := applyPromotions(lines, promos, now)
+	result.Discount = discount
+	result.Total = roundMoney(linesSubtotal(lines) - discount)
+
+	customerID, err := tx.CustomerByName(ctx, order.CustomerName)
+	if err != nil {
+		return result, err
+	}
+	result.OrderID, err = tx.InsertOrder(ctx, db.Order{
+		CustomerID:     customerID,
+		TotalAmount:    result.Total,
+		Status:         "open",
+		PaymentMethod:  "cash",
+		DiscountAmount: discount,
+	}, lines)
+	if err != nil {
+		return result, err
+	}
+	if err := tx.UsePromotions(ctx, result.OrderID, applied); err != nil {
+		return result, err
+	}
+	result.Usage = ingredientUsage(lines)
+	return result, tx.DeductStock(ctx, result.Usage)
+}
+
+// RejectReason turns the error of a rejected bulk order into the reason
+// reported to the client. Unexpected errors are logged.
+func RejectReason(err error) string {
+	switch {
+	case errors.Is(err, repository.ErrInsufficientStock):
+		return "insufficient_inventory"
+	case errors.Is(err, repository.ErrUnknownMenuItem):
+		return "unknown_menu_item"
+	case errors.Is(err, repository.ErrUnknownCustomer):
+		return "unknown_customer"
+	case errors.Is(err, repository.ErrInvalidModifiers):
+		return "invalid_modifiers"
+	case errors.Is(err, repository.ErrInvalidBundle):
+		return "invalid_bundle_choices"
+	case errors.Is(err, repository.ErrInvalidQuantity), errors.Is(err, repository.ErrNoItems):
+		return "invalid_items"
+	case errors.Is(err, repository.ErrPromoCodeUsedUp):
+		return "promotion_unavailable"
+	}
+	log.Println("Bulk order failed:", err)
+	return "processing_error"
+}
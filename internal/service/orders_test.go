@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+func newTestOrders() (*Orders, *fakeOrders) {
+	f := newFakeOrders()
+	f.items = testCatalog()
+	f.stock = map[string]float64{"milk": 1000, "espresso": 100, "oat_milk": 1000, "flour": 500}
+	return NewOrders(f, time.UTC), f
+}
+
+func lattes(n int) []db.OrderItem {
+	return []db.OrderItem{{MenuItemID: "latte", Quantity: n}}
+}
+
+func TestCreatePlacesOrder(t *testing.T) {
+	s, f := newTestOrders()
+	f.promos = []db.Promotion{{ID: 7, Name: "Coffee week", DiscountType: "percentage", Value: 10, Category: "coffee"}}
+	f.loyalty[1] = []db.LoyaltyTransaction{{TransactionType: "earned", Points: 500, Punches: 9}}
+
+	receipt, err := s.Create(context.Background(), db.Order{
+		CustomerID: 1, PaymentMethod: "cash", RedeemPoints: 100, Items: lattes(2),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 6.00 less 0.60 off coffee, one latte free for the punch card and 1.00 in points
+	want := db.OrderReceipt{
+		OrderID:           1,
+		TotalAmount:       1.4,
+		DiscountAmount:    4.6,
+		AppliedPromotions: []db.AppliedPromotion{{PromotionID: 7, Name: "Coffee week", DiscountAmount: 0.6}},
+		Loyalty:           db.LoyaltyRedemption{PointsRedeemed: 100, PointsDiscount: 1, FreeCoffees: 1, RewardDiscount: 3},
+	}
+	if !reflect.DeepEqual(receipt, want) {
+		t.Errorf("receipt = %+v\nwant %+v", receipt, want)
+	}
+	if o := f.orders[1]; o.TotalAmount != 1.4 || o.DiscountAmount != 4.6 || o.Status != "open" {
+		t.Errorf("stored order = %+v", o.Order)
+	}
+	if f.stock["milk"] != 600 || f.stock["espresso"] != 64 {
+		t.Errorf("stock = %v, want 600 milk and 64 espresso", f.stock)
+	}
+	if f.used[7] != 1 {
+		t.Errorf("promotion used %d times, want 1", f.used[7])
+	}
+	if points, punches, _ := f.LoyaltyBalance(context.Background(), 1); points != 400 || punches != 0 {
+		t.Errorf("loyalty = %d points, %d punches; want 400, 0", points, punches)
+	}
+}
+
+func TestCreateRollsBack(t *testing.T) {
+	s, f := newTestOrders()
+	f.stock["espresso"] = 20
+	f.promos = []db.Promotion{{ID: 7, Name: "Coffee week", DiscountType: "percentage", Value: 10}}
+	f.loyalty[1] = []db.LoyaltyTransaction{{TransactionType: "earned", Points: 500}}
+
+	_, err := s.Create(context.Background(), db.Order{
+		CustomerID: 1, PaymentMethod: "cash", RedeemPoints: 100, Items: lattes(2),
+	})
+	if !errors.Is(err, repository.ErrInsufficientStock) {
+		t.Fatalf("err = %v, want insufficient stock", err)
+	}
+	if len(f.orders) != 0 || f.used[7] != 0 || len(f.loyalty[1]) != 1 || f.stock["milk"] != 1000 {
+		t.Errorf("a failed order left changes: orders %v, promotion uses %v, loyalty %v, stock %v",
+			f.orders, f.used, f.loyalty[1], f.stock)
+	}
+}
+
+func TestCreateWithoutItems(t *testing.T) {
+	s, f := newTestOrders()
+
+	receipt, err := s.Create(context.Background(), db.Order{CustomerID: 2, PaymentMethod: "card", TotalAmount: 7.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.OrderID != 1 || receipt.TotalAmount != 7.5 || f.orders[1].Status != "open" {
+		t.Errorf("receipt = %+v, stored %+v", receipt, f.orders[1].Order)
+	}
+
+	_, err = s.Create(context.Background(), db.Order{CustomerID: 9, PaymentMethod: "card", TotalAmount: 7.5})
+	if !errors.Is(err, repository.ErrUnknownCustomer) {
+		t.Errorf("err = %v, want unknown customer", err)
+	}
+
+	_, err = s.Create(context.Background(), db.Order{CustomerID: 1, PaymentMethod: "card", TotalAmount: 7.5, PromoCode: "WELCOME", RedeemPoints: 100})
+	var e *repository.Error
+	if !errors.As(err, &e) || len(e.Fields) != 2 || e.Fields[0].Field != "promo_code" || e.Fields[1].Field != "redeem_points" {
+		t.Errorf("promo code and points without items: err = %v, want field errors for both", err)
+	}
+	if len(f.orders) != 1 {
+		t.Errorf("%d orders stored, want 1", len(f.orders))
+	}
+}
+
+func TestCloseEarnsOnce(t *testing.T) {
+	ctx := context.Background()
+	s, f := newTestOrders()
+	receipt, err := s.Create(ctx, db.Order{CustomerID: 1, PaymentMethod: "cash", Items: lattes(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Close(ctx, receipt.OrderID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(ctx, receipt.OrderID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("closing twice: err = %v, want not found", err)
+	}
+	// An order put back to open outside the service does not earn again.
+	o := f.orders[receipt.OrderID]
+	o.Status = "open"
+	f.orders[receipt.OrderID] = o
+	if err := s.Close(ctx, receipt.OrderID); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []db.LoyaltyTransaction{{OrderID: &receipt.OrderID, TransactionType: "earned", Points: 6, Punches: 2}}
+	if !reflect.DeepEqual(f.loyalty[1], want) {
+		t.Errorf("loyalty = %+v, want %+v", f.loyalty[1], want)
+	}
+}
+
+func TestCloseSkipsRewardCoffees(t *testing.T) {
+	ctx := context.Background()
+	s, f := newTestOrders()
+	f.loyalty[1] = []db.LoyaltyTransaction{{TransactionType: "earned", Punches: 9}}
+	receipt, err := s.Create(ctx, db.Order{CustomerID: 1, PaymentMethod: "cash", Items: lattes(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(ctx, receipt.OrderID); err != nil {
+		t.Fatal(err)
+	}
+
+	// The free latte earns no punch; the paid one does.
+	earned := f.loyalty[1][len(f.loyalty[1])-1]
+	if earned.TransactionType != "earned" || earned.Points != 3 || earned.Punches != 1 {
+		t.Errorf("earned = %+v, want 3 points and 1 punch", earned)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	s, f := newTestOrders()
+	receipt, err := s.Create(ctx, db.Order{CustomerID: 1, PaymentMethod: "cash", Items: lattes(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := receipt.OrderID
+
+	closed := db.Order{CustomerID: 1, PaymentMethod: "card", TotalAmount: 0, Status: "closed"}
+	if err := s.Update(ctx, id, closed); err != nil {
+		t.Fatal(err)
+	}
+	if o := f.orders[id]; o.Status != "closed" || o.TotalAmount != 0 || o.PaymentMethod != "card" {
+		t.Errorf("stored order = %+v", o.Order)
+	}
+	// Closing by update earns like Close: the punch, and no points on a total of 0.
+	if l := f.loyalty[1]; len(l) != 1 || l[0].Points != 0 || l[0].Punches != 1 {
+		t.Errorf("loyalty = %+v, want one punch", l)
+	}
+
+	reopen := closed
+	reopen.Status = ""
+	if err := s.Update(ctx, id, reopen); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("reopening: err = %v, want conflict", err)
+	}
+	if err := s.Update(ctx, 99, closed); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("missing order: err = %v, want not found", err)
+	}
+}
+
+func TestPlaceBulk(t *testing.T) {
+	s, f := newTestOrders()
+	f.stock["espresso"] = 40
+	f.promos = []db.Promotion{
+		{ID: 1, Name: "Pastry deal", DiscountType: "fixed", Value: 0.5, Category: "Pastry"},
+		{ID: 2, Name: "Code only", DiscountType: "percentage", Value: 50, Code: "HALF"},
+	}
+
+	results, err := s.PlaceBulk(context.Background(), []repository.BulkOrder{
+		{CustomerName: "John Smith", Items: []db.OrderItem{{MenuItemID: "latte", Quantity: 1}, {MenuItemID: "croissant", Quantity: 1}}},
+		{CustomerName: "Jon Smith", Items: lattes(1)},
+		{CustomerName: "Emily Johnson", Items: []db.OrderItem{{MenuItemID: "croissant", Quantity: 1}, {MenuItemID: "latte", Quantity: 2}}},
+		{CustomerName: "Emily Johnson"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []repository.BulkResult{
+		{OrderID: 1, Total: 5, Discount: 0.5, Usage: map[string]float64{"milk": 200, "espresso": 18, "flour": 50}},
+		{Err: results[1].Err},
+		{Err: results[2].Err},
+		{Err: results[3].Err},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results = %+v\nwant %+v", results, want)
+	}
+	for i, reason := range []string{"unknown_customer", "insufficient_inventory", "invalid_items"} {
+		if got := RejectReason(results[i+1].Err); got != reason {
+			t.Errorf("order %d rejected for %s, want %s", i+2, got, reason)
+		}
+	}
+	// The rejected orders changed nothing; the accepted one is kept.
+	if len(f.orders) != 1 || f.stock["flour"] != 450 || f.stock["espresso"] != 22 || f.used[1] != 1 {
+		t.Errorf("orders %v, stock %v, promotion uses %v", f.orders, f.stock, f.used)
+	}
+}
+
+func TestRedeemLoyalty(t *testing.T) {
+	lines := []repository.OrderLine{{MenuItemID: "latte", Categories: []string{"Beverage"}, Quantity: 3, UnitPrice: 3}}
+	tests := []struct {
+		name                   string
+		points, punches, spend int
+		maxDiscount            float64
+		want                   db.LoyaltyRedemption
+		err                    error
+	}{
+		{"nothing to redeem", 50, 8, 0, 9, db.LoyaltyRedemption{}, nil},
+		{"one full card", 0, 9, 0, 9, db.LoyaltyRedemption{FreeCoffees: 1, RewardDiscount: 3}, nil},
+		{"two full cards", 0, 18, 0, 9, db.LoyaltyRedemption{FreeCoffees: 2, RewardDiscount: 6}, nil},
+		{"reward capped by what is payable", 0, 9, 0, 2, db.LoyaltyRedemption{FreeCoffees: 1, RewardDiscount: 2}, nil},
+		{"points", 300, 0, 250, 9, db.LoyaltyRedemption{PointsRedeemed: 250, PointsDiscount: 2.5}, nil},
+		{"points capped by what is payable", 1000, 9, 1000, 4, db.LoyaltyRedemption{FreeCoffees: 1, RewardDiscount: 3, PointsRedeemed: 100, PointsDiscount: 1}, nil},
+		{"more points than the balance", 10, 0, 11, 9, db.LoyaltyRedemption{}, repository.ErrInsufficientPoints},
+		{"negative points", 10, 0, -1, 9, db.LoyaltyRedemption{}, repository.ErrInvalidRedeemAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := redeemLoyalty(tt.points, tt.punches, tt.spend, lines, tt.maxDiscount)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("redemption = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
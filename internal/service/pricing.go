@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// saleLines prices the requested items from the catalog. It validates the
+// modifiers and bundle choices selected on them and works out what one unit
+// of every line takes from stock.
+func saleLines(ctx context.Context, catalog repository.Catalog, items []db.OrderItem) ([]repository.OrderLine, error) {
+	lines := make([]repository.OrderLine, 0, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: menu item %s", repository.ErrInvalidQuantity, item.MenuItemID)
+		}
+		sale, err := catalog.SaleItem(ctx, item.MenuItemID)
+		if err != nil {
+			return nil, err
+		}
+		modifiers, err := selectModifiers(sale, item.Modifiers)
+		if err != nil {
+			return nil, err
+		}
+		parts, err := bundleParts(ctx, catalog, sale, item.Choices)
+		if err != nil {
+			return nil, err
+		}
+
+		line := repository.OrderLine{
+			MenuItemID: item.MenuItemID,
+			Categories: sale.Categories,
+			Quantity:   item.Quantity,
+			UnitPrice:  sale.Price,
+			Modifiers:  modifiers,
+		}
+		for _, m := range modifiers {
+			line.UnitPrice += m.PriceDelta
+		}
+		line.UnitPrice = roundMoney(line.UnitPrice)
+		line.Usage = unitUsage(sale, parts, modifiers)
+
+		shares := allocateBundleRevenue(lineTotal(line), parts)
+		for i, p := range parts {
+			line.Parts = append(line.Parts, repository.BundlePart{
+				MenuItemID: p.MenuItemID,
+				Quantity:   p.Quantity * line.Quantity,
+				Revenue:    shares[i],
+			})
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// selectModifiers validates the modifiers selected for a menu item against
+// the modifier groups attached to it and returns them with their prices.
+func selectModifiers(item repository.SaleItem, selected []int) ([]db.Modifier, error) {
+	available := make(map[int]db.Modifier)
+	groupOf := make(map[int]int)
+	for _, g := range item.ModifierGroups {
+		for _, m := range g.Modifiers {
+			available[m.ID] = m
+			groupOf[m.ID] = g.ID
+		}
+	}
+
+	var modifiers []db.Modifier
+	seen := make(map[int]bool)
+	counts := make(map[int]int)
+	for _, id := range selected {
+		if seen[id] {
+			return nil, fmt.Errorf("%w: modifier %d selected twice for menu item %s", repository.ErrInvalidModifiers, id, item.ID)
+		}
+		seen[id] = true
+		m, ok := available[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: modifier %d is not available for menu item %s", repository.ErrInvalidModifiers, id, item.ID)
+		}
+		counts[groupOf[id]]++
+		modifiers = append(modifiers, m)
+	}
+	sort.Slice(modifiers, func(i, j int) bool { return modifiers[i].ID < modifiers[j].ID })
+
+	for _, g := range item.ModifierGroups {
+		if counts[g.ID] < g.MinSelections {
+			return nil, fmt.Errorf("%w: choose at least %d from %s for menu item %s", repository.ErrInvalidModifiers, g.MinSelections, g.Name, item.ID)
+		}
+		if counts[g.ID] > g.MaxSelections {
+			return nil, fmt.Errorf("%w: choose at most %d from %s for menu item %s", repository.ErrInvalidModifiers, g.MaxSelections, g.Name, item.ID)
+		}
+	}
+	return modifiers, nil
+}
+
+// bundlePart is a menu item sold as part of a bundle, per bundle sold.
+type bundlePart struct {
+	MenuItemID      string
+	Quantity        int
+	StandalonePrice float64
+	Recipe          map[string]float64
+}
+
+// bundleParts turns the components of a bundle into the menu items sold,
+// using the customer's choices for choice slots. It returns nil for items
+// that are not bundles.
+func bundleParts(ctx context.Context, catalog repository.Catalog, bundle repository.SaleItem, choices []db.BundleChoice) ([]bundlePart, error) {
+	if len(bundle.Components) == 0 {
+		if len(choices) > 0 {
+			return nil, fmt.Errorf("%w: menu item %s is not a bundle", repository.ErrInvalidBundle, bundle.ID)
+		}
+		return nil, nil
+	}
+
+	picked := make(map[int]string, len(choices))
+	for _, c := range choices {
+		picked[c.ComponentID] = c.MenuItemID
+	}
+
+	parts := make([]bundlePart, 0, len(bundle.Components))
+	for _, c := range bundle.Components {
+		id := c.MenuItemID
+		if c.ChoiceCategoryID != 0 {
+			if id = picked[c.ID]; id == "" {
+				return nil, fmt.Errorf("%w: choose an item for %s", repository.ErrInvalidBundle, c.Name)
+			}
+		}
+		delete(picked, c.ID)
+
+		item, err := catalog.SaleItem(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if c.ChoiceCategoryID != 0 {
+			if len(item.Components) > 0 || !containsInt(item.CategoryIDs, c.ChoiceCategoryID) {
+				return nil, fmt.Errorf("%w: %s must be a %s item", repository.ErrInvalidBundle, c.Name, c.ChoiceCategory)
+			}
+			if c.ChoiceSize != "" && item.Size != c.ChoiceSize {
+				return nil, fmt.Errorf("%w: %s must be %s", repository.ErrInvalidBundle, c.Name, c.ChoiceSize)
+			}
+		}
+		parts = append(parts, bundlePart{
+			MenuItemID:      id,
+			Quantity:        c.Quantity,
+			StandalonePrice: item.Price,
+			Recipe:          item.Recipe,
+		})
+	}
+	if len(picked) > 0 {
+		return nil, fmt.Errorf("%w: choice for unknown component of bundle %s", repository.ErrInvalidBundle, bundle.ID)
+	}
+	return parts, nil
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// allocateBundleRevenue splits the revenue of a bundle line across its parts
+// in proportion to their standalone prices. The last part takes the rounding
+// remainder so the shares add up to the line total.
+func allocateBundleRevenue(total float64, parts []bundlePart) []float64 {
+	shares := make([]float64, len(parts))
+	var standalone float64
+	for _, p := range parts {
+		standalone += p.StandalonePrice * float64(p.Quantity)
+	}
+	remaining := roundMoney(total)
+	for i, p := range parts {
+		if i == len(parts)-1 {
+			shares[i] = remaining
+			break
+		}
+		share := total / float64(len(parts))
+		if standalone > 0 {
+			share = total * p.StandalonePrice * float64(p.Quantity) / standalone
+		}
+		shares[i] = roundMoney(share)
+		remaining = roundMoney(remaining - shares[i])
+	}
+	return shares
+}
+
+func lineTotal(l repository.OrderLine) float64 {
+	return l.UnitPrice * float64(l.Quantity)
+}
+
+// inCategory reports whether the line's item belongs to the named category
+// or one of its subcategories.
+func inCategory(l repository.OrderLine, name string) bool {
+	for _, c := range l.Categories {
+		if strings.EqualFold(c, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func linesSubtotal(lines []repository.OrderLine) float64 {
+	var sum float64
+	for _, l := range lines {
+		sum += lineTotal(l)
+	}
+	return roundMoney(sum)
+}
+
+// applyPromotions works out the discount each promotion gives on the lines
+// at the given time, which must be in the shop's time zone. Discounts stack,
+// but the total never exceeds the order subtotal.
+func applyPromotions(lines []repository.OrderLine, promos []db.Promotion, at time.Time) ([]db.AppliedPromotion, float64) {
+	remaining := linesSubtotal(lines)
+	var applied []db.AppliedPromotion
+	var total float64
+	for _, p := range promos {
+		if !inTimeWindow(p, at) {
+			continue
+		}
+		amount := roundMoney(math.Min(promotionDiscount(p, lines), remaining))
+		if amount <= 0 {
+			continue
+		}
+		remaining = roundMoney(remaining - amount)
+		total += amount
+		applied = append(applied, db.AppliedPromotion{
+			PromotionID:    p.ID,
+			Name:           p.Name,
+			Code:           p.Code,
+			DiscountAmount: amount,
+		})
+	}
+	return applied, roundMoney(total)
+}
+
+// promotionDiscount returns the discount a single promotion gives on the lines
+// it targets, ignoring other promotions.
+func promotionDiscount(p db.Promotion, lines []repository.OrderLine) float64 {
+	var scoped []repository.OrderLine
+	for _, l := range lines {
+		if p.MenuItemID != "" && l.MenuItemID != p.MenuItemID {
+			continue
+		}
+		if p.Category != "" && !inCategory(l, p.Category) {
+			continue
+		}
+		scoped = append(scoped, l)
+	}
+	if len(scoped) == 0 {
+		return 0
+	}
+
+	switch p.DiscountType {
+	case "percentage":
+		return linesSubtotal(scoped) * p.Value / 100
+	case "fixed":
+		return math.Min(p.Value, linesSubtotal(scoped))
+	case "buy_x_get_y":
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return 0
+		}
+		// Every group of buy+get units makes the cheapest get units free.
+		var units []float64
+		for _, l := range scoped {
+			for i := 0; i < l.Quantity; i++ {
+				units = append(units, l.UnitPrice)
+			}
+		}
+		free := len(units) / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+		sort.Float64s(units)
+		var discount float64
+		for i := 0; i < free; i++ {
+			discount += units[i]
+		}
+		return discount
+	}
+	return 0
+}
+
+// inTimeWindow reports whether the wall-clock time of at, in at's location,
+// falls into the promotion's daily window. Windows that end before they
+// start wrap around midnight.
+func inTimeWindow(p db.Promotion, at time.Time) bool {
+	if p.StartTime == "" || p.EndTime == "" {
+		return true
+	}
+	start, err1 := clockSeconds(p.StartTime)
+	end, err2 := clockSeconds(p.EndTime)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	now := at.Hour()*3600 + at.Minute()*60 + at.Second()
+	if start <= end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+// clockSeconds parses "15:04" or "15:04:05" into seconds since midnight.
+func clockSeconds(clock string) (int, error) {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, clock); err == nil {
+			return t.Hour()*3600 + t.Minute()*60 + t.Second(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time of day %q", clock)
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// testCatalog is a small menu: three coffees, one of which needs a milk
+// chosen, a pastry and a breakfast bundle of the pastry with a medium coffee
+// of choice. Recipes are in stock units.
+func testCatalog() map[string]repository.SaleItem {
+	coffee := []string{"Beverage", "Coffee"}
+	return map[string]repository.SaleItem{
+		"latte": {
+			Price: 3, Size: "medium", Categories: coffee, CategoryIDs: []int{1, 2},
+			Recipe: map[string]float64{"milk": 200, "espresso": 18},
+			ModifierGroups: []db.ModifierGroup{
+				{ID: 1, Name: "Milk", MinSelections: 0, MaxSelections: 1, Modifiers: []db.Modifier{
+					{ID: 1, Name: "Oat milk", PriceDelta: 0.5, Ingredients: []db.ModifierIngredient{
+						{IngredientID: "milk", QuantityDelta: -250},
+						{IngredientID: "oat_milk", QuantityDelta: 200},
+					}},
+				}},
+				{ID: 2, Name: "Extras", MinSelections: 0, MaxSelections: 1, Modifiers: []db.Modifier{
+					{ID: 2, Name: "Extra shot", PriceDelta: 0.75, Ingredients: []db.ModifierIngredient{
+						{IngredientID: "espresso", QuantityDelta: 18},
+					}},
+					{ID: 3, Name: "Syrup", PriceDelta: 0.25},
+				}},
+			},
+		},
+		"espresso": {
+			Price: 2, Size: "small", Categories: coffee, CategoryIDs: []int{1, 2},
+			Recipe: map[string]float64{"espresso": 18},
+		},
+		"milk-first": {
+			Price: 3, Size: "medium", Categories: coffee, CategoryIDs: []int{1, 2},
+			ModifierGroups: []db.ModifierGroup{{ID: 1, Name: "Milk", MinSelections: 1, MaxSelections: 1}},
+		},
+		"croissant": {
+			Price: 2.5, Size: "medium", Categories: []string{"Food", "Pastry"}, CategoryIDs: []int{3, 4},
+			Recipe: map[string]float64{"flour": 50},
+		},
+		"breakfast": {
+			Price: 5, Size: "medium", Categories: []string{"Combo"}, CategoryIDs: []int{5},
+			Components: []db.BundleComponent{
+				{ID: 1, Name: "Pastry", MenuItemID: "croissant", Quantity: 1},
+				{ID: 2, Name: "Coffee", ChoiceCategoryID: 2, ChoiceCategory: "Coffee", ChoiceSize: "medium", Quantity: 1},
+			},
+		},
+	}
+}
+
+func TestSaleLines(t *testing.T) {
+	f := newFakeOrders()
+	f.items = testCatalog()
+
+	lines, err := saleLines(context.Background(), f, []db.OrderItem{
+		{MenuItemID: "latte", Quantity: 2, Modifiers: []int{2, 1}},
+		{MenuItemID: "breakfast", Quantity: 2, Choices: []db.BundleChoice{{ComponentID: 2, MenuItemID: "latte"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	latte := lines[0]
+	if latte.UnitPrice != 4.25 {
+		t.Errorf("latte unit price = %v, want 4.25", latte.UnitPrice)
+	}
+	if ids := []int{latte.Modifiers[0].ID, latte.Modifiers[1].ID}; !reflect.DeepEqual(ids, []int{1, 2}) {
+		t.Errorf("latte modifiers = %v, want sorted by id", ids)
+	}
+	// Oat milk removes more milk than the recipe uses; no milk is put back.
+	wantUsage := map[string]float64{"espresso": 36, "oat_milk": 200}
+	if !reflect.DeepEqual(latte.Usage, wantUsage) {
+		t.Errorf("latte usage = %v, want %v", latte.Usage, wantUsage)
+	}
+
+	bundle := lines[1]
+	wantParts := []repository.BundlePart{
+		{MenuItemID: "croissant", Quantity: 2, Revenue: 4.55},
+		{MenuItemID: "latte", Quantity: 2, Revenue: 5.45},
+	}
+	if !reflect.DeepEqual(bundle.Parts, wantParts) {
+		t.Errorf("bundle parts = %+v, want %+v", bundle.Parts, wantParts)
+	}
+	wantUsage = map[string]float64{"flour": 50, "milk": 200, "espresso": 18}
+	if !reflect.DeepEqual(bundle.Usage, wantUsage) {
+		t.Errorf("bundle usage = %v, want %v", bundle.Usage, wantUsage)
+	}
+	if got := ingredientUsage(lines); got["espresso"] != 108 || got["flour"] != 100 {
+		t.Errorf("order usage = %v, want 108 espresso and 100 flour", got)
+	}
+}
+
+func TestSaleLinesRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		item db.OrderItem
+		err  error
+		msg  string
+	}{
+		{"zero quantity", db.OrderItem{MenuItemID: "latte"}, repository.ErrInvalidQuantity, "menu item latte"},
+		{"unknown item", db.OrderItem{MenuItemID: "tea", Quantity: 1}, repository.ErrUnknownMenuItem, "tea"},
+		{"modifier twice", db.OrderItem{MenuItemID: "latte", Quantity: 1, Modifiers: []int{1, 1}}, repository.ErrInvalidModifiers, "selected twice"},
+		{"modifier of another item", db.OrderItem{MenuItemID: "espresso", Quantity: 1, Modifiers: []int{1}}, repository.ErrInvalidModifiers, "not available"},
+		{"too few from a group", db.OrderItem{MenuItemID: "milk-first", Quantity: 1}, repository.ErrInvalidModifiers, "at least 1 from Milk"},
+		{"too many from a group", db.OrderItem{MenuItemID: "latte", Quantity: 1, Modifiers: []int{2, 3}}, repository.ErrInvalidModifiers, "at most 1 from Extras"},
+		{"choice for a plain item", db.OrderItem{MenuItemID: "latte", Quantity: 1, Choices: []db.BundleChoice{{ComponentID: 2, MenuItemID: "latte"}}}, repository.ErrInvalidBundle, "not a bundle"},
+		{"choice missing", db.OrderItem{MenuItemID: "breakfast", Quantity: 1}, repository.ErrInvalidBundle, "choose an item for Coffee"},
+		{"choice outside the category", db.OrderItem{MenuItemID: "breakfast", Quantity: 1, Choices: []db.BundleChoice{{ComponentID: 2, MenuItemID: "croissant"}}}, repository.ErrInvalidBundle, "must be a Coffee item"},
+		{"choice of the wrong size", db.OrderItem{MenuItemID: "breakfast", Quantity: 1, Choices: []db.BundleChoice{{ComponentID: 2, MenuItemID: "espresso"}}}, repository.ErrInvalidBundle, "must be medium"},
+		{"choice for a fixed component", db.OrderItem{MenuItemID: "breakfast", Quantity: 1, Choices: []db.BundleChoice{{ComponentID: 2, MenuItemID: "latte"}, {ComponentID: 7, MenuItemID: "latte"}}}, repository.ErrInvalidBundle, "unknown component"},
+	}
+
+	f := newFakeOrders()
+	f.items = testCatalog()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := saleLines(context.Background(), f, []db.OrderItem{tt.item})
+			if !errors.Is(err, tt.err) || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("err = %v, want %v containing %q", err, tt.err, tt.msg)
+			}
+		})
+	}
+}
+
+// testLines are two lattes, an espresso and a croissant: 10.50 in all, of
+// which 8.00 is coffee.
+func testLines() []repository.OrderLine {
+	coffee := []string{"Beverage", "Coffee"}
+	return []repository.OrderLine{
+		{MenuItemID: "latte", Categories: coffee, Quantity: 2, UnitPrice: 3},
+		{MenuItemID: "espresso", Categories: coffee, Quantity: 1, UnitPrice: 2},
+		{MenuItemID: "croissant", Categories: []string{"Food", "Pastry"}, Quantity: 1, UnitPrice: 2.5},
+	}
+}
+
+func TestPromotionDiscount(t *testing.T) {
+	tests := []struct {
+		name  string
+		promo db.Promotion
+		want  float64
+	}{
+		{"percentage of the order", db.Promotion{DiscountType: "percentage", Value: 10}, 1.05},
+		{"percentage of a category", db.Promotion{DiscountType: "percentage", Value: 10, Category: "coffee"}, 0.8},
+		{"percentage of a parent category", db.Promotion{DiscountType: "percentage", Value: 10, Category: "Beverage"}, 0.8},
+		{"category not ordered", db.Promotion{DiscountType: "percentage", Value: 10, Category: "Tea"}, 0},
+		{"fixed", db.Promotion{DiscountType: "fixed", Value: 1}, 1},
+		{"fixed above the item price", db.Promotion{DiscountType: "fixed", Value: 5, MenuItemID: "croissant"}, 2.5},
+		{"buy 2 get 1 with mixed prices", db.Promotion{DiscountType: "buy_x_get_y", BuyQuantity: 2, GetQuantity: 1, Category: "Coffee"}, 2},
+		{"buy 1 get 1 gives the cheapest free", db.Promotion{DiscountType: "buy_x_get_y", BuyQuantity: 1, GetQuantity: 1}, 4.5},
+		{"buy 2 get 1 with one item", db.Promotion{DiscountType: "buy_x_get_y", BuyQuantity: 2, GetQuantity: 1, MenuItemID: "croissant"}, 0},
+		{"buy x get y without quantities", db.Promotion{DiscountType: "buy_x_get_y"}, 0},
+		{"unknown type", db.Promotion{DiscountType: "free", Value: 10}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := promotionDiscount(tt.promo, testLines()); got != tt.want {
+				t.Errorf("discount = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPromotions(t *testing.T) {
+	at := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+	coffeeWeek := db.Promotion{ID: 1, Name: "Coffee week", DiscountType: "percentage", Value: 10, Category: "Coffee"}
+	morning := db.Promotion{ID: 2, Name: "Morning", DiscountType: "fixed", Value: 1, StartTime: "07:00", EndTime: "10:00"}
+	welcome := db.Promotion{ID: 3, Name: "Welcome", Code: "WELCOME", DiscountType: "fixed", Value: 1}
+	big := db.Promotion{ID: 4, Name: "Big", DiscountType: "fixed", Value: 8}
+	tea := db.Promotion{ID: 5, Name: "Tea time", DiscountType: "percentage", Value: 50, Category: "Tea"}
+
+	tests := []struct {
+		name    string
+		promos  []db.Promotion
+		applied []db.AppliedPromotion
+		total   float64
+	}{
+		{"none", nil, nil, 0},
+		{"discounts stack", []db.Promotion{coffeeWeek, welcome}, []db.AppliedPromotion{
+			{PromotionID: 1, Name: "Coffee week", DiscountAmount: 0.8},
+			{PromotionID: 3, Name: "Welcome", Code: "WELCOME", DiscountAmount: 1},
+		}, 1.8},
+		{"outside the time window", []db.Promotion{morning}, nil, 0},
+		{"nothing to discount", []db.Promotion{tea}, nil, 0},
+		{"capped at the subtotal", []db.Promotion{big, big, welcome}, []db.AppliedPromotion{
+			{PromotionID: 4, Name: "Big", DiscountAmount: 8},
+			{PromotionID: 4, Name: "Big", DiscountAmount: 2.5},
+		}, 10.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied, total := applyPromotions(testLines(), tt.promos, at)
+			if !reflect.DeepEqual(applied, tt.applied) || total != tt.total {
+				t.Errorf("applied %+v, total %v; want %+v, %v", applied, total, tt.applied, tt.total)
+			}
+		})
+	}
+}
+
+func TestInTimeWindow(t *testing.T) {
+	happyHour := db.Promotion{StartTime: "15:00", EndTime: "17:00"}
+	lateNight := db.Promotion{StartTime: "22:00:00", EndTime: "02:00:00"}
+	tests := []struct {
+		name  string
+		promo db.Promotion
+		clock string
+		want  bool
+	}{
+		{"no window", db.Promotion{}, "03:00:00", true},
+		{"at the start", happyHour, "15:00:00", true},
+		{"inside", happyHour, "16:30:00", true},
+		{"just before", happyHour, "14:59:59", false},
+		{"at the end", happyHour, "17:00:00", false},
+		{"past midnight window before midnight", lateNight, "23:00:00", true},
+		{"past midnight window after midnight", lateNight, "01:59:59", true},
+		{"past midnight window at its end", lateNight, "02:00:00", false},
+		{"past midnight window at noon", lateNight, "12:00:00", false},
+		{"invalid window", db.Promotion{StartTime: "25:00", EndTime: "26:00"}, "12:00:00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock, _ := time.Parse("15:04:05", tt.clock)
+			at := time.Date(2026, 10, 19, clock.Hour(), clock.Minute(), clock.Second(), 0, time.UTC)
+			if got := inTimeWindow(tt.promo, at); got != tt.want {
+				t.Errorf("inTimeWindow at %s = %v, want %v", tt.clock, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeWindowInShopZone(t *testing.T) {
+	almaty := time.FixedZone("Almaty", 5*60*60)
+	happyHour := db.Promotion{StartTime: "15:00", EndTime: "17:00"}
+
+	// 10:00 UTC is 15:00 in the shop
+	at := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	if inTimeWindow(happyHour, at) {
+		t.Error("happy hour applies at 10:00 UTC")
+	}
+	if !inTimeWindow(happyHour, at.In(almaty)) {
+		t.Error("happy hour does not apply at 15:00 in the shop")
+	}
+	if loc := NewOrders(newFakeOrders(), almaty).now().Location(); loc != almaty {
+		t.Errorf("now is in %v, want the shop's zone", loc)
+	}
+}
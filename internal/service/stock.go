@@ -0,0 +1,44 @@
+package service
+
+import (
+	"frappuccino/internal/db"
+	"frappuccino/internal/repository"
+)
+
+// unitUsage returns what one unit of a line takes from stock: the recipe of
+// the item, or of the parts of a bundle, plus the ingredient deltas of the
+// selected modifiers. A modifier may remove more than the recipe uses (oat
+// milk in a drink without milk); nothing is put back into stock in that case.
+func unitUsage(item repository.SaleItem, parts []bundlePart, modifiers []db.Modifier) map[string]float64 {
+	usage := make(map[string]float64)
+	if len(parts) == 0 {
+		parts = []bundlePart{{Quantity: 1, Recipe: item.Recipe}}
+	}
+	for _, p := range parts {
+		for id, quantity := range p.Recipe {
+			usage[id] += quantity * float64(p.Quantity)
+		}
+	}
+	for _, m := range modifiers {
+		for _, ing := range m.Ingredients {
+			usage[ing.IngredientID] += ing.QuantityDelta
+		}
+	}
+	for id, quantity := range usage {
+		if quantity <= 0 {
+			delete(usage, id)
+		}
+	}
+	return usage
+}
+
+// ingredientUsage sums what the order lines take from stock.
+func ingredientUsage(lines []repository.OrderLine) map[string]float64 {
+	usage := make(map[string]float64)
+	for _, l := range lines {
+		for id, quantity := range l.Usage {
+			usage[id] += quantity * float64(l.Quantity)
+		}
+	}
+	return usage
+}
@@ -0,0 +1,172 @@
+// Package validate checks request structs against rules declared in their
+// `validate` struct tags and reports every failing field at once.
+//
+// A tag holds comma-separated rules:
+//
+//	required     the value is not the zero value (a slice is not empty)
+//	gt=N         a number is greater than N
+//	min=N        a number is at least N
+//	max=N        a number is at most N
+//	oneof=a b c  a string is one of the values; empty passes unless required
+//
+// Structs, pointers to structs and slices of structs are checked field by
+// field; errors name the field by its JSON path, e.g. items[1].quantity.
+// Rules that involve more than one field are left to the caller, who adds
+// their failures to the same Errors.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"frappuccino/internal/repository"
+)
+
+// Errors collects the field errors of a request.
+type Errors []repository.FieldError
+
+// Add records that field is invalid; message completes the sentence, e.g.
+// "is required".
+func (e *Errors) Add(field, format string, args ...interface{}) {
+	*e = append(*e, repository.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns nil when there are no errors, and otherwise an ErrInvalid
+// *repository.Error listing them all.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	sentences := make([]string, len(e))
+	for i, f := range e {
+		sentences[i] = f.Field + " " + f.Message
+	}
+	return &repository.Error{
+		Kind:    repository.ErrInvalid,
+		Code:    "validation_failed",
+		Message: strings.Join(sentences, "; "),
+		Fields:  e,
+	}
+}
+
+// Struct checks v, a struct or a pointer to one, against its tags.
+func Struct(v interface{}) Errors {
+	var errs Errors
+	check(&errs, "", reflect.ValueOf(v))
+	return errs
+}
+
+// check descends into structs and the structs held by pointers and slices.
+func check(errs *Errors, path string, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			check(errs, path, v.Elem())
+		}
+	case reflect.Slice:
+		if k := v.Type().Elem().Kind(); k != reflect.Struct && k != reflect.Ptr {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			check(errs, fmt.Sprintf("%s[%d]", path, i), v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue // unexported
+			}
+			name := jsonName(f)
+			if name == "" {
+				continue
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			field := v.Field(i)
+			if tag := f.Tag.Get("validate"); tag != "" {
+				for _, rule := range strings.Split(tag, ",") {
+					if msg := apply(rule, field); msg != "" {
+						errs.Add(name, "%s", msg)
+						break
+					}
+				}
+			}
+			check(errs, name, field)
+		}
+	}
+}
+
+// jsonName is the name of a field in JSON, or "" for fields left out of it.
+func jsonName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// apply checks one rule and returns why the value breaks it, or "".
+func apply(rule string, v reflect.Value) string {
+	name, arg, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		if v.IsZero() || (v.Kind() == reflect.Slice && v.Len() == 0) {
+			return "is required"
+		}
+	case "gt":
+		if number(v) <= parse(rule, arg) {
+			return "must be greater than " + arg
+		}
+	case "min":
+		if number(v) < parse(rule, arg) {
+			if arg == "0" {
+				return "cannot be negative"
+			}
+			return "must be at least " + arg
+		}
+	case "max":
+		if number(v) > parse(rule, arg) {
+			return "must be at most " + arg
+		}
+	case "oneof":
+		s := v.String()
+		if s == "" {
+			return ""
+		}
+		values := strings.Fields(arg)
+		for _, allowed := range values {
+			if s == allowed {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(values, ", ")
+	default:
+		panic("validate: unknown rule " + rule)
+	}
+	return ""
+}
+
+func number(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	panic("validate: " + v.Type().String() + " is not a number")
+}
+
+func parse(rule, arg string) float64 {
+	n, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		panic("validate: bad rule " + rule)
+	}
+	return n
+}